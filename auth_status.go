@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// RateLimit is the API request budget reported in response headers
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// RepoAccess is what a token can do with a repository's review threads
+type RepoAccess struct {
+	Repo            string `json:"repo"`
+	Found           bool   `json:"found"`
+	Private         bool   `json:"private"`
+	Permission      string `json:"permission,omitempty"` // ADMIN, MAINTAIN, WRITE, TRIAGE or READ
+	CanReadThreads  bool   `json:"can_read_threads"`
+	CanWriteThreads bool   `json:"can_write_threads"`
+}
+
+// AuthStatus describes the token a command would use and what it can access
+type AuthStatus struct {
+	Host           string      `json:"host"`
+	Source         string      `json:"source"`
+	TokenType      string      `json:"token_type"`
+	Login          string      `json:"login,omitempty"`
+	Scopes         []string    `json:"scopes"`
+	ScopesReported bool        `json:"scopes_reported"`
+	SSO            string      `json:"sso,omitempty"`
+	RateLimit      *RateLimit  `json:"rate_limit,omitempty"`
+	Repo           *RepoAccess `json:"repo,omitempty"`
+	Problems       []string    `json:"problems"`
+}
+
+// OK reports whether no problems were found
+func (s *AuthStatus) OK() bool {
+	return len(s.Problems) == 0
+}
+
+func (s *AuthStatus) problem(format string, args ...interface{}) {
+	s.Problems = append(s.Problems, fmt.Sprintf(format, args...))
+}
+
+// hasScope reports whether the token was granted an OAuth scope
+func (s *AuthStatus) hasScope(scope string) bool {
+	for _, granted := range s.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenType names the kind of token from its prefix
+func tokenType(token string) string {
+	switch {
+	case strings.HasPrefix(token, "ghp_"):
+		return "classic personal access token"
+	case strings.HasPrefix(token, "github_pat_"):
+		return "fine-grained personal access token"
+	case strings.HasPrefix(token, "gho_"):
+		return "OAuth app token"
+	case strings.HasPrefix(token, "ghu_"):
+		return "GitHub App user token"
+	case strings.HasPrefix(token, "ghs_"):
+		return "GitHub App installation token"
+	default:
+		return "unknown"
+	}
+}
+
+// CheckAuthStatus inspects a token: who it belongs to, its scopes, SSO
+// authorization and rate limit, and, if repo (owner/repo) is given, whether it
+// can read and write that repository's review threads. Problems found are
+// recorded with guidance on fixing them; an error means GitHub couldn't be
+// reached at all.
+func CheckAuthStatus(ctx context.Context, rest *GitHubClient, graphQL *GitHubGraphQLClient, token, source, host, repo string) (*AuthStatus, error) {
+	status := &AuthStatus{
+		Host:      hostName(host),
+		Source:    source,
+		TokenType: tokenType(token),
+		Scopes:    []string{},
+		Problems:  []string{},
+	}
+
+	// Installation tokens can't read /user, so check them against /rate_limit
+	path := "/user"
+	if strings.HasPrefix(token, "ghs_") {
+		path = "/rate_limit"
+	}
+
+	resp, body, err := rest.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	status.readHeaders(resp)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		status.problem("GitHub rejected the token from %s (bad credentials): it may be mistyped, expired or revoked. Create a new token, or run gh auth refresh if it came from the gh CLI", source)
+		return status, nil
+	case resp.StatusCode != http.StatusOK:
+		status.problem("GitHub API error %d checking the token: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return status, nil
+	}
+
+	if path == "/user" {
+		var user struct {
+			Login string `json:"login"`
+		}
+		if err := json.Unmarshal(body, &user); err != nil {
+			return nil, fmt.Errorf("decoding user: %w", err)
+		}
+		status.Login = user.Login
+	}
+
+	if status.ScopesReported && !status.hasScope("repo") {
+		if status.hasScope("public_repo") {
+			status.problem("The token has the public_repo scope but not repo, so it can't read private repositories. Add the repo scope if you review private PRs")
+		} else {
+			status.problem("The token has neither the repo nor the public_repo scope, so it can only read public repositories and can't resolve or reply to threads. Add the repo scope")
+		}
+	}
+
+	if status.RateLimit != nil && status.RateLimit.Remaining == 0 {
+		status.problem("The API rate limit is exhausted; it resets at %s", status.RateLimit.Reset.Local().Format("15:04:05"))
+	}
+
+	if repo != "" {
+		if err := status.checkRepo(ctx, rest, graphQL, repo); err != nil {
+			return nil, err
+		}
+	}
+
+	if status.SSO != "" {
+		if _, url, found := strings.Cut(status.SSO, "url="); found {
+			status.problem("The token isn't authorized for an organization that enforces SAML single sign-on. Authorize it at %s", url)
+		} else {
+			status.problem("The token isn't authorized for some organizations that enforce SAML single sign-on (%s). Authorize it for them in your token settings", status.SSO)
+		}
+	}
+
+	return status, nil
+}
+
+// readHeaders records the scopes, SSO and rate limit headers of a response
+func (s *AuthStatus) readHeaders(resp *http.Response) {
+	if values, ok := resp.Header["X-Oauth-Scopes"]; ok {
+		s.ScopesReported = true
+		s.Scopes = []string{}
+		for _, scope := range strings.Split(strings.Join(values, ","), ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				s.Scopes = append(s.Scopes, scope)
+			}
+		}
+	}
+
+	// e.g. "required; url=https://github.com/orgs/ORG/sso?authorization_request=..."
+	// or "partial-results; organizations=21955855,20582480"
+	if sso := resp.Header.Get("X-GitHub-SSO"); sso != "" {
+		s.SSO = sso
+	}
+
+	limit, errLimit := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	remaining, errRemaining := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	reset, errReset := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if errLimit == nil && errRemaining == nil && errReset == nil {
+		s.RateLimit = &RateLimit{Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0).UTC()}
+	}
+}
+
+// checkRepo records whether the token can read and write a repository's review threads
+func (s *AuthStatus) checkRepo(ctx context.Context, rest *GitHubClient, graphQL *GitHubGraphQLClient, repo string) error {
+	owner, name, found := strings.Cut(repo, "/")
+	if !found || owner == "" || name == "" {
+		return fmt.Errorf("invalid repository %q (expected OWNER/REPO)", repo)
+	}
+
+	access := &RepoAccess{Repo: repo}
+	s.Repo = access
+
+	resp, body, err := rest.get(ctx, fmt.Sprintf("/repos/%s/%s", owner, name))
+	if err != nil {
+		return err
+	}
+	s.readHeaders(resp)
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		hint := "the token needs the repo scope for private repositories"
+		if !s.ScopesReported {
+			hint = "a fine-grained or app token must be granted access to the repository"
+		}
+		s.problem("%s was not found or the token can't see it: check the name; %s", repo, hint)
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		s.problem("GitHub API error %d reading %s: %s", resp.StatusCode, repo, strings.TrimSpace(string(body)))
+		return nil
+	}
+
+	var repository struct {
+		Private     bool `json:"private"`
+		Permissions struct {
+			Push bool `json:"push"`
+		} `json:"permissions"`
+	}
+	if err := json.Unmarshal(body, &repository); err != nil {
+		return fmt.Errorf("decoding repository: %w", err)
+	}
+	access.Found = true
+	access.Private = repository.Private
+
+	permission, err := graphQL.RepositoryThreadAccess(ctx, owner, name)
+	if err != nil {
+		guidance := "fine-grained and app tokens need the Pull requests read permission"
+		if strings.Contains(err.Error(), "SAML") {
+			guidance = "authorize the token for the organization's SAML single sign-on"
+		}
+		s.problem("The token can't read review threads on %s (%v): %s", repo, err, guidance)
+		return nil
+	}
+	access.CanReadThreads = true
+	access.Permission = permission
+
+	writable := repository.Permissions.Push
+	switch permission {
+	case "ADMIN", "MAINTAIN", "WRITE":
+		writable = true
+	}
+	if s.ScopesReported && !s.hasScope("repo") && (access.Private || !s.hasScope("public_repo")) {
+		writable = false
+	}
+	access.CanWriteThreads = writable
+
+	if !writable {
+		s.problem("The token can read but not resolve review threads on %s: that needs write access to the repository (and the repo scope, or Pull requests write permission for fine-grained and app tokens)", repo)
+	}
+	return nil
+}
+
+// get makes an authenticated GET request to the REST API and reads the body
+func (c *GitHubClient) get(ctx context.Context, path string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading response: %w", err)
+	}
+	return resp, body, nil
+}
+
+// RepositoryThreadAccess checks that review threads of a repository can be
+// read and returns the viewer's permission on it, e.g. WRITE
+func (c *GitHubGraphQLClient) RepositoryThreadAccess(ctx context.Context, owner, name string) (string, error) {
+	var query struct {
+		Repository struct {
+			ViewerPermission githubv4.String
+			PullRequests     struct {
+				Nodes []struct {
+					ReviewThreads struct {
+						TotalCount githubv4.Int
+					} `graphql:"reviewThreads(first: 1)"`
+				}
+			} `graphql:"pullRequests(first: 1, orderBy: {field: UPDATED_AT, direction: DESC})"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"owner": githubv4.String(owner),
+		"name":  githubv4.String(name),
+	}
+
+	if err := c.query(ctx, &query, variables); err != nil {
+		return "", fmt.Errorf("GraphQL query error: %w", err)
+	}
+	return string(query.Repository.ViewerPermission), nil
+}
+
+// FormatAuthStatus renders an auth status report for the terminal
+func FormatAuthStatus(status *AuthStatus) string {
+	var output strings.Builder
+
+	output.WriteString(status.Host + "\n")
+	if status.Login != "" {
+		output.WriteString(fmt.Sprintf("  ✓ Logged in as %s\n", status.Login))
+	}
+	output.WriteString(fmt.Sprintf("  Token source: %s\n", status.Source))
+	output.WriteString(fmt.Sprintf("  Token type:   %s\n", status.TokenType))
+
+	switch {
+	case !status.ScopesReported:
+		output.WriteString("  Scopes:       not reported (fine-grained and app tokens use permissions instead)\n")
+	case len(status.Scopes) == 0:
+		output.WriteString("  Scopes:       none\n")
+	default:
+		output.WriteString(fmt.Sprintf("  Scopes:       %s\n", strings.Join(status.Scopes, ", ")))
+	}
+
+	if status.RateLimit != nil {
+		output.WriteString(fmt.Sprintf("  Rate limit:   %d of %d remaining, resets at %s\n",
+			status.RateLimit.Remaining, status.RateLimit.Limit, status.RateLimit.Reset.Local().Format("15:04:05")))
+	}
+
+	if repo := status.Repo; repo != nil {
+		output.WriteString(fmt.Sprintf("\n%s\n", repo.Repo))
+		if repo.Found {
+			visibility := "public"
+			if repo.Private {
+				visibility = "private"
+			}
+			permission := repo.Permission
+			if permission == "" {
+				permission = "unknown"
+			}
+			output.WriteString(fmt.Sprintf("  Visibility:   %s\n", visibility))
+			output.WriteString(fmt.Sprintf("  Permission:   %s\n", permission))
+		}
+		output.WriteString(fmt.Sprintf("  %s Read review threads\n", checkMark(repo.CanReadThreads)))
+		output.WriteString(fmt.Sprintf("  %s Resolve review threads\n", checkMark(repo.CanWriteThreads)))
+	}
+
+	if status.OK() {
+		output.WriteString("\nNo problems found.\n")
+		return output.String()
+	}
+
+	output.WriteString("\nProblems:\n")
+	for _, problem := range status.Problems {
+		output.WriteString(fmt.Sprintf("  - %s\n", problem))
+	}
+	return output.String()
+}
+
+func checkMark(ok bool) string {
+	if ok {
+		return "✓"
+	}
+	return "✗"
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"pr-review-cli/fakegithub"
+)
+
+func TestTokenType(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"ghp_abc", "classic personal access token"},
+		{"github_pat_abc", "fine-grained personal access token"},
+		{"gho_abc", "OAuth app token"},
+		{"ghu_abc", "GitHub App user token"},
+		{"ghs_abc", "GitHub App installation token"},
+		{"0123456789abcdef", "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := tokenType(tt.token); got != tt.want {
+			t.Errorf("tokenType(%q) = %q, want %q", tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestCheckAuthStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		repo       string
+		permission string
+		configure  func(*fakegithub.Server)
+
+		wantLogin      string
+		wantScopes     []string
+		wantReported   bool
+		wantWrite      bool
+		wantProblem    string
+		wantRateLimit  bool
+		wantRepoAccess bool
+	}{
+		{
+			name:           "classic token with repo scope",
+			token:          "ghp_test",
+			repo:           "octo-org/widgets",
+			wantLogin:      "octocat",
+			wantScopes:     []string{"repo", "read:org"},
+			wantReported:   true,
+			wantWrite:      true,
+			wantRateLimit:  true,
+			wantRepoAccess: true,
+		},
+		{
+			name:           "public_repo only",
+			token:          "ghp_test",
+			repo:           "octo-org/widgets",
+			configure:      func(s *fakegithub.Server) { s.Scopes = []string{"public_repo"} },
+			wantLogin:      "octocat",
+			wantScopes:     []string{"public_repo"},
+			wantReported:   true,
+			wantWrite:      true,
+			wantProblem:    "public_repo scope but not repo",
+			wantRateLimit:  true,
+			wantRepoAccess: true,
+		},
+		{
+			name:           "no scopes",
+			token:          "ghp_test",
+			repo:           "octo-org/widgets",
+			configure:      func(s *fakegithub.Server) { s.Scopes = []string{} },
+			wantLogin:      "octocat",
+			wantScopes:     []string{},
+			wantReported:   true,
+			wantProblem:    "neither the repo nor the public_repo scope",
+			wantRateLimit:  true,
+			wantRepoAccess: true,
+		},
+		{
+			name:           "fine-grained token reports no scopes",
+			token:          "github_pat_test",
+			repo:           "octo-org/widgets",
+			configure:      func(s *fakegithub.Server) { s.FineGrained = true },
+			wantLogin:      "octocat",
+			wantScopes:     []string{},
+			wantWrite:      true,
+			wantRateLimit:  true,
+			wantRepoAccess: true,
+		},
+		{
+			name:           "read-only permission",
+			token:          "ghp_test",
+			repo:           "octo-org/widgets",
+			permission:     "READ",
+			wantLogin:      "octocat",
+			wantScopes:     []string{"repo", "read:org"},
+			wantReported:   true,
+			wantProblem:    "can read but not resolve review threads on octo-org/widgets",
+			wantRateLimit:  true,
+			wantRepoAccess: true,
+		},
+		{
+			name:          "installation token skips /user",
+			token:         "ghs_test",
+			configure:     func(s *fakegithub.Server) { s.FineGrained = true },
+			wantScopes:    []string{},
+			wantRateLimit: true,
+		},
+		{
+			name:         "unknown repository",
+			token:        "ghp_test",
+			repo:         "octo-org/missing",
+			wantLogin:    "octocat",
+			wantScopes:   []string{"repo", "read:org"},
+			wantReported: true,
+			wantProblem:  "octo-org/missing was not found or the token can't see it",
+			// The rate limit headers of the 404 are read too
+			wantRateLimit: true,
+		},
+		{
+			name:          "bad credentials",
+			token:         "ghp_wrong",
+			configure:     func(s *fakegithub.Server) { s.Token = "ghp_right" },
+			wantScopes:    []string{"repo", "read:org"},
+			wantReported:  true,
+			wantProblem:   "GitHub rejected the token from test (bad credentials)",
+			wantRateLimit: true,
+		},
+		{
+			name:          "rate limit used up",
+			token:         "ghp_test",
+			configure:     func(s *fakegithub.Server) { s.RateLimit = 1 },
+			wantLogin:     "octocat",
+			wantScopes:    []string{"repo", "read:org"},
+			wantReported:  true,
+			wantWrite:     true,
+			wantProblem:   "The API rate limit is exhausted",
+			wantRateLimit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seed := fakeSeed()
+			seed.Repositories[0].Permission = tt.permission
+			server, err := fakegithub.New(seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.configure != nil {
+				tt.configure(server)
+			}
+			httpServer := httptest.NewServer(server)
+			defer httpServer.Close()
+
+			ctx := context.Background()
+			src := tokenSource(tt.token)
+			status, err := CheckAuthStatus(ctx,
+				NewGitHubClientFromTokenSource(ctx, src, httpServer.URL),
+				NewGitHubGraphQLClientFromTokenSource(ctx, src, httpServer.URL),
+				tt.token, "test", httpServer.URL, tt.repo)
+			if err != nil {
+				t.Fatalf("CheckAuthStatus: %v", err)
+			}
+
+			if status.TokenType != tokenType(tt.token) {
+				t.Errorf("token type = %q", status.TokenType)
+			}
+			if status.Login != tt.wantLogin {
+				t.Errorf("login = %q, want %q", status.Login, tt.wantLogin)
+			}
+			if !reflect.DeepEqual(status.Scopes, tt.wantScopes) || status.ScopesReported != tt.wantReported {
+				t.Errorf("scopes = %q (reported %v), want %q (reported %v)", status.Scopes, status.ScopesReported, tt.wantScopes, tt.wantReported)
+			}
+			if (status.RateLimit != nil) != tt.wantRateLimit {
+				t.Errorf("rate limit = %+v", status.RateLimit)
+			}
+
+			problems := strings.Join(status.Problems, "\n")
+			if tt.wantProblem == "" && !status.OK() {
+				t.Errorf("problems: %s", problems)
+			}
+			if tt.wantProblem != "" && !strings.Contains(problems, tt.wantProblem) {
+				t.Errorf("problems = %q, want one containing %q", problems, tt.wantProblem)
+			}
+
+			if tt.repo == "" {
+				return
+			}
+			access := status.Repo
+			if access == nil || access.Found != tt.wantRepoAccess || access.CanReadThreads != tt.wantRepoAccess || access.CanWriteThreads != tt.wantWrite {
+				t.Errorf("repo access = %+v, want found and readable %v, writable %v", access, tt.wantRepoAccess, tt.wantWrite)
+			}
+		})
+	}
+}
+
+func TestAuthStatusReadHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-OAuth-Scopes", " repo ,workflow,, read:org")
+	header.Set("X-GitHub-SSO", "required; url=https://github.com/orgs/octo-org/sso?authorization_request=1")
+	header.Set("X-RateLimit-Limit", "5000")
+	header.Set("X-RateLimit-Remaining", "4999")
+	header.Set("X-RateLimit-Reset", "1767225600")
+
+	status := &AuthStatus{Scopes: []string{}}
+	status.readHeaders(&http.Response{Header: header})
+
+	if !status.ScopesReported || !reflect.DeepEqual(status.Scopes, []string{"repo", "workflow", "read:org"}) {
+		t.Errorf("scopes = %q (reported %v)", status.Scopes, status.ScopesReported)
+	}
+	if !strings.HasPrefix(status.SSO, "required; url=") {
+		t.Errorf("sso = %q", status.SSO)
+	}
+	if status.RateLimit == nil || status.RateLimit.Remaining != 4999 || status.RateLimit.Reset.Unix() != 1767225600 {
+		t.Errorf("rate limit = %+v", status.RateLimit)
+	}
+}
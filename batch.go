@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PRRef identifies a single pull request
+type PRRef struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// String returns the PR reference in owner/repo#number form
+func (r PRRef) String() string {
+	return fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.Number)
+}
+
+var (
+	prURLRegex = regexp.MustCompile(`^https?://[^/]+/([^/]+)/([^/]+)/pull/(\d+)(?:[/?#].*)?$`)
+	prRefRegex = regexp.MustCompile(`^([^/\s]+)/([^/#\s]+)#(\d+)$`)
+)
+
+// ParsePRRef parses a PR reference given as a number, owner/repo#number or a PR URL.
+// Bare numbers use the default owner and repo.
+func ParsePRRef(value, defaultOwner, defaultRepo string) (PRRef, error) {
+	value = strings.TrimSpace(value)
+
+	if matches := prURLRegex.FindStringSubmatch(value); matches != nil {
+		number, _ := strconv.Atoi(matches[3])
+		return PRRef{Owner: matches[1], Repo: matches[2], Number: number}, nil
+	}
+
+	if matches := prRefRegex.FindStringSubmatch(value); matches != nil {
+		number, _ := strconv.Atoi(matches[3])
+		return PRRef{Owner: matches[1], Repo: matches[2], Number: number}, nil
+	}
+
+	number, err := strconv.Atoi(strings.TrimPrefix(value, "#"))
+	if err != nil || number <= 0 {
+		return PRRef{}, fmt.Errorf("invalid PR reference %q (expected NUMBER, OWNER/REPO#NUMBER or a PR URL)", value)
+	}
+	if defaultOwner == "" || defaultRepo == "" {
+		return PRRef{}, fmt.Errorf("PR reference %q needs --owner and --repo", value)
+	}
+
+	return PRRef{Owner: defaultOwner, Repo: defaultRepo, Number: number}, nil
+}
+
+// readPRRefList reads PR references from a file (or stdin for "-"), one per line.
+// Blank lines and lines starting with # are ignored.
+func readPRRefList(path string) ([]string, error) {
+	var reader io.Reader
+	if path == "-" {
+		reader = os.Stdin
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("opening PR list: %w", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	var values []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		values = append(values, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading PR list: %w", err)
+	}
+
+	return values, nil
+}
+
+// CollectPRRefs resolves every PR given via --pr flags, positional arguments
+// and an optional list file into a de-duplicated, ordered list of references
+func CollectPRRefs(owner, repo string, prFlags []string, listPath string, args []string) ([]PRRef, error) {
+	values := append([]string{}, prFlags...)
+	values = append(values, args...)
+
+	if listPath != "" {
+		listed, err := readPRRefList(listPath)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, listed...)
+	}
+
+	seen := make(map[PRRef]bool)
+	refs := make([]PRRef, 0, len(values))
+	for _, value := range values {
+		ref, err := ParsePRRef(value, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		refs = append(refs, ref)
+	}
+
+	return refs, nil
+}
+
+// BatchResult holds the outcome of fetching one PR in a batch
+type BatchResult struct {
+	Ref      PRRef
+	Response *PRCommentsResponse
+	Err      error
+}
+
+// PRFetchFunc fetches and assembles the response for a single PR
+type PRFetchFunc func(ctx context.Context, ref PRRef) (*PRCommentsResponse, error)
+
+// FetchBatch fetches all PRs with a bounded pool of workers. Results are
+// returned in input order; onResult, if set, is called as each PR completes.
+func FetchBatch(
+	ctx context.Context,
+	refs []PRRef,
+	concurrency int,
+	fetch PRFetchFunc,
+	onResult func(BatchResult),
+) []BatchResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]BatchResult, len(refs))
+	jobs := make(chan int)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				response, err := fetch(ctx, refs[i])
+				result := BatchResult{Ref: refs[i], Response: response, Err: err}
+				results[i] = result
+
+				if onResult != nil {
+					mu.Lock()
+					onResult(result)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range refs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// batchOutputFileName returns the per-PR output file name for a format
+func batchOutputFileName(ref PRRef, format string) string {
+	ext := "txt"
+	switch format {
+	case "json":
+		ext = "json"
+	case "claude", "checklist":
+		ext = "md"
+	case "html", "csv", "tsv", "jsonl":
+		ext = format
+	}
+	return fmt.Sprintf("%s_%s_pr%d.%s", ref.Owner, ref.Repo, ref.Number, ext)
+}
+
+// writeBatchOutputFile formats a single PR's response into the output directory
+func writeBatchOutputFile(dir string, result BatchResult, format string, formatResponse func(*PRCommentsResponse, string) (string, error)) (string, error) {
+	output, err := formatResponse(result.Response, format)
+	if err != nil {
+		return "", fmt.Errorf("formatting output: %w", err)
+	}
+
+	path := filepath.Join(dir, batchOutputFileName(result.Ref, format))
+	if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+		return "", fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	return path, nil
+}
+
+// formatBatchJSONArray combines the successful responses into one JSON array
+func formatBatchJSONArray(results []BatchResult, jsonVersion int) (string, error) {
+	responses := make([]interface{}, 0, len(results))
+	for _, result := range results {
+		if result.Err == nil {
+			responses = append(responses, VersionedResponse(result.Response, jsonVersion))
+		}
+	}
+
+	data, err := json.MarshalIndent(responses, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// formatBatchJSONLine renders one response as a single compact JSON line
+func formatBatchJSONLine(response *PRCommentsResponse, jsonVersion int) (string, error) {
+	data, err := json.Marshal(VersionedResponse(response, jsonVersion))
+	if err != nil {
+		return "", fmt.Errorf("marshaling JSON: %w", err)
+	}
+	return string(data) + "\n", nil
+}
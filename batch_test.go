@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParsePRRef(t *testing.T) {
+	tests := []struct {
+		value   string
+		owner   string
+		repo    string
+		want    PRRef
+		wantErr bool
+	}{
+		{value: "42", owner: "octo-org", repo: "widgets", want: PRRef{"octo-org", "widgets", 42}},
+		{value: "#42", owner: "octo-org", repo: "widgets", want: PRRef{"octo-org", "widgets", 42}},
+		{value: " other/repo#7 ", owner: "octo-org", repo: "widgets", want: PRRef{"other", "repo", 7}},
+		{value: "https://github.com/octo-org/widgets/pull/9", want: PRRef{"octo-org", "widgets", 9}},
+		{value: "https://github.example.com/team/app/pull/12/files?w=1", want: PRRef{"team", "app", 12}},
+		{value: "42", wantErr: true},
+		{value: "0", owner: "octo-org", repo: "widgets", wantErr: true},
+		{value: "octo-org/widgets", owner: "octo-org", repo: "widgets", wantErr: true},
+		{value: "https://github.com/octo-org/widgets/issues/9", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePRRef(tt.value, tt.owner, tt.repo)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParsePRRef(%q) = %v, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParsePRRef(%q) = %v, %v; want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestCollectPRRefs(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "prs.txt")
+	if err := os.WriteFile(list, []byte("# to review\n3\n\nother/repo#4\n1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := CollectPRRefs("octo-org", "widgets", []string{"1", "2"}, list, []string{"#2", "5"})
+	if err != nil {
+		t.Fatalf("CollectPRRefs: %v", err)
+	}
+	want := []PRRef{
+		{"octo-org", "widgets", 1},
+		{"octo-org", "widgets", 2},
+		{"octo-org", "widgets", 5},
+		{"octo-org", "widgets", 3},
+		{"other", "repo", 4},
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("refs = %v, want %v", refs, want)
+	}
+
+	if _, err := CollectPRRefs("octo-org", "widgets", []string{"abc"}, "", nil); err == nil {
+		t.Error("want an error for a bad reference")
+	}
+}
+
+func TestFetchBatch(t *testing.T) {
+	refs := make([]PRRef, 8)
+	for i := range refs {
+		refs[i] = PRRef{Owner: "octo-org", Repo: "widgets", Number: i + 1}
+	}
+
+	var running, maxRunning, reported int32
+	results := FetchBatch(context.Background(), refs, 3, func(ctx context.Context, ref PRRef) (*PRCommentsResponse, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		// Later PRs finish first, so results arrive out of order
+		time.Sleep(time.Duration(len(refs)-ref.Number) * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+
+		if ref.Number%4 == 0 {
+			return nil, fmt.Errorf("PR %d failed", ref.Number)
+		}
+		return &PRCommentsResponse{PRNumber: ref.Number}, nil
+	}, func(BatchResult) { atomic.AddInt32(&reported, 1) })
+
+	if maxRunning > 3 {
+		t.Errorf("%d fetches ran at once, want at most 3", maxRunning)
+	}
+	if reported != int32(len(refs)) {
+		t.Errorf("onResult called %d times, want %d", reported, len(refs))
+	}
+	for i, result := range results {
+		if result.Ref != refs[i] {
+			t.Errorf("result %d is for %v, want %v", i, result.Ref, refs[i])
+		}
+		if failed := result.Err != nil; failed != (refs[i].Number%4 == 0) {
+			t.Errorf("%v: err = %v", refs[i], result.Err)
+		}
+		if result.Err == nil && result.Response.PRNumber != refs[i].Number {
+			t.Errorf("%v: response for PR %d", refs[i], result.Response.PRNumber)
+		}
+	}
+}
+
+func TestBatchOutputFileName(t *testing.T) {
+	ref := PRRef{Owner: "octo-org", Repo: "widgets", Number: 7}
+	tests := map[string]string{
+		"json":      "octo-org_widgets_pr7.json",
+		"claude":    "octo-org_widgets_pr7.md",
+		"checklist": "octo-org_widgets_pr7.md",
+		"csv":       "octo-org_widgets_pr7.csv",
+		"human":     "octo-org_widgets_pr7.txt",
+	}
+	for format, want := range tests {
+		if got := batchOutputFileName(ref, format); got != want {
+			t.Errorf("%s: %s, want %s", format, got, want)
+		}
+	}
+}
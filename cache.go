@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCacheTTL is how long GraphQL responses are reused by default: not at
+// all, since a new reply doesn't always change the PR's updatedAt
+const defaultCacheTTL = 0
+
+// prUpdatedAtQuery asks for a PR's last update, to check cached responses
+const prUpdatedAtQuery = `query($owner:String!$name:String!$prNumber:Int!){repository(owner:$owner,name:$name){pullRequest(number:$prNumber){updatedAt}}}`
+
+// userCacheDir returns ~/.cache/pr-review-cli, honouring XDG_CACHE_HOME
+func userCacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, configDirName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("finding home directory: %w", err)
+	}
+	return filepath.Join(home, ".cache", configDirName), nil
+}
+
+// newUserCache creates a cache in the user's cache directory
+func newUserCache(ttl time.Duration) (*CachingTransport, error) {
+	dir, err := userCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewCachingTransport(filepath.Join(dir, "http"), ttl, nil)
+}
+
+// cacheEntry is a cached response, stored as a JSON file
+type cacheEntry struct {
+	URL         string           `json:"url"`
+	StoredAt    time.Time        `json:"stored_at"`
+	ETag        string           `json:"etag,omitempty"`          // REST responses
+	PRUpdatedAt string           `json:"pr_updated_at,omitempty"` // GraphQL responses about a PR
+	Response    RecordedResponse `json:"response"`
+}
+
+// CachingTransport caches GitHub API responses on disk, per token.
+//
+// REST responses are stored with their ETag and revalidated with
+// If-None-Match on every request; GitHub answers 304 Not Modified without
+// counting it against the rate limit. GraphQL has no conditional requests, so
+// query responses are reused for TTL. Those about a PR are reused only while
+// the PR's updatedAt is unchanged, which is checked once per PR per run with
+// one small query.
+type CachingTransport struct {
+	Dir  string
+	TTL  time.Duration
+	Next http.RoundTripper
+
+	mu     sync.Mutex
+	probed map[string]string // PR updatedAt, by prKey, checked this run
+}
+
+// NewCachingTransport creates a cache in dir in front of next
+func NewCachingTransport(dir string, ttl time.Duration, next http.RoundTripper) (*CachingTransport, error) {
+	for _, sub := range []string{"rest", "graphql"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("creating cache directory: %w", err)
+		}
+	}
+	return &CachingTransport{Dir: dir, TTL: ttl, Next: next, probed: make(map[string]string)}, nil
+}
+
+func (t *CachingTransport) next() http.RoundTripper {
+	if t.Next == nil {
+		return http.DefaultTransport
+	}
+	return t.Next
+}
+
+// RoundTrip answers a request from the cache or sends it
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch {
+	case req.Method == http.MethodGet:
+		return t.conditionalGet(req)
+	case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/graphql") && t.TTL > 0:
+		return t.cachedQuery(req)
+	default:
+		return t.next().RoundTrip(req)
+	}
+}
+
+// cachePath names the cache file of a request. The key includes the
+// Authorization header, so tokens never see each other's responses.
+func (t *CachingTransport) cachePath(kind string, req *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s %s\n%s\n", req.Header.Get("Authorization"), req.Method, req.URL.String(), req.Header.Get("Accept"))
+	hash.Write(body)
+	return filepath.Join(t.Dir, kind, hex.EncodeToString(hash.Sum(nil))[:32]+".json")
+}
+
+// conditionalGet sends a REST request with the ETag of the cached response,
+// and answers 304 Not Modified with the cached response
+func (t *CachingTransport) conditionalGet(req *http.Request) (*http.Response, error) {
+	path := t.cachePath("rest", req, nil)
+	entry := readCacheEntry(path)
+	if entry != nil && entry.ETag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := t.next().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		cached := entry.Response.httpResponse(req)
+		// The rate limit headers of the 304 are current
+		for name, values := range resp.Header {
+			if strings.HasPrefix(name, "X-Ratelimit-") {
+				cached.Header[name] = values
+			}
+		}
+		return cached, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+	body, err := readResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	t.store(path, &cacheEntry{URL: req.URL.String(), ETag: etag}, resp, body)
+	return resp, nil
+}
+
+// graphQLBody is the part of a GraphQL request the cache looks at
+type graphQLBody struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// cachedQuery answers a GraphQL query from the cache if its response is
+// younger than the TTL and, for a query about a PR, the PR hasn't been updated
+// since. Mutations are never cached, nor are node lookups: a review thread's
+// later comments change with its PR, but the query doesn't name the PR to
+// check.
+func (t *CachingTransport) cachedQuery(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	var query graphQLBody
+	if json.Unmarshal(body, &query) != nil || strings.HasPrefix(strings.TrimSpace(query.Query), "mutation") || strings.Contains(query.Query, "node(") {
+		return t.next().RoundTrip(req)
+	}
+
+	updatedAt := ""
+	if pr, ok := prKey(query.Variables); ok {
+		if updatedAt, err = t.prUpdatedAt(req, pr, query.Variables); err != nil {
+			// Let the query itself report the problem
+			return t.next().RoundTrip(req)
+		}
+	}
+
+	path := t.cachePath("graphql", req, body)
+	if entry := readCacheEntry(path); entry != nil {
+		if time.Since(entry.StoredAt) < t.TTL && entry.PRUpdatedAt == updatedAt {
+			return entry.Response.httpResponse(req), nil
+		}
+		os.Remove(path)
+	}
+
+	resp, err := t.next().RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	respBody, err := readResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Responses with errors may be partial; don't keep them
+	var result struct {
+		Errors []json.RawMessage `json:"errors"`
+	}
+	if json.Unmarshal(respBody, &result) == nil && len(result.Errors) == 0 {
+		t.store(path, &cacheEntry{URL: req.URL.String(), PRUpdatedAt: updatedAt}, resp, respBody)
+	}
+	return resp, nil
+}
+
+// prKey identifies the PR a query is about, from its owner, name and
+// prNumber variables
+func prKey(variables map[string]interface{}) (string, bool) {
+	owner, _ := variables["owner"].(string)
+	name, _ := variables["name"].(string)
+	number, _ := variables["prNumber"].(float64)
+	if owner == "" || name == "" || number == 0 {
+		return "", false
+	}
+	return strings.ToLower(fmt.Sprintf("%s/%s#%d", owner, name, int(number))), true
+}
+
+// prUpdatedAt returns when a PR was last updated. It asks GitHub once per PR
+// per run, with the credentials of the request being cached.
+func (t *CachingTransport) prUpdatedAt(req *http.Request, pr string, variables map[string]interface{}) (string, error) {
+	t.mu.Lock()
+	updatedAt, ok := t.probed[pr]
+	t.mu.Unlock()
+	if ok {
+		return updatedAt, nil
+	}
+
+	body, err := json.Marshal(graphQLBody{
+		Query: prUpdatedAtQuery,
+		Variables: map[string]interface{}{
+			"owner":    variables["owner"],
+			"name":     variables["name"],
+			"prNumber": variables["prNumber"],
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	probe, err := http.NewRequestWithContext(req.Context(), http.MethodPost, req.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	probe.Header = req.Header.Clone()
+	probe.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.next().RoundTrip(probe)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("checking %s for updates: status %d", pr, resp.StatusCode)
+	}
+
+	var result struct {
+		Data struct {
+			Repository struct {
+				PullRequest struct {
+					UpdatedAt string `json:"updatedAt"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("checking %s for updates: %w", pr, err)
+	}
+	updatedAt = result.Data.Repository.PullRequest.UpdatedAt
+	if updatedAt == "" {
+		return "", fmt.Errorf("checking %s for updates: no updatedAt", pr)
+	}
+
+	t.mu.Lock()
+	t.probed[pr] = updatedAt
+	t.mu.Unlock()
+	return updatedAt, nil
+}
+
+// readResponseBody reads a response's body and puts it back for the caller
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// readCacheEntry reads a cache file; a missing or unreadable one is a miss
+func readCacheEntry(path string) *cacheEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil {
+		return nil
+	}
+	return &entry
+}
+
+// store saves a response in the cache. Failing to is not an error; the
+// response is simply not cached.
+func (t *CachingTransport) store(path string, entry *cacheEntry, resp *http.Response, body []byte) {
+	entry.StoredAt = time.Now().UTC()
+	entry.Response = RecordedResponse{Status: resp.StatusCode, Headers: resp.Header.Clone()}
+	delete(entry.Response.Headers, "Set-Cookie")
+	setBody(body, &entry.Response.Body, &entry.Response.BodyText)
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	writeFileAtomic(path, data)
+}
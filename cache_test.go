@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"pr-review-cli/fakegithub"
+)
+
+// statusTransport records, for each request it sends, whether it was
+// conditional and the response's status
+type statusTransport struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (t *statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	conditional := ""
+	if req.Header.Get("If-None-Match") != "" {
+		conditional = " if-none-match"
+	}
+	t.mu.Lock()
+	t.requests = append(t.requests, req.Method+conditional+" "+resp.Status[:3])
+	t.mu.Unlock()
+	return resp, nil
+}
+
+func (t *statusTransport) sent() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.Join(t.requests, ", ")
+}
+
+// getThrough sends an authenticated GET through a transport and reads the body
+func getThrough(t *testing.T, transport http.RoundTripper, url, token string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "token "+token)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestCachingTransportRevalidatesREST(t *testing.T) {
+	server, host := newFakeGitHub(t, nil)
+	sender := &statusTransport{}
+	cache, err := NewCachingTransport(t.TempDir(), 0, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := host + "/api/v3/repos/octo-org/widgets/pulls/1/comments"
+
+	first, firstBody := getThrough(t, cache, url, "alice")
+	if first.StatusCode != http.StatusOK || first.Header.Get("ETag") == "" {
+		t.Fatalf("first response: %s, ETag %q", first.Status, first.Header.Get("ETag"))
+	}
+
+	// Another request uses up some of the rate limit in between
+	getThrough(t, http.DefaultTransport, host+"/api/v3/user", "alice")
+	requests := server.Requests()
+
+	second, secondBody := getThrough(t, cache, url, "alice")
+	if got := sender.sent(); got != "GET 200, GET if-none-match 304" {
+		t.Errorf("sent %s, want the second request revalidated", got)
+	}
+	if second.StatusCode != http.StatusOK || strings.TrimSpace(secondBody) != strings.TrimSpace(firstBody) {
+		t.Errorf("second response: %s %q, want the cached 200", second.Status, secondBody)
+	}
+	if server.Requests() != requests {
+		t.Error("the 304 counted against the rate limit")
+	}
+	// The cached response carries the rate limit of the 304, not of the first response
+	if remaining := second.Header.Get("X-RateLimit-Remaining"); remaining == first.Header.Get("X-RateLimit-Remaining") || remaining == "" {
+		t.Errorf("X-RateLimit-Remaining = %q, want the 304's", remaining)
+	}
+
+	// Another token doesn't see alice's cached response
+	getThrough(t, cache, url, "bob")
+	if got := sender.sent(); !strings.HasSuffix(got, ", GET 200") {
+		t.Errorf("sent %s, want bob's request sent unconditionally", got)
+	}
+}
+
+// resolveThread resolves a review thread on the fake directly, updating its PR
+func resolveThread(t *testing.T, host, threadID string) {
+	t.Helper()
+	body := `{"query":"mutation($input:ResolveReviewThreadInput!){resolveReviewThread(input:$input){thread{id}}}","variables":{"input":{"threadId":"` + threadID + `"}}}`
+	req, err := http.NewRequest(http.MethodPost, host+"/api/graphql", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "bearer test")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("resolving %s: %s", threadID, resp.Status)
+	}
+}
+
+func TestCachingTransportGraphQL(t *testing.T) {
+	seed := fakeSeed()
+	seed.Repositories[0].PullRequests[0].UpdatedAt = time.Now().Add(-time.Hour)
+	server, err := fakegithub.New(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	host := httpServer.URL
+
+	dir := t.TempDir()
+	ref := PRRef{Owner: "octo-org", Repo: "widgets", Number: 1}
+	opts := FetchOptions{IncludeResolved: true, IncludeOutdated: true, IncludeGeneral: true}
+
+	// fetch fetches the PR through a cache, as one run of the tool, and
+	// returns the response and how many requests reached the server
+	fetch := func(ttl time.Duration, token string) (*PRCommentsResponse, int) {
+		t.Helper()
+		cache, err := NewCachingTransport(dir, ttl, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: cache})
+		client := NewGitHubGraphQLClientFromTokenSource(ctx, tokenSource(token), host)
+
+		before := server.Requests()
+		response, err := fetchGraphQLResponse(ctx, client, ref, opts)
+		if err != nil {
+			t.Fatalf("fetch: %v", err)
+		}
+		return response, server.Requests() - before
+	}
+
+	first, requests := fetch(time.Hour, "alice")
+	if requests < 2 {
+		t.Fatalf("first fetch sent %d requests, want the update check and the queries", requests)
+	}
+
+	// Within the TTL and with the PR unchanged, only the update check is sent
+	cached, requests := fetch(time.Hour, "alice")
+	if requests != 1 {
+		t.Errorf("cached fetch sent %d requests, want 1", requests)
+	}
+	if !reflect.DeepEqual(cached, first) {
+		t.Errorf("cached response differs:\n%+v\nwant:\n%+v", cached, first)
+	}
+
+	// Another token has its own cache entries
+	if _, requests := fetch(time.Hour, "bob"); requests < 2 {
+		t.Errorf("fetch with another token sent %d requests, want it uncached", requests)
+	}
+
+	// An expired entry is fetched again
+	if _, requests := fetch(time.Nanosecond, "alice"); requests < 2 {
+		t.Errorf("fetch after the TTL sent %d requests, want it uncached", requests)
+	}
+
+	// Updating the PR invalidates its entries
+	if _, requests := fetch(time.Hour, "alice"); requests != 1 {
+		t.Fatalf("fetch sent %d requests, want it cached again", requests)
+	}
+	resolveThread(t, host, first.ReviewThreads[0].ID)
+	updated, requests := fetch(time.Hour, "alice")
+	if requests < 2 {
+		t.Errorf("fetch after an update sent %d requests, want it uncached", requests)
+	}
+	if !updated.ReviewThreads[0].IsResolved {
+		t.Error("fetch after an update returned the stale thread")
+	}
+}
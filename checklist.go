@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// todoFileTitle heads a REVIEW_TODO.md file written by --out-todo
+const todoFileTitle = "# Review TODO"
+
+// checklistItemRegex matches a task list item written by the checklist
+// format, capturing its checkbox state and the ID in its trailing marker
+var checklistItemRegex = regexp.MustCompile(`^\s*- \[([ xX])\] .*<!-- (?:thread|comment):(\S+) -->\s*$`)
+
+// checklistItem is a single task in a review checklist
+type checklistItem struct {
+	ID      string
+	Checked bool
+	Line    string   // rendered item, without the checkbox
+	Notes   []string // the developer's lines below the item, kept across runs
+}
+
+// formatChecklistV2 outputs review threads as a GitHub-flavoured markdown task list
+func formatChecklistV2(response *PRCommentsResponse, opts FormatOptions) (string, error) {
+	return renderChecklistSection(response, checklistItems(response, opts), nil, nil), nil
+}
+
+// checklistItems builds one task per review thread and general comment.
+// Resolved threads start out ticked.
+func checklistItems(response *PRCommentsResponse, opts FormatOptions) []checklistItem {
+	items := make([]checklistItem, 0, len(response.ReviewThreads)+len(response.GeneralComments))
+
+	for _, thread := range SortThreads(response.ReviewThreads, opts.Sort, opts.ReviewerWeights) {
+		location := thread.File
+		if line := threadLineNumber(thread); line != math.MaxInt32 {
+			location = fmt.Sprintf("%s:%d", thread.File, line)
+		}
+
+		summary := ""
+		author := ""
+		link := ""
+		if len(thread.Comments) > 0 {
+			summary = firstLine(thread.Comments[0].Body)
+			author = thread.Comments[0].Author
+			link = thread.Comments[0].HTMLURL
+		}
+		if thread.Conventional != nil && thread.Conventional.Subject != "" {
+			summary = thread.Conventional.Subject
+		}
+
+		items = append(items, checklistItem{
+			ID:      thread.ID,
+			Checked: thread.IsResolved,
+			Line: fmt.Sprintf("%s — %s (%s) [link](%s) <!-- thread:%s -->",
+				location, summary, author, link, thread.ID),
+		})
+	}
+
+	for _, comment := range response.GeneralComments {
+		items = append(items, checklistItem{
+			ID: comment.ID,
+			Line: fmt.Sprintf("PR discussion — %s (%s) [link](%s) <!-- comment:%s -->",
+				firstLine(comment.Body), comment.Author, comment.HTMLURL, comment.ID),
+		})
+	}
+
+	return items
+}
+
+// renderChecklistSection renders a PR's checklist under its heading, after
+// the notes that preceded the items and followed by the stale items, each
+// carried over from a previous run
+func renderChecklistSection(response *PRCommentsResponse, items []checklistItem, notes, stale []string) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("## %s/%s#%d\n\n", response.Owner, response.Repo, response.PRNumber))
+
+	for _, line := range notes {
+		output.WriteString(line + "\n")
+	}
+	if len(items) == 0 && len(stale) == 0 {
+		if len(notes) == 0 {
+			output.WriteString("No review comments to address.\n")
+		}
+		return output.String()
+	}
+
+	for _, item := range items {
+		box := " "
+		if item.Checked {
+			box = "x"
+		}
+		output.WriteString(fmt.Sprintf("- [%s] %s\n", box, item.Line))
+		for _, line := range item.Notes {
+			output.WriteString(line + "\n")
+		}
+	}
+	for _, line := range stale {
+		output.WriteString(line + "\n")
+	}
+
+	return output.String()
+}
+
+// todoFile is a parsed REVIEW_TODO.md: free text before the first PR heading
+// and one section per PR keyed by its heading
+type todoFile struct {
+	Preamble []string
+	Headings []string
+	Sections map[string][]string
+}
+
+// readTodoFile parses an existing TODO file; a missing file is empty
+func readTodoFile(path string) (*todoFile, error) {
+	todo := &todoFile{Sections: make(map[string][]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return todo, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	heading := ""
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if strings.HasPrefix(line, "## ") {
+			heading = strings.TrimSpace(line)
+			if _, seen := todo.Sections[heading]; !seen {
+				todo.Headings = append(todo.Headings, heading)
+				todo.Sections[heading] = nil
+			}
+			continue
+		}
+
+		if heading == "" {
+			todo.Preamble = append(todo.Preamble, line)
+		} else {
+			todo.Sections[heading] = append(todo.Sections[heading], line)
+		}
+	}
+
+	return todo, nil
+}
+
+// mergeChecklistSection keeps the boxes the developer already ticked in a PR's
+// previous section and carries over items that are no longer fetched, so the
+// worklist survives new fetches. The developer's own lines are kept too:
+// those below an item stay with it, those before the first item are returned
+// as notes, and those below a stale item go with it.
+func mergeChecklistSection(items []checklistItem, previous []string) ([]checklistItem, []string, []string) {
+	current := make(map[string]bool, len(items))
+	for _, item := range items {
+		current[item.ID] = true
+	}
+
+	// Drop the blank lines around the section and the placeholder this
+	// format writes for a PR without comments
+	for len(previous) > 0 && strings.TrimSpace(previous[0]) == "" {
+		previous = previous[1:]
+	}
+	for len(previous) > 0 && strings.TrimSpace(previous[len(previous)-1]) == "" {
+		previous = previous[:len(previous)-1]
+	}
+
+	ticked := make(map[string]bool)
+	itemNotes := make(map[string][]string)
+	var notes, stale []string
+	owner := "" // the item the lines read belong to
+	inStale := false
+	for _, line := range previous {
+		matches := checklistItemRegex.FindStringSubmatch(line)
+		switch {
+		case matches != nil && current[matches[2]]:
+			owner, inStale = matches[2], false
+			ticked[owner] = matches[1] != " "
+		case matches != nil:
+			owner, inStale = "", true
+			stale = append(stale, line)
+		case strings.TrimSpace(line) == "No review comments to address.":
+		case inStale:
+			stale = append(stale, line)
+		case owner != "":
+			itemNotes[owner] = append(itemNotes[owner], line)
+		default:
+			notes = append(notes, line)
+		}
+	}
+
+	merged := make([]checklistItem, len(items))
+	for i, item := range items {
+		item.Checked = item.Checked || ticked[item.ID]
+		item.Notes = itemNotes[item.ID]
+		merged[i] = item
+	}
+
+	return merged, notes, stale
+}
+
+// WriteTodoFile writes or merges the fetched PRs' checklists into a TODO file.
+// Sections for PRs that weren't fetched are kept as they are.
+func WriteTodoFile(path string, responses []*PRCommentsResponse, opts FormatOptions) error {
+	todo, err := readTodoFile(path)
+	if err != nil {
+		return err
+	}
+
+	rendered := make(map[string]string, len(responses))
+	for _, response := range responses {
+		heading := fmt.Sprintf("## %s/%s#%d", response.Owner, response.Repo, response.PRNumber)
+
+		items, notes, stale := mergeChecklistSection(checklistItems(response, opts), todo.Sections[heading])
+		rendered[heading] = renderChecklistSection(response, items, notes, stale)
+
+		if _, seen := todo.Sections[heading]; !seen {
+			todo.Headings = append(todo.Headings, heading)
+			todo.Sections[heading] = nil
+		}
+	}
+
+	var output strings.Builder
+
+	preamble := strings.TrimSpace(strings.Join(todo.Preamble, "\n"))
+	if preamble == "" {
+		preamble = todoFileTitle
+	}
+	output.WriteString(preamble + "\n")
+
+	for _, heading := range todo.Headings {
+		output.WriteString("\n")
+		if section, ok := rendered[heading]; ok {
+			output.WriteString(section)
+			continue
+		}
+
+		output.WriteString(heading + "\n")
+		if body := strings.TrimRight(strings.Join(todo.Sections[heading], "\n"), "\n"); body != "" {
+			output.WriteString(body + "\n")
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(output.String()), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
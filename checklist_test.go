@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeChecklistSection(t *testing.T) {
+	items := []checklistItem{
+		{ID: "PRRT_1", Line: "cache.go:12 — needs a mutex (octocat) [link](u1) <!-- thread:PRRT_1 -->"},
+		{ID: "PRRT_2", Checked: true, Line: "cache.go:30 — naming (octocat) [link](u2) <!-- thread:PRRT_2 -->"},
+		{ID: "IC_1", Line: "PR discussion — benchmark (octocat) [link](u3) <!-- comment:IC_1 -->"},
+	}
+	previous := strings.Split(`
+Talk to hubot about the cache first.
+- [x] cache.go:12 — needs a mutex (octocat) [link](u1) <!-- thread:PRRT_1 -->
+  - use sync.RWMutex
+- [ ] cache.go:30 — naming (octocat) [link](u2) <!-- thread:PRRT_2 -->
+- [x] old.go:1 — gone (octocat) [link](u0) <!-- thread:PRRT_0 -->
+  - done in abc123
+No review comments to address.
+
+`, "\n")
+
+	merged, notes, stale := mergeChecklistSection(items, previous)
+
+	if got := []bool{merged[0].Checked, merged[1].Checked, merged[2].Checked}; !reflect.DeepEqual(got, []bool{true, true, false}) {
+		t.Errorf("checked = %v, want [true true false]", got)
+	}
+	if !reflect.DeepEqual(merged[0].Notes, []string{"  - use sync.RWMutex"}) || merged[1].Notes != nil {
+		t.Errorf("item notes = %q, %q", merged[0].Notes, merged[1].Notes)
+	}
+	if !reflect.DeepEqual(notes, []string{"Talk to hubot about the cache first."}) {
+		t.Errorf("notes = %q", notes)
+	}
+	wantStale := []string{
+		"- [x] old.go:1 — gone (octocat) [link](u0) <!-- thread:PRRT_0 -->",
+		"  - done in abc123",
+	}
+	if !reflect.DeepEqual(stale, wantStale) {
+		t.Errorf("stale = %q, want %q", stale, wantStale)
+	}
+
+	response := &PRCommentsResponse{Owner: "octo-org", Repo: "widgets", PRNumber: 1}
+	rendered := renderChecklistSection(response, merged, notes, stale)
+	want := `## octo-org/widgets#1
+
+Talk to hubot about the cache first.
+- [x] cache.go:12 — needs a mutex (octocat) [link](u1) <!-- thread:PRRT_1 -->
+  - use sync.RWMutex
+- [x] cache.go:30 — naming (octocat) [link](u2) <!-- thread:PRRT_2 -->
+- [ ] PR discussion — benchmark (octocat) [link](u3) <!-- comment:IC_1 -->
+- [x] old.go:1 — gone (octocat) [link](u0) <!-- thread:PRRT_0 -->
+  - done in abc123
+`
+	if rendered != want {
+		t.Errorf("rendered:\n%s\nwant:\n%s", rendered, want)
+	}
+}
+
+func TestRenderChecklistSectionPlaceholder(t *testing.T) {
+	response := &PRCommentsResponse{Owner: "octo-org", Repo: "widgets", PRNumber: 2}
+
+	tests := []struct {
+		notes []string
+		want  string
+	}{
+		{want: "## octo-org/widgets#2\n\nNo review comments to address.\n"},
+		{notes: []string{"Waiting on CI."}, want: "## octo-org/widgets#2\n\nWaiting on CI.\n"},
+	}
+
+	for _, tt := range tests {
+		if got := renderChecklistSection(response, nil, tt.notes, nil); got != tt.want {
+			t.Errorf("notes %q: got %q, want %q", tt.notes, got, tt.want)
+		}
+	}
+}
+
+func TestWriteTodoFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "REVIEW_TODO.md")
+	response := func(number int, threads ...ReviewThread) *PRCommentsResponse {
+		return &PRCommentsResponse{Owner: "octo-org", Repo: "widgets", PRNumber: number, ReviewThreads: threads}
+	}
+	thread := func(id, file string, resolved bool) ReviewThread {
+		return ReviewThread{ID: id, File: file, IsResolved: resolved, Comments: []ThreadComment{{Author: "octocat", Body: "fix " + file, HTMLURL: "u"}}}
+	}
+
+	if err := WriteTodoFile(path, []*PRCommentsResponse{response(1, thread("T1", "a.go", false), thread("T2", "b.go", false)), response(2)}, FormatOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The developer ticks a box, adds notes and a section of their own
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(data), "# Review TODO\n", "# Review TODO\nMy worklist.\n", 1)
+	edited = strings.Replace(edited, "- [ ] a.go", "- [x] a.go", 1)
+	edited = strings.Replace(edited, "<!-- thread:T2 -->\n", "<!-- thread:T2 -->\n  - ask about b.go\n", 1)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// PR 1 is fetched again with T1 gone and a new thread; PR 2 isn't fetched
+	if err := WriteTodoFile(path, []*PRCommentsResponse{response(1, thread("T2", "b.go", false), thread("T3", "c.go", true))}, FormatOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Review TODO
+My worklist.
+
+## octo-org/widgets#1
+
+- [ ] b.go — fix b.go (octocat) [link](u) <!-- thread:T2 -->
+  - ask about b.go
+- [x] c.go — fix c.go (octocat) [link](u) <!-- thread:T3 -->
+- [x] a.go — fix a.go (octocat) [link](u) <!-- thread:T1 -->
+
+## octo-org/widgets#2
+
+No review comments to address.
+`
+	if string(data) != want {
+		t.Errorf("TODO file:\n%s\nwant:\n%s", data, want)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Review comment intent categories
+const (
+	CategoryBlocking   = "blocking"
+	CategoryQuestion   = "question"
+	CategorySuggestion = "suggestion"
+	CategoryNit        = "nit"
+	CategoryPraise     = "praise"
+	CategoryComment    = "comment" // anything no rule matched
+)
+
+// categoryOrder lists the categories from most to least pressing
+var categoryOrder = []string{
+	CategoryBlocking,
+	CategoryQuestion,
+	CategorySuggestion,
+	CategoryComment,
+	CategoryNit,
+	CategoryPraise,
+}
+
+// ClassifierRule assigns a category to comments whose body matches a pattern
+type ClassifierRule struct {
+	Category string `json:"category"`
+	Pattern  string `json:"pattern"`
+	pattern  *regexp.Regexp
+}
+
+// Classifier assigns an intent category to review comments using ordered rules;
+// the first matching rule wins
+type Classifier struct {
+	Rules []ClassifierRule
+}
+
+// classifierRuleFile is the format of a --category-rules file
+type classifierRuleFile struct {
+	// ReplaceDefaults drops the built-in rules instead of running them after the file's rules
+	ReplaceDefaults bool             `json:"replace_defaults"`
+	Rules           []ClassifierRule `json:"rules"`
+}
+
+// defaultClassifierRules recognise common review conventions: prefixes such as
+// "nit:" and "[blocking]", Conventional Comments labels and decorations,
+// GitHub suggestion blocks, questions and emoji
+var defaultClassifierRules = []ClassifierRule{
+	{Category: CategoryBlocking, Pattern: `(?i)^\s*(\[blocking\]|blocking\s*:|\*\*blocking\*\*|🚫|⛔|🛑)`},
+	{Category: CategoryBlocking, Pattern: `(?i)^\s*\w+\s*\((?:[^)]*,\s*)?blocking(?:\s*,[^)]*)?\)\s*:`},
+	{Category: CategoryNit, Pattern: `(?i)^\s*(\[nit(pick)?\]|\(nit(pick)?\)|nit(pick)?\b)`},
+	{Category: CategoryPraise, Pattern: `(?i)^\s*(\[praise\]|praise\s*:|kudos\b|(nice|great) (work|job|catch|one)\b|love (this|it)\b|lgtm\b|👍|🎉|💯|🙌|✨|❤️)`},
+	{Category: CategorySuggestion, Pattern: "(?m)^\\s*```suggestion"},
+	{Category: CategorySuggestion, Pattern: `(?i)^\s*(\[suggestion\]|suggestion\b|suggest\b|consider\b|what about\b|how about\b|optional\b|💡)`},
+	{Category: CategoryQuestion, Pattern: `(?i)^\s*(\[question\]|question\s*:|q\s*:|❓)`},
+	{Category: CategoryQuestion, Pattern: `(?m)\?\s*$`},
+}
+
+// defaultClassifier is shared by all fetches that don't configure their own rules
+var defaultClassifier = DefaultClassifier()
+
+// NewClassifier compiles a rule list into a classifier
+func NewClassifier(rules []ClassifierRule) (*Classifier, error) {
+	classifier := &Classifier{Rules: make([]ClassifierRule, 0, len(rules))}
+
+	for _, rule := range rules {
+		if !isKnownCategory(rule.Category) {
+			return nil, fmt.Errorf("unknown category %q (expected one of: %s)", rule.Category, strings.Join(categoryOrder, ", "))
+		}
+
+		compiled, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for category %s: %w", rule.Category, err)
+		}
+
+		rule.pattern = compiled
+		classifier.Rules = append(classifier.Rules, rule)
+	}
+
+	return classifier, nil
+}
+
+// DefaultClassifier returns a classifier using only the built-in rules
+func DefaultClassifier() *Classifier {
+	classifier, err := NewClassifier(defaultClassifierRules)
+	if err != nil {
+		panic(fmt.Sprintf("invalid built-in classifier rules: %v", err))
+	}
+	return classifier
+}
+
+// LoadClassifier builds a classifier from a JSON rule file. The file's rules
+// run before the built-in ones unless it sets "replace_defaults".
+func LoadClassifier(path string) (*Classifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading category rules: %w", err)
+	}
+
+	var file classifierRuleFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing category rules %s: %w", path, err)
+	}
+
+	rules := file.Rules
+	if !file.ReplaceDefaults {
+		rules = append(rules, defaultClassifierRules...)
+	}
+
+	classifier, err := NewClassifier(rules)
+	if err != nil {
+		return nil, fmt.Errorf("category rules %s: %w", path, err)
+	}
+	return classifier, nil
+}
+
+// Classify returns the category of a comment body
+func (c *Classifier) Classify(body string) string {
+	category, _ := c.Match(body)
+	return category
+}
+
+// Match returns the category of the first rule matching a comment body, and
+// whether any rule matched
+func (c *Classifier) Match(body string) (string, bool) {
+	for _, rule := range c.Rules {
+		if rule.pattern.MatchString(body) {
+			return rule.Category, true
+		}
+	}
+	return CategoryComment, false
+}
+
+// MatchThread categorises a thread by its opening comment, reporting whether
+// any rule matched
+func (c *Classifier) MatchThread(thread ReviewThread) (string, bool) {
+	if len(thread.Comments) == 0 {
+		return CategoryComment, false
+	}
+	return c.Match(thread.Comments[0].Body)
+}
+
+// isKnownCategory reports whether a category name is valid
+func isKnownCategory(category string) bool {
+	for _, known := range categoryOrder {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryRank orders categories from most to least pressing
+func categoryRank(category string) int {
+	for i, known := range categoryOrder {
+		if category == known {
+			return i
+		}
+	}
+	return len(categoryOrder)
+}
+
+// categoryAction describes what a thread of the given category asks of the author
+func categoryAction(category string) string {
+	switch category {
+	case CategoryBlocking:
+		return "Must be addressed before merging"
+	case CategoryQuestion:
+		return "Answer the reviewer's question"
+	case CategorySuggestion:
+		return "Consider the suggested change"
+	case CategoryNit:
+		return "Optional minor polish"
+	case CategoryPraise:
+		return "No action needed"
+	default:
+		return "Address the feedback"
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultClassifier(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"[blocking] this breaks the build", CategoryBlocking},
+		{"Blocking: needs a migration", CategoryBlocking},
+		{"🛑 don't merge yet", CategoryBlocking},
+		{"issue (ux, blocking): the button is hidden", CategoryBlocking},
+		{"nit: trailing whitespace", CategoryNit},
+		{"Nitpick - rename this", CategoryNit},
+		{"LGTM", CategoryPraise},
+		{"Nice catch!", CategoryPraise},
+		{"```suggestion\nreturn nil\n```", CategorySuggestion},
+		{"Consider caching this", CategorySuggestion},
+		{"Why is this needed?", CategoryQuestion},
+		{"q: is this tested", CategoryQuestion},
+		{"This allocates on every call.", CategoryComment},
+		{"", CategoryComment},
+	}
+
+	for _, tt := range tests {
+		if got := defaultClassifier.Classify(tt.body); got != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestLoadClassifier(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		body    string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "file rules run first",
+			content: `{"rules": [{"category": "blocking", "pattern": "(?i)^must[- ]fix"}, {"category": "praise", "pattern": "nit"}]}`,
+			body:    "nit: must-fix later",
+			want:    CategoryPraise,
+		},
+		{
+			name:    "built-in rules still apply",
+			content: `{"rules": [{"category": "blocking", "pattern": "(?i)^must[- ]fix"}]}`,
+			body:    "nit: spacing",
+			want:    CategoryNit,
+		},
+		{
+			name:    "replace defaults",
+			content: `{"replace_defaults": true, "rules": [{"category": "blocking", "pattern": "(?i)^must[- ]fix"}]}`,
+			body:    "nit: spacing",
+			want:    CategoryComment,
+		},
+		{
+			name:    "unknown category",
+			content: `{"rules": [{"category": "urgent", "pattern": "x"}]}`,
+			wantErr: `unknown category "urgent"`,
+		},
+		{
+			name:    "bad pattern",
+			content: `{"rules": [{"category": "nit", "pattern": "("}]}`,
+			wantErr: "invalid pattern for category nit",
+		},
+		{
+			name:    "not JSON",
+			content: `rules: []`,
+			wantErr: "parsing category rules",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classifier, err := LoadClassifier(write(filepath.Base(t.Name())+".json", tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadClassifier: %v", err)
+			}
+			if got := classifier.Classify(tt.body); got != tt.want {
+				t.Errorf("Classify(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadClassifier(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("want an error for a missing file")
+	}
+}
+
+func TestKeepCategory(t *testing.T) {
+	opts := FetchOptions{Categories: []string{"blocking", "Question"}}
+	for category, want := range map[string]bool{CategoryBlocking: true, CategoryQuestion: true, CategoryNit: false} {
+		if got := opts.keepCategory(category); got != want {
+			t.Errorf("keepCategory(%q) = %v, want %v", category, got, want)
+		}
+	}
+	if !(FetchOptions{}).keepCategory(CategoryPraise) {
+		t.Error("without --category every category should be kept")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+)
+
+// codeownersPaths lists the locations GitHub reads a CODEOWNERS file from, in order
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeownersRule maps a path pattern to its owners
+type CodeownersRule struct {
+	Pattern string
+	Owners  []string
+	pattern pathPattern
+}
+
+// Codeowners holds the parsed rules of a CODEOWNERS file
+type Codeowners struct {
+	Rules []CodeownersRule
+}
+
+// ParseCodeowners parses the contents of a CODEOWNERS file
+func ParseCodeowners(content string) *Codeowners {
+	codeowners := &Codeowners{}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Strip trailing comments
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = strings.TrimSpace(line[:idx])
+		}
+
+		fields := strings.Fields(line)
+		codeowners.Rules = append(codeowners.Rules, CodeownersRule{
+			Pattern: fields[0],
+			Owners:  fields[1:],
+			pattern: compilePathPattern(fields[0]),
+		})
+	}
+
+	return codeowners
+}
+
+// Owners returns the owners of a file. As on GitHub, the last matching
+// rule wins, and a matching rule without owners leaves the file unowned.
+func (c *Codeowners) Owners(filePath string) []string {
+	for i := len(c.Rules) - 1; i >= 0; i-- {
+		if c.Rules[i].pattern.Match(filePath) {
+			return c.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// OwnedByAny reports whether any of the given teams or users owns the file
+func (c *Codeowners) OwnedByAny(filePath string, owners []string) bool {
+	for _, owner := range c.Owners(filePath) {
+		for _, candidate := range owners {
+			if strings.EqualFold(strings.TrimPrefix(owner, "@"), strings.TrimPrefix(candidate, "@")) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"pr-review-cli/fakegithub"
+)
+
+func TestCodeownersOwners(t *testing.T) {
+	codeowners := ParseCodeowners(`# Default owners
+*       @octo-org/everyone
+
+*.go    @octo-org/backend   # Go code
+/docs/  @hubot
+docs/generated/
+apps/**/config.yaml @octo-org/ops @alice
+`)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"README.md", []string{"@octo-org/everyone"}},
+		{"cmd/main.go", []string{"@octo-org/backend"}},
+		{"docs/guide.md", []string{"@hubot"}},
+		{"docs/generated/api.md", []string{}},
+		{"apps/web/prod/config.yaml", []string{"@octo-org/ops", "@alice"}},
+	}
+
+	for _, tt := range tests {
+		got := codeowners.Owners(tt.path)
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Owners(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if !codeowners.OwnedByAny("cmd/main.go", []string{"octo-org/Backend"}) {
+		t.Error("OwnedByAny should match teams without @ and case-insensitively")
+	}
+	if codeowners.OwnedByAny("docs/generated/api.md", []string{"@hubot"}) {
+		t.Error("a rule without owners should leave the file unowned")
+	}
+}
+
+func TestFetchCodeownersTeam(t *testing.T) {
+	seed := fakeSeed()
+	widgets := seed.Repositories[0]
+	widgets.Commits = []*fakegithub.Commit{{
+		SHA:   "base1234",
+		Files: map[string]string{".github/CODEOWNERS": "*.md @octo-org/docs\ncache.go @octo-org/backend\n"},
+	}}
+	widgets.PullRequests[0].BaseSHA = "base1234"
+
+	server, err := fakegithub.New(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client := NewGitHubGraphQLClientFromTokenSource(context.Background(), tokenSource("test"), httpServer.URL)
+
+	ref := PRRef{Owner: "octo-org", Repo: "widgets", Number: 1}
+	opts := FetchOptions{IncludeResolved: true, IncludeOutdated: true, CodeownersTeams: []string{"@octo-org/docs"}}
+	response, err := fetchGraphQLResponse(context.Background(), client, ref, opts)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(response.ReviewThreads) != 1 || response.ReviewThreads[0].File != "docs/cache.md" {
+		t.Errorf("threads = %+v, want only the one on docs/cache.md", response.ReviewThreads)
+	}
+
+	widgets.PullRequests[0].BaseSHA = "missing"
+	if _, err := fetchGraphQLResponse(context.Background(), client, ref, opts); err == nil || !strings.Contains(err.Error(), "no CODEOWNERS file") {
+		t.Errorf("err = %v, want one saying the base branch has no CODEOWNERS file", err)
+	}
+}
@@ -0,0 +1,533 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Config file locations
+const (
+	configDirName      = "pr-review-cli"
+	userConfigFileName = "config.yaml"
+	repoConfigFileName = ".pr-review-cli.yaml"
+
+	// configEnvPrefix prefixes the environment variables that set options,
+	// e.g. PR_REVIEW_CLI_FORMAT or PR_REVIEW_CLI_INCLUDE_GENERAL
+	configEnvPrefix = "PR_REVIEW_CLI_"
+)
+
+// repoConfigOptions are the only settings a repo config may make: ones that
+// choose what is fetched and how it is shown. A cloned repository mustn't be
+// able to send your credentials to another host, swap them for its own, or
+// have files read or written outside the output you asked for.
+var repoConfigOptions = []string{
+	"owner", "repo", "org", "profile",
+	"format", "json-version", "error-format", "layout", "columns", "sort", "reviewer-weight",
+	"include-resolved", "include-outdated", "include-general",
+	"author", "exclude-author", "exclude-bots", "thread-started-by",
+	"path", "exclude-path", "codeowners-team",
+	"since", "until", "time-field", "since-commit",
+	"needs-response-from", "only-involved",
+	"grep", "grep-fixed", "i", "grep-diff", "category",
+	"review-requested", "state", "limit", "concurrency", "stream", "graphql",
+	"require-open", "fail-on", "max-unresolved",
+	"verbose", "cache-ttl", "no-cache",
+}
+
+// configCommands are the commands that read settings; each may have its own
+// section in a config file
+var configCommands = []string{"fetch", "list", "search"}
+
+// commandOptions are the options of each command that settings may be given
+// for, besides the credential and traffic options they share
+var commandOptions = map[string][]string{
+	"fetch": {
+		"owner", "repo", "pr", "format", "profile",
+		"pr-file", "concurrency", "output-dir", "stream", "json-version", "out-todo",
+		"error-format", "require-open", "fail-on", "max-unresolved",
+		"graphql", "include-resolved", "include-outdated", "include-general",
+		"author", "exclude-author", "exclude-bots", "thread-started-by",
+		"path", "exclude-path", "codeowners-team", "needs-response-from", "only-involved",
+		"grep", "grep-fixed", "i", "grep-diff", "category", "category-rules",
+		"since", "until", "time-field", "since-commit",
+		"sort", "reviewer-weight", "columns", "layout", "template",
+	},
+	"list": {
+		"owner", "repo", "org", "author", "review-requested",
+		"limit", "concurrency", "format", "error-format",
+	},
+	"search": {
+		"owner", "repo", "grep", "grep-fixed", "i", "grep-diff",
+		"state", "limit", "concurrency", "format", "error-format",
+	},
+	"auth": {"repo", "json"},
+}
+
+// Setting is an option value and where it came from
+type Setting struct {
+	Value  string
+	Source string
+}
+
+// configLayer holds the settings from one source, e.g. a config file
+type configLayer struct {
+	source   string
+	global   map[string]string            // top-level keys, for every command
+	commands map[string]map[string]string // keys from the command sections
+}
+
+// userConfigDir returns ~/.config/pr-review-cli, honouring XDG_CONFIG_HOME
+func userConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, configDirName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("finding home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", configDirName), nil
+}
+
+// findRepoFile looks for a file in the current directory and its parents,
+// stopping at the root of the git repository
+func findRepoFile(name string) (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("finding current directory: %w", err)
+	}
+
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadConfigFile reads a config file into a layer; a missing file gives nil
+func loadConfigFile(path, source string) (*configLayer, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	document, err := parseYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	layer, err := newConfigLayer(document, source)
+	if err == nil {
+		err = layer.checkKnown()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return layer, nil
+}
+
+// newConfigLayer splits a parsed config document into top-level settings and
+// per-command sections
+func newConfigLayer(document map[string]interface{}, source string) (*configLayer, error) {
+	layer := &configLayer{
+		source:   source,
+		global:   make(map[string]string),
+		commands: make(map[string]map[string]string),
+	}
+
+	for key, value := range document {
+		if section, ok := value.(map[string]interface{}); ok {
+			if !isConfigCommand(key) {
+				return nil, fmt.Errorf("unknown section %q (expected one of: %s)", key, strings.Join(configCommands, ", "))
+			}
+
+			settings := make(map[string]string, len(section))
+			for name, sectionValue := range section {
+				text, err := configValueString(sectionValue)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s: %w", key, name, err)
+				}
+				settings[name] = text
+			}
+			layer.commands[key] = settings
+			continue
+		}
+
+		text, err := configValueString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		layer.global[key] = text
+	}
+
+	return layer, nil
+}
+
+// configValueString turns a config value into the string a flag would be
+// given; lists are comma-separated and ~/ is expanded to the home directory
+func configValueString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return expandHome(v), nil
+	case []string:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = expandHome(item)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("expected a value or a list")
+	}
+}
+
+// expandHome replaces a leading ~/ with the user's home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// isConfigCommand reports whether a config section names a command
+func isConfigCommand(name string) bool {
+	for _, command := range configCommands {
+		if name == command {
+			return true
+		}
+	}
+	return false
+}
+
+// sharedOptions returns the credential and traffic options every command has
+func sharedOptions() []string {
+	flags := flag.NewFlagSet("", flag.ContinueOnError)
+	RegisterAuthFlags(flags)
+	RegisterTrafficFlags(flags)
+
+	var names []string
+	flags.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	return names
+}
+
+// isConfigOption reports whether a command has an option; an empty command
+// asks whether any command has it
+func isConfigOption(command, key string) bool {
+	for _, name := range sharedOptions() {
+		if name == key {
+			return true
+		}
+	}
+	for name, options := range commandOptions {
+		if command != "" && name != command {
+			continue
+		}
+		for _, option := range options {
+			if option == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sortedSettingKeys returns the keys of settings in alphabetical order
+func sortedSettingKeys(settings map[string]string) []string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkKnown returns an error if the layer sets an option no command has, or
+// one a command section's command doesn't have, so typos aren't ignored
+func (l *configLayer) checkKnown() error {
+	for _, key := range sortedSettingKeys(l.global) {
+		if !isConfigOption("", key) {
+			return fmt.Errorf("unknown option %q", key)
+		}
+	}
+	for _, command := range configCommands {
+		for _, key := range sortedSettingKeys(l.commands[command]) {
+			if !isConfigOption(command, key) {
+				return fmt.Errorf("%s.%s: %s has no --%s option", command, key, command, key)
+			}
+		}
+	}
+	return nil
+}
+
+// checkAllowed returns an error if the layer sets anything but the allowed
+// keys, at the top level or in a command section
+func (l *configLayer) checkAllowed(allowed []string) error {
+	isAllowed := func(key string) bool {
+		for _, name := range allowed {
+			if name == key {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, key := range sortedSettingKeys(l.global) {
+		if !isAllowed(key) {
+			return fmt.Errorf("%s can't be set in a repo config; use the user config, the environment or --%s", key, key)
+		}
+	}
+	for _, command := range configCommands {
+		for _, key := range sortedSettingKeys(l.commands[command]) {
+			if !isAllowed(key) {
+				return fmt.Errorf("%s.%s can't be set in a repo config; use the user config, the environment or --%s", command, key, key)
+			}
+		}
+	}
+	return nil
+}
+
+// settings returns a layer's settings for a command: the top-level keys,
+// overridden by the command's section
+func (l *configLayer) settings(command string) map[string]string {
+	merged := make(map[string]string, len(l.global))
+	for key, value := range l.global {
+		merged[key] = value
+	}
+	for key, value := range l.commands[command] {
+		merged[key] = value
+	}
+	return merged
+}
+
+// loadConfigLayers loads the config sources from lowest to highest
+// precedence: user config, repo config, then environment variables
+func loadConfigLayers() ([]*configLayer, error) {
+	var layers []*configLayer
+
+	dir, err := userConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	userPath := filepath.Join(dir, userConfigFileName)
+	user, err := loadConfigFile(userPath, "user config "+userPath)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		layers = append(layers, user)
+	}
+
+	repoPath, err := findRepoFile(repoConfigFileName)
+	if err != nil {
+		return nil, err
+	}
+	if repoPath != "" {
+		repo, err := loadConfigFile(repoPath, "repo config "+repoPath)
+		if err != nil {
+			return nil, err
+		}
+		if repo != nil {
+			if err := repo.checkAllowed(repoConfigOptions); err != nil {
+				return nil, fmt.Errorf("%s: %w", repoPath, err)
+			}
+			layers = append(layers, repo)
+		}
+	}
+
+	env := envConfigLayer()
+	if err := env.checkKnown(); err != nil {
+		return nil, fmt.Errorf("environment: %w", err)
+	}
+	return append(layers, env), nil
+}
+
+// envConfigLayer collects the PR_REVIEW_CLI_* environment variables
+func envConfigLayer() *configLayer {
+	layer := &configLayer{
+		source:   "env",
+		global:   make(map[string]string),
+		commands: make(map[string]map[string]string),
+	}
+
+	for _, entry := range os.Environ() {
+		name, value, found := strings.Cut(entry, "=")
+		if !found || !strings.HasPrefix(name, configEnvPrefix) {
+			continue
+		}
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(name, configEnvPrefix), "_", "-"))
+		layer.global[key] = value
+	}
+
+	return layer
+}
+
+// configEnvName returns the environment variable that sets an option
+func configEnvName(key string) string {
+	return configEnvPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// EffectiveSettings merges the config sources for a command. Later sources
+// take precedence: user config, then repo config, then environment variables,
+// then a profile.
+func EffectiveSettings(layers []*configLayer, command string) map[string]Setting {
+	effective := make(map[string]Setting)
+	for _, layer := range layers {
+		for key, value := range layer.settings(command) {
+			source := layer.source
+			if layer.source == "env" {
+				source = "env " + configEnvName(key)
+			}
+			effective[key] = Setting{Value: value, Source: source}
+		}
+	}
+	return effective
+}
+
+// withProfileLayer adds the selected profile's settings on top of the config
+// files and environment. Profiles only hold fetch options, so other commands
+// are left as they are. The profile is the one given, else the one set by a
+// "profile" setting, else the one the profiles file selects for the repository.
+func withProfileLayer(layers []*configLayer, command, profile, repo string) ([]*configLayer, error) {
+	if command != profileCommand {
+		return layers, nil
+	}
+
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	source := ""
+	if profile != "" {
+		source = fmt.Sprintf("profile %s", profile)
+	} else if setting, ok := EffectiveSettings(layers, command)["profile"]; ok && setting.Value != "" {
+		profile = setting.Value
+		source = fmt.Sprintf("profile %s, from %s", profile, setting.Source)
+	} else if profile = profiles.ProfileForRepo(repo); profile != "" {
+		source = fmt.Sprintf("profile %s, selected for %s", profile, repo)
+	} else {
+		return layers, nil
+	}
+
+	settings, err := profiles.Resolve(profile)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range sortedSettingKeys(settings) {
+		if !isConfigOption(profileCommand, key) {
+			return nil, fmt.Errorf("profile %s: %s has no --%s option", profile, profileCommand, key)
+		}
+	}
+
+	return append(layers, &configLayer{
+		source:   source,
+		global:   settings,
+		commands: make(map[string]map[string]string),
+	}), nil
+}
+
+// ApplyConfig fills in the options of a parsed command that weren't given on
+// the command line from a profile, the environment and config files. Settings
+// for options only other commands have are ignored, so one file can serve
+// every command; options no command has are rejected when loading. repo is the owner/repo being worked on, for selecting a
+// profile; it may be empty.
+func ApplyConfig(flags *flag.FlagSet, command, repo string) error {
+	layers, err := loadConfigLayers()
+	if err != nil {
+		return err
+	}
+
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	profile := ""
+	if explicit["profile"] {
+		profile = flags.Lookup("profile").Value.String()
+	}
+	if layers, err = withProfileLayer(layers, command, profile, repo); err != nil {
+		return err
+	}
+
+	settings := EffectiveSettings(layers, command)
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if explicit[key] || flags.Lookup(key) == nil {
+			continue
+		}
+		setting := settings[key]
+		if err := flags.Set(key, setting.Value); err != nil {
+			return fmt.Errorf("%s: invalid value %q for %s: %w", setting.Source, setting.Value, key, err)
+		}
+	}
+
+	return nil
+}
+
+// FormatConfigSettings renders the effective settings of a command with their sources
+func FormatConfigSettings(settings map[string]Setting, command string) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Effective settings for %s (flags > profile > env > repo config > user config):\n\n", command))
+
+	if len(settings) == 0 {
+		output.WriteString("  (none; every option uses its default)\n")
+		return output.String()
+	}
+
+	keys := make([]string, 0, len(settings))
+	width := 0
+	for key := range settings {
+		keys = append(keys, key)
+		if len(key) > width {
+			width = len(key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		setting := settings[key]
+		value := setting.Value
+		if isSecretSetting(key) && value != "" {
+			value = "********"
+		}
+		output.WriteString(fmt.Sprintf("  %-*s = %-20s (%s)\n", width, key, value, setting.Source))
+	}
+
+	output.WriteString("\nOptions not listed use their defaults; flags given on the command line override these.\n")
+	return output.String()
+}
+
+// isSecretSetting reports whether a setting's value should be masked when shown
+func isSecretSetting(key string) bool {
+	return strings.Contains(key, "token") || strings.Contains(key, "private-key")
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepoConfigAllowedKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		document map[string]interface{}
+		wantErr  string
+	}{
+		{
+			name:     "ordinary settings",
+			document: map[string]interface{}{"format": "json", "fetch": map[string]interface{}{"exclude-author": []string{"renovate"}}},
+		},
+		{
+			name:     "host",
+			document: map[string]interface{}{"host": "github.example.com"},
+			wantErr:  "host can't be set in a repo config",
+		},
+		{
+			name:     "token in a section",
+			document: map[string]interface{}{"fetch": map[string]interface{}{"token": "ghp_x"}},
+			wantErr:  "fetch.token can't be set in a repo config",
+		},
+		{
+			name:     "app key",
+			document: map[string]interface{}{"app-private-key": "key.pem"},
+			wantErr:  "--app-private-key",
+		},
+		{
+			name:     "replay",
+			document: map[string]interface{}{"replay": "fixtures"},
+			wantErr:  "replay can't be set in a repo config",
+		},
+		{
+			name:     "record",
+			document: map[string]interface{}{"search": map[string]interface{}{"record": "/tmp/traffic"}},
+			wantErr:  "search.record can't be set in a repo config",
+		},
+		{
+			name:     "out-todo",
+			document: map[string]interface{}{"fetch": map[string]interface{}{"out-todo": "../../.bashrc"}},
+			wantErr:  "fetch.out-todo can't be set in a repo config",
+		},
+		{
+			name:     "output-dir",
+			document: map[string]interface{}{"output-dir": "/etc"},
+			wantErr:  "output-dir can't be set in a repo config",
+		},
+		{
+			name:     "category-rules",
+			document: map[string]interface{}{"category-rules": "rules.json"},
+			wantErr:  "category-rules can't be set in a repo config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer, err := newConfigLayer(tt.document, "repo config")
+			if err != nil {
+				t.Fatalf("newConfigLayer: %v", err)
+			}
+			err = layer.checkAllowed(repoConfigOptions)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkAllowed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRepoConfigOptionsExist(t *testing.T) {
+	for _, key := range repoConfigOptions {
+		if !isConfigOption("", key) {
+			t.Errorf("repo config allows %q, which no command has", key)
+		}
+	}
+}
+
+func TestConfigUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		document map[string]interface{}
+		wantErr  string
+	}{
+		{
+			name:     "options of several commands",
+			document: map[string]interface{}{"format": "json", "review-requested": "@me", "cache-ttl": "2m", "search": map[string]interface{}{"state": "open"}},
+		},
+		{
+			name:     "shared option in a section",
+			document: map[string]interface{}{"list": map[string]interface{}{"host": "github.example.com"}},
+		},
+		{
+			name:     "typo",
+			document: map[string]interface{}{"include-generl": "true"},
+			wantErr:  `unknown option "include-generl"`,
+		},
+		{
+			name:     "another command's option",
+			document: map[string]interface{}{"list": map[string]interface{}{"include-general": "true"}},
+			wantErr:  "list.include-general: list has no --include-general option",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer, err := newConfigLayer(tt.document, "user config")
+			if err != nil {
+				t.Fatalf("newConfigLayer: %v", err)
+			}
+			err = layer.checkKnown()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkKnown: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	writeFile(t, filepath.Join(dir, configDirName, userConfigFileName),
+		"format: json\nlimit: 5\nfetch:\n  include-general: true\nsearch:\n  state: open\n")
+	t.Setenv(configEnvName("limit"), "7")
+
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	format := flags.String("format", "table", "")
+	limit := flags.Int("limit", 50, "")
+	concurrency := flags.Int("concurrency", 4, "")
+	if err := flags.Parse([]string{"--concurrency", "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ApplyConfig(flags, "list", ""); err != nil {
+		t.Fatalf("ApplyConfig: %v", err)
+	}
+	if *format != "json" || *limit != 7 || *concurrency != 2 {
+		t.Errorf("format = %s, limit = %d, concurrency = %d; want json from the user config, 7 from the env, 2 from the flag", *format, *limit, *concurrency)
+	}
+
+	t.Setenv(configEnvName("colour"), "always")
+	err := ApplyConfig(flag.NewFlagSet("list", flag.ContinueOnError), "list", "")
+	if err == nil || !strings.Contains(err.Error(), `unknown option "colour"`) {
+		t.Errorf("err = %v, want the unknown option rejected", err)
+	}
+}
+
+// writeFile creates a file and its directory
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// conventionalCommentRegex matches a Conventional Comments first line,
+// "label (decorations): subject", optionally wrapped in bold markers
+var conventionalCommentRegex = regexp.MustCompile(`^\s*(?:\*\*)?([A-Za-z]+)(?:\*\*)?(?:\s*\(([^)]*)\))?(?:\*\*)?\s*:(?:\*\*)?\s*(.*)$`)
+
+// conventionalLabels lists the labels of the Conventional Comments spec. No
+// label blocks merging by itself; only the "blocking" decoration does.
+var conventionalLabels = map[string]bool{
+	"issue":      true,
+	"todo":       true,
+	"chore":      true,
+	"suggestion": true,
+	"question":   true,
+	"praise":     true,
+	"nitpick":    true,
+	"thought":    true,
+	"note":       true,
+	"typo":       true,
+	"polish":     true,
+	"quibble":    true,
+}
+
+// ParseConventionalComment parses the first line of a comment body following
+// the Conventional Comments spec (https://conventionalcomments.org). It
+// returns nil if the comment doesn't start with a known label.
+func ParseConventionalComment(body string) *ConventionalComment {
+	firstLine := strings.SplitN(strings.TrimSpace(body), "\n", 2)[0]
+
+	matches := conventionalCommentRegex.FindStringSubmatch(firstLine)
+	if matches == nil {
+		return nil
+	}
+
+	label := strings.ToLower(matches[1])
+	if !conventionalLabels[label] {
+		return nil
+	}
+
+	comment := &ConventionalComment{
+		Label:   label,
+		Subject: strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(matches[3]), "**")),
+	}
+
+	for _, decoration := range strings.Split(matches[2], ",") {
+		decoration = strings.ToLower(strings.TrimSpace(decoration))
+		if decoration == "" {
+			continue
+		}
+		comment.Decorations = append(comment.Decorations, decoration)
+		if decoration == "blocking" {
+			comment.Blocking = true
+		}
+	}
+
+	return comment
+}
+
+// Category maps a Conventional Comments label onto an intent category; a
+// "blocking" decoration makes any label blocking. It is used only when no
+// classifier rule matches the comment.
+func (c *ConventionalComment) Category() string {
+	if c.Blocking {
+		return CategoryBlocking
+	}
+
+	switch c.Label {
+	case "praise":
+		return CategoryPraise
+	case "nitpick", "typo", "polish", "quibble":
+		return CategoryNit
+	case "suggestion":
+		return CategorySuggestion
+	case "question":
+		return CategoryQuestion
+	default:
+		return CategoryComment
+	}
+}
+
+// threadCategory decides a thread's category: the first classifier rule
+// matching its opening comment wins, then its Conventional Comments label
+func threadCategory(thread ReviewThread, classifier *Classifier) string {
+	category, matched := classifier.MatchThread(thread)
+	if !matched && thread.Conventional != nil {
+		return thread.Conventional.Category()
+	}
+	return category
+}
+
+// isBlockingThread reports whether a thread should block merging, which is
+// decided by its category alone
+func isBlockingThread(thread ReviewThread) bool {
+	return thread.Category == CategoryBlocking
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConventionalComment(t *testing.T) {
+	tests := []struct {
+		body         string
+		want         *ConventionalComment
+		wantCategory string
+	}{
+		{
+			body:         "suggestion: extract a helper",
+			want:         &ConventionalComment{Label: "suggestion", Subject: "extract a helper"},
+			wantCategory: CategorySuggestion,
+		},
+		{
+			body:         "issue (blocking): this leaks the file handle\n\nClose it in a defer.",
+			want:         &ConventionalComment{Label: "issue", Decorations: []string{"blocking"}, Subject: "this leaks the file handle", Blocking: true},
+			wantCategory: CategoryBlocking,
+		},
+		{
+			body:         "**nitpick (non-blocking, ux):** trailing space",
+			want:         &ConventionalComment{Label: "nitpick", Decorations: []string{"non-blocking", "ux"}, Subject: "trailing space"},
+			wantCategory: CategoryNit,
+		},
+		{
+			body:         "Question (blocking): is this thread-safe?",
+			want:         &ConventionalComment{Label: "question", Decorations: []string{"blocking"}, Subject: "is this thread-safe?", Blocking: true},
+			wantCategory: CategoryBlocking,
+		},
+		{
+			body:         "issue: the cache is never cleared",
+			want:         &ConventionalComment{Label: "issue", Subject: "the cache is never cleared"},
+			wantCategory: CategoryComment,
+		},
+		{body: "Note: check this", want: &ConventionalComment{Label: "note", Subject: "check this"}, wantCategory: CategoryComment},
+		{body: "Note to self: check this"},
+		{body: "fixme: not a conventional label"},
+		{body: "Looks good to me"},
+		{body: ""},
+	}
+
+	for _, tt := range tests {
+		got := ParseConventionalComment(tt.body)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseConventionalComment(%q) = %+v, want %+v", tt.body, got, tt.want)
+			continue
+		}
+		if got != nil && got.Category() != tt.wantCategory {
+			t.Errorf("ParseConventionalComment(%q).Category() = %q, want %q", tt.body, got.Category(), tt.wantCategory)
+		}
+	}
+}
+
+func TestThreadCategory(t *testing.T) {
+	// A team rule marking "issue:" comments as blocking, and one calling
+	// anything about spelling a nit
+	custom, err := NewClassifier(append([]ClassifierRule{
+		{Category: CategoryBlocking, Pattern: `(?i)^issue\s*:`},
+		{Category: CategoryNit, Pattern: `(?i)spelling`},
+	}, defaultClassifierRules...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rulesOnly, err := NewClassifier([]ClassifierRule{{Category: CategoryPraise, Pattern: `thanks`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		classifier *Classifier
+		want       string
+	}{
+		{name: "undecorated issue", body: "issue: the cache is never cleared", classifier: defaultClassifier, want: CategoryComment},
+		{name: "rule makes an issue blocking", body: "issue: the cache is never cleared", classifier: custom, want: CategoryBlocking},
+		{name: "blocking decoration", body: "issue (blocking): leaks a handle", classifier: defaultClassifier, want: CategoryBlocking},
+		{name: "rule beats the label", body: "suggestion: fix the spelling", classifier: custom, want: CategoryNit},
+		{name: "decoration without a rule for it", body: "question (blocking): is this safe", classifier: rulesOnly, want: CategoryBlocking},
+		{name: "label without a rule for it", body: "typo: teh", classifier: rulesOnly, want: CategoryNit},
+		{name: "not conventional", body: "Why is this needed?", classifier: defaultClassifier, want: CategoryQuestion},
+		{name: "no rule, no label", body: "This allocates.", classifier: rulesOnly, want: CategoryComment},
+	}
+
+	for _, tt := range tests {
+		thread := ReviewThread{
+			Comments:     []ThreadComment{{Body: tt.body}},
+			Conventional: ParseConventionalComment(tt.body),
+		}
+		thread.Category = threadCategory(thread, tt.classifier)
+		if thread.Category != tt.want {
+			t.Errorf("%s: category = %q, want %q", tt.name, thread.Category, tt.want)
+		}
+		if isBlockingThread(thread) != (tt.want == CategoryBlocking) {
+			t.Errorf("%s: isBlockingThread = %v with category %q", tt.name, isBlockingThread(thread), thread.Category)
+		}
+	}
+}
+
+func TestClaudeFormatSeparatesNonBlocking(t *testing.T) {
+	response := &PRCommentsResponse{
+		Owner: "octo-org", Repo: "widgets", PRNumber: 1,
+		ReviewThreads: []ReviewThread{
+			{ID: "T1", File: "cache.go", Category: CategoryBlocking, Comments: []ThreadComment{{Author: "octocat", Body: "issue (blocking): add a mutex"}}},
+			{ID: "T2", File: "cache.go", Category: CategoryQuestion, Comments: []ThreadComment{{Author: "octocat", Body: "Why a map?"}}},
+			{ID: "T3", File: "docs.md", Category: CategoryNit, Comments: []ThreadComment{{Author: "hubot", Body: "nit: typo"}}},
+		},
+	}
+	response.Summary = GenerateThreadSummary(response.ReviewThreads, nil, "octo-org", "widgets", 1)
+
+	output, err := FormatCommentsV2(response, "claude", FormatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	action, nonBlocking, found := strings.Cut(output, "## Non-blocking Feedback")
+	if !found {
+		t.Fatalf("no non-blocking section in:\n%s", output)
+	}
+	if !strings.Contains(action, "add a mutex") || strings.Contains(action, "Why a map?") || strings.Contains(action, "nit: typo") {
+		t.Errorf("action items:\n%s", action)
+	}
+	if !strings.Contains(nonBlocking, "Why a map?") || !strings.Contains(nonBlocking, "nit: typo") {
+		t.Errorf("non-blocking feedback:\n%s", nonBlocking)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// gitCredentialTimeout bounds the git credential helper, which may be slow or interactive
+const gitCredentialTimeout = 10 * time.Second
+
+// AuthFlags are the authentication options shared by the commands that talk to GitHub
+type AuthFlags struct {
+	Token          *string
+	TokenFile      *string
+	Host           *string
+	Verbose        *bool
+	AppID          *int64
+	AppPrivateKey  *string
+	InstallationID *int64
+
+	// HostGiven is set when --host was given on the command line rather
+	// than by a config file or the environment
+	HostGiven *bool
+}
+
+// RegisterAuthFlags defines the authentication options on a command
+func RegisterAuthFlags(flags *flag.FlagSet) AuthFlags {
+	return AuthFlags{
+		Token:     flags.String("token", "", "GitHub personal access token (optional if another credential source is available)"),
+		TokenFile: flags.String("token-file", "", "Read the GitHub token from this file"),
+		Host:      flags.String("host", defaultHost, "GitHub host, e.g. github.example.com for GitHub Enterprise Server"),
+		Verbose:   flags.Bool("verbose", false, "Report which credential source was used"),
+
+		AppID:          flags.Int64("app-id", 0, "Authenticate as this GitHub App (requires --app-private-key and --installation-id)"),
+		AppPrivateKey:  flags.String("app-private-key", "", "GitHub App private key: path to the PEM file, or the PEM text"),
+		InstallationID: flags.Int64("installation-id", 0, "GitHub App installation to request an access token for"),
+
+		HostGiven: new(bool),
+	}
+}
+
+// Credential is a GitHub token and where it came from
+type Credential struct {
+	Token  string
+	Source string
+}
+
+// credentialSourcesHelp lists the credential sources in the order they are tried
+const credentialSourcesHelp = `  --app-id                GitHub App installation token (with --app-private-key, --installation-id)
+  --token                 GitHub token given on the command line
+  --token-file            file containing the token
+  GITHUB_TOKEN            environment variable (github.com, or a --host given on the command line)
+  GH_TOKEN                environment variable (github.com)
+  GH_ENTERPRISE_TOKEN     environment variable (other hosts)
+  gh CLI hosts.yml        token stored by "gh auth login" for the host
+  git credential fill     token from your git credential helper for the host
+`
+
+// ResolveCredential finds a token for a host, trying in order: the --token
+// flag, --token-file, GITHUB_TOKEN, GH_TOKEN (github.com) or
+// GH_ENTERPRISE_TOKEN (other hosts), the gh CLI's hosts.yml and git's
+// credential helpers. GITHUB_TOKEN goes to a host other than github.com only
+// when hostGiven, i.e. the host was named on the command line: a config file
+// naming some other host mustn't be enough to send it your github.com token.
+func ResolveCredential(ctx context.Context, token, tokenFile, host string, hostGiven bool) (Credential, error) {
+	if token != "" {
+		return Credential{Token: token, Source: "--token flag"}, nil
+	}
+
+	if tokenFile != "" {
+		data, err := os.ReadFile(expandHome(tokenFile))
+		if err != nil {
+			return Credential{}, fmt.Errorf("reading token file: %w", err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return Credential{}, fmt.Errorf("token file %s is empty", tokenFile)
+		}
+		return Credential{Token: token, Source: "token file " + tokenFile}, nil
+	}
+
+	envVars := []string{"GITHUB_TOKEN", "GH_TOKEN"}
+	if !isDefaultHost(host) {
+		envVars = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+		if hostGiven {
+			envVars = append([]string{"GITHUB_TOKEN"}, envVars...)
+		}
+	}
+	for _, name := range envVars {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			return Credential{Token: value, Source: name + " environment variable"}, nil
+		}
+	}
+
+	if token, path, err := ghHostsToken(hostName(host)); err != nil {
+		return Credential{}, err
+	} else if token != "" {
+		return Credential{Token: token, Source: "gh CLI " + path}, nil
+	}
+
+	if token := gitCredentialToken(ctx, host); token != "" {
+		return Credential{Token: token, Source: "git credential helper"}, nil
+	}
+
+	if !isDefaultHost(host) {
+		return Credential{}, newError(KindAuth, "GitHub token is required for %s. Provide via --token, --token-file or GH_ENTERPRISE_TOKEN, or log in with gh auth login --hostname %s", hostName(host), hostName(host))
+	}
+	return Credential{}, newError(KindAuth, "GitHub token is required for %s. Provide via --token, --token-file, GITHUB_TOKEN or GH_TOKEN, or log in with gh auth login", hostName(host))
+}
+
+// usesApp reports whether any GitHub App option was given
+func (a AuthFlags) usesApp() bool {
+	return *a.AppID != 0 || *a.AppPrivateKey != "" || *a.InstallationID != 0
+}
+
+// AppCredentials loads the GitHub App options, which must be given together
+func (a AuthFlags) AppCredentials() (AppCredentials, error) {
+	if *a.AppID <= 0 || *a.AppPrivateKey == "" || *a.InstallationID <= 0 {
+		return AppCredentials{}, newError(KindUsage, "GitHub App authentication requires --app-id, --app-private-key and --installation-id")
+	}
+
+	key, err := LoadAppPrivateKey(*a.AppPrivateKey)
+	if err != nil {
+		return AppCredentials{}, err
+	}
+
+	return AppCredentials{
+		AppID:          *a.AppID,
+		InstallationID: *a.InstallationID,
+		PrivateKey:     key,
+	}, nil
+}
+
+// TokenSource returns the token source for the auth options and a description
+// of where its tokens come from: a GitHub App installation when the app
+// options are given, else the first token found by ResolveCredential
+func (a AuthFlags) TokenSource(ctx context.Context) (oauth2.TokenSource, string, error) {
+	if a.usesApp() {
+		app, err := a.AppCredentials()
+		if err != nil {
+			return nil, "", err
+		}
+
+		src := NewAppTokenSource(ctx, app, restAPIBaseURL(*a.Host))
+		// Fetch the first token now so a bad key or installation fails early
+		if _, err := src.Token(); err != nil {
+			return nil, "", err
+		}
+		return src, fmt.Sprintf("GitHub App %d installation %d", app.AppID, app.InstallationID), nil
+	}
+
+	credential, err := ResolveCredential(ctx, *a.Token, *a.TokenFile, *a.Host, *a.HostGiven)
+	if err != nil {
+		return nil, "", err
+	}
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: credential.Token}), credential.Source, nil
+}
+
+// ghConfigDir returns the gh CLI's config directory
+func ghConfigDir() (string, error) {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("finding home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "gh"), nil
+}
+
+// ghHostsToken reads the token the gh CLI stored for a host in hosts.yml.
+// Recent gh versions keep tokens in the system keyring instead, in which
+// case no token is found here.
+func ghHostsToken(host string) (string, string, error) {
+	dir, err := ghConfigDir()
+	if err != nil {
+		return "", "", err
+	}
+	path := filepath.Join(dir, "hosts.yml")
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", path, nil
+	}
+	if err != nil {
+		return "", path, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	hosts, err := parseYAML(data)
+	if err != nil {
+		return "", path, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	for name, value := range hosts {
+		entry, ok := value.(map[string]interface{})
+		if !ok || !strings.EqualFold(name, host) {
+			continue
+		}
+
+		if token, ok := entry["oauth_token"].(string); ok && token != "" {
+			return token, path, nil
+		}
+
+		// Multi-account layout: the active user's token sits under users
+		user, _ := entry["user"].(string)
+		if users, ok := entry["users"].(map[string]interface{}); ok && user != "" {
+			if account, ok := users[user].(map[string]interface{}); ok {
+				if token, ok := account["oauth_token"].(string); ok && token != "" {
+					return token, path, nil
+				}
+			}
+		}
+	}
+
+	return "", path, nil
+}
+
+// gitCredentialToken asks git's credential helpers for the host's password,
+// without letting git prompt. It returns "" if git isn't installed or has no
+// credential for the host.
+func gitCredentialToken(ctx context.Context, host string) string {
+	ctx, cancel := context.WithTimeout(ctx, gitCredentialTimeout)
+	defer cancel()
+
+	protocol := "https"
+	if strings.HasPrefix(hostBaseURL(host), "http://") {
+		protocol = "http"
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=%s\nhost=%s\n\n", protocol, hostName(host)))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		if value, found := strings.CutPrefix(scanner.Text(), "password="); found {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResolveCredentialEnvironment(t *testing.T) {
+	// Keep gh's hosts.yml and git's credential helpers out of the test
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		host       string
+		hostGiven  bool
+		wantSource string
+		wantKind   ErrorKind
+	}{
+		{
+			name:       "GITHUB_TOKEN for github.com",
+			env:        map[string]string{"GITHUB_TOKEN": "a", "GH_TOKEN": "b"},
+			host:       defaultHost,
+			wantSource: "GITHUB_TOKEN environment variable",
+		},
+		{
+			name:       "GH_TOKEN for github.com",
+			env:        map[string]string{"GH_TOKEN": "b", "GH_ENTERPRISE_TOKEN": "c"},
+			host:       defaultHost,
+			wantSource: "GH_TOKEN environment variable",
+		},
+		{
+			name:       "GITHUB_TOKEN not sent to a configured host",
+			env:        map[string]string{"GITHUB_TOKEN": "a", "GH_ENTERPRISE_TOKEN": "c"},
+			host:       "github.example.com",
+			wantSource: "GH_ENTERPRISE_TOKEN environment variable",
+		},
+		{
+			name:     "only GITHUB_TOKEN for a configured host",
+			env:      map[string]string{"GITHUB_TOKEN": "a"},
+			host:     "github.example.com",
+			wantKind: KindAuth,
+		},
+		{
+			name:       "GITHUB_TOKEN for a --host on the command line",
+			env:        map[string]string{"GITHUB_TOKEN": "a", "GH_ENTERPRISE_TOKEN": "c"},
+			host:       "github.example.com",
+			hostGiven:  true,
+			wantSource: "GITHUB_TOKEN environment variable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"GITHUB_TOKEN", "GH_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			credential, err := ResolveCredential(context.Background(), "", "", tt.host, tt.hostGiven)
+			if tt.wantKind != "" {
+				if ErrorKindOf(err) != tt.wantKind {
+					t.Errorf("err = %v, want kind %q", err, tt.wantKind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveCredential: %v", err)
+			}
+			if credential.Source != tt.wantSource {
+				t.Errorf("source = %q, want %q", credential.Source, tt.wantSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Attention score weights used to rank pull requests in the list command
+const (
+	attentionPerUnresolvedThread = 3
+	attentionChangesRequested    = 8
+	attentionReviewRequired      = 4
+	attentionMaxStaleDays        = 7
+)
+
+// PRListQuery describes which pull requests the list command should include
+type PRListQuery struct {
+	Owner           string
+	Repo            string
+	Org             string
+	Author          string
+	ReviewRequested string
+}
+
+// SearchString builds the GitHub search query for open pull requests
+func (q PRListQuery) SearchString() (string, error) {
+	terms := []string{"is:pr", "is:open", "archived:false"}
+
+	switch {
+	case q.Owner != "" && q.Repo != "":
+		terms = append(terms, fmt.Sprintf("repo:%s/%s", q.Owner, q.Repo))
+	case q.Owner != "" || q.Repo != "":
+		return "", fmt.Errorf("--owner and --repo must be used together")
+	}
+
+	if q.Org != "" {
+		terms = append(terms, "org:"+q.Org)
+	}
+	if q.Author != "" {
+		terms = append(terms, "author:"+q.Author)
+	}
+	if q.ReviewRequested != "" {
+		terms = append(terms, "review-requested:"+q.ReviewRequested)
+	}
+
+	if len(terms) == 3 {
+		return "", fmt.Errorf("one of --owner/--repo, --org, --author, or --review-requested is required")
+	}
+
+	return strings.Join(terms, " "), nil
+}
+
+// BuildPRListItem combines search metadata with the PR's review threads
+func BuildPRListItem(pr PullRequestSearchResult, threads []ReviewThread, now time.Time) PRListItem {
+	summary := GenerateThreadSummary(threads, nil, pr.Owner, pr.Repo, pr.Number)
+
+	item := PRListItem{
+		Owner:             pr.Owner,
+		Repo:              pr.Repo,
+		PRNumber:          pr.Number,
+		Title:             pr.Title,
+		URL:               pr.URL,
+		Author:            pr.Author,
+		IsDraft:           pr.IsDraft,
+		ReviewDecision:    pr.ReviewDecision,
+		LastActivity:      pr.UpdatedAt.Format("2006-01-02 15:04:05"),
+		UnresolvedThreads: summary.UnresolvedThreads,
+		ResolvedThreads:   summary.ResolvedThreads,
+		OutdatedThreads:   summary.OutdatedThreads,
+	}
+	item.AttentionScore = attentionScore(item, pr.UpdatedAt, now)
+
+	return item
+}
+
+// attentionScore estimates how urgently a PR needs its author's attention.
+// Unresolved threads dominate, followed by the review decision; PRs that have
+// been idle gain a little weight each day and drafts count for half.
+func attentionScore(item PRListItem, updatedAt, now time.Time) int {
+	score := item.UnresolvedThreads * attentionPerUnresolvedThread
+
+	switch item.ReviewDecision {
+	case "CHANGES_REQUESTED":
+		score += attentionChangesRequested
+	case "REVIEW_REQUIRED":
+		score += attentionReviewRequired
+	}
+
+	if !updatedAt.IsZero() {
+		staleDays := int(now.Sub(updatedAt).Hours() / 24)
+		if staleDays > attentionMaxStaleDays {
+			staleDays = attentionMaxStaleDays
+		}
+		if staleDays > 0 {
+			score += staleDays
+		}
+	}
+
+	if item.IsDraft {
+		score /= 2
+	}
+
+	return score
+}
+
+// sortPRListItems orders PRs by attention score, oldest activity first on ties
+func sortPRListItems(items []PRListItem) []PRListItem {
+	sorted := make([]PRListItem, len(items))
+	copy(sorted, items)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a := sorted[i]
+		b := sorted[j]
+
+		if a.AttentionScore != b.AttentionScore {
+			return a.AttentionScore > b.AttentionScore
+		}
+
+		if a.LastActivity != b.LastActivity {
+			return a.LastActivity < b.LastActivity
+		}
+
+		if a.Owner+"/"+a.Repo != b.Owner+"/"+b.Repo {
+			return a.Owner+"/"+a.Repo < b.Owner+"/"+b.Repo
+		}
+
+		return a.PRNumber < b.PRNumber
+	})
+
+	return sorted
+}
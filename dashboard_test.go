@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"pr-review-cli/fakegithub"
+)
+
+func TestPRListQuerySearchString(t *testing.T) {
+	tests := []struct {
+		query   PRListQuery
+		want    string
+		wantErr bool
+	}{
+		{query: PRListQuery{Owner: "octo-org", Repo: "widgets"}, want: "is:pr is:open archived:false repo:octo-org/widgets"},
+		{query: PRListQuery{Org: "octo-org", Author: "@me"}, want: "is:pr is:open archived:false org:octo-org author:@me"},
+		{query: PRListQuery{ReviewRequested: "hubot"}, want: "is:pr is:open archived:false review-requested:hubot"},
+		{query: PRListQuery{Owner: "octo-org"}, wantErr: true},
+		{query: PRListQuery{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.query.SearchString()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%+v: got %q, want an error", tt.query, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%+v: got %q, %v; want %q", tt.query, got, err, tt.want)
+		}
+	}
+}
+
+func TestAttentionScore(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		item      PRListItem
+		updatedAt time.Time
+		want      int
+	}{
+		{name: "nothing outstanding", updatedAt: now, want: 0},
+		{name: "unresolved threads", item: PRListItem{UnresolvedThreads: 2}, updatedAt: now, want: 6},
+		{name: "changes requested", item: PRListItem{UnresolvedThreads: 1, ReviewDecision: "CHANGES_REQUESTED"}, updatedAt: now, want: 11},
+		{name: "review required", item: PRListItem{ReviewDecision: "REVIEW_REQUIRED"}, updatedAt: now, want: 4},
+		{name: "idle for three days", item: PRListItem{UnresolvedThreads: 1}, updatedAt: now.Add(-3 * 24 * time.Hour), want: 6},
+		{name: "idle time is capped", updatedAt: now.Add(-30 * 24 * time.Hour), want: attentionMaxStaleDays},
+		{name: "unknown update time", item: PRListItem{UnresolvedThreads: 1}, want: 3},
+		{name: "drafts count for half", item: PRListItem{UnresolvedThreads: 3, IsDraft: true}, updatedAt: now, want: 4},
+	}
+
+	for _, tt := range tests {
+		if got := attentionScore(tt.item, tt.updatedAt, now); got != tt.want {
+			t.Errorf("%s: score = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSortPRListItems(t *testing.T) {
+	items := []PRListItem{
+		{Owner: "octo-org", Repo: "widgets", PRNumber: 3, AttentionScore: 5, LastActivity: "2026-03-02 00:00:00"},
+		{Owner: "octo-org", Repo: "gadgets", PRNumber: 9, AttentionScore: 5, LastActivity: "2026-03-01 00:00:00"},
+		{Owner: "octo-org", Repo: "widgets", PRNumber: 1, AttentionScore: 12, LastActivity: "2026-03-05 00:00:00"},
+		{Owner: "octo-org", Repo: "widgets", PRNumber: 2, AttentionScore: 5, LastActivity: "2026-03-01 00:00:00"},
+	}
+
+	var order []string
+	for _, item := range sortPRListItems(items) {
+		order = append(order, fmt.Sprintf("%s#%d", item.Repo, item.PRNumber))
+	}
+	if got, want := strings.Join(order, ","), "widgets#1,gadgets#9,widgets#2,widgets#3"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+func TestSearchPullRequests(t *testing.T) {
+	_, host := newFakeGitHub(t, func(s *fakegithub.Server) { s.PageSize = 1 })
+	client := NewGitHubGraphQLClientFromTokenSource(context.Background(), tokenSource("test"), host)
+
+	tests := []struct {
+		query string
+		limit int
+		want  []int
+	}{
+		{query: "is:pr is:open archived:false repo:octo-org/widgets", want: []int{1}},
+		{query: "is:pr repo:octo-org/widgets", want: []int{1, 2}},
+		{query: "is:pr repo:octo-org/widgets", limit: 1, want: []int{1}},
+		{query: "is:pr is:open org:octo-org author:octocat"},
+	}
+
+	for _, tt := range tests {
+		results, err := client.SearchPullRequests(context.Background(), tt.query, tt.limit)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.query, err)
+		}
+		var numbers []int
+		for _, pr := range results {
+			numbers = append(numbers, pr.Number)
+		}
+		if len(numbers) != len(tt.want) {
+			t.Errorf("%q: got PRs %v, want %v", tt.query, numbers, tt.want)
+			continue
+		}
+		for i := range numbers {
+			if numbers[i] != tt.want[i] {
+				t.Errorf("%q: got PRs %v, want %v", tt.query, numbers, tt.want)
+			}
+		}
+	}
+}
+
+func TestBuildPRListItem(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	pr := PullRequestSearchResult{Owner: "octo-org", Repo: "widgets", Number: 1, ReviewDecision: "CHANGES_REQUESTED", UpdatedAt: now}
+	threads := []ReviewThread{{IsResolved: false}, {IsResolved: false, IsOutdated: true}, {IsResolved: true}}
+
+	item := BuildPRListItem(pr, threads, now)
+	if item.UnresolvedThreads != 2 || item.ResolvedThreads != 1 || item.OutdatedThreads != 1 {
+		t.Errorf("counts = %d unresolved, %d resolved, %d outdated", item.UnresolvedThreads, item.ResolvedThreads, item.OutdatedThreads)
+	}
+	if item.AttentionScore != 14 || item.LastActivity != "2026-03-10 12:00:00" {
+		t.Errorf("score %d, last activity %q", item.AttentionScore, item.LastActivity)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorKind classifies a failure so scripts can react to it
+type ErrorKind string
+
+// Error kinds
+const (
+	KindError       ErrorKind = "error"        // anything not covered below
+	KindUsage       ErrorKind = "usage"        // invalid options or arguments
+	KindAuth        ErrorKind = "auth"         // missing, invalid or insufficient credentials
+	KindNotFound    ErrorKind = "not_found"    // repository or PR doesn't exist or isn't visible
+	KindPRClosed    ErrorKind = "pr_closed"    // PR is closed or merged and --require-open was given
+	KindRateLimited ErrorKind = "rate_limited" // API rate limit exceeded; retry later
+	KindNetwork     ErrorKind = "network"      // GitHub couldn't be reached or had a server error
+	KindPartial     ErrorKind = "partial"      // some of several PRs failed
+	KindUnresolved  ErrorKind = "unresolved"   // feedback left over the --fail-on limit
+)
+
+// Exit codes, one per error kind
+const (
+	ExitOK          = 0
+	ExitError       = 1
+	ExitUsage       = 2
+	ExitAuth        = 3
+	ExitNotFound    = 4
+	ExitPRClosed    = 5
+	ExitRateLimited = 6
+	ExitNetwork     = 7
+	ExitPartial     = 8
+	ExitUnresolved  = 9
+)
+
+// exitCodes maps error kinds to exit codes
+var exitCodes = map[ErrorKind]int{
+	KindError:       ExitError,
+	KindUsage:       ExitUsage,
+	KindAuth:        ExitAuth,
+	KindNotFound:    ExitNotFound,
+	KindPRClosed:    ExitPRClosed,
+	KindRateLimited: ExitRateLimited,
+	KindNetwork:     ExitNetwork,
+	KindPartial:     ExitPartial,
+	KindUnresolved:  ExitUnresolved,
+}
+
+// exitCodesHelp documents the exit codes
+const exitCodesHelp = `  0  success
+  1  other error
+  2  invalid options or arguments
+  3  authentication failed or the token lacks access
+  4  repository or pull request not found
+  5  pull request is closed or merged (with --require-open)
+  6  API rate limit exceeded; retry after the reset time
+  7  network error or GitHub server error; retry later
+  8  some of several pull requests failed
+  9  feedback left over the --fail-on limit
+`
+
+// CLIError is an error with a kind that determines the exit code
+type CLIError struct {
+	Kind    ErrorKind
+	Message string
+	Status  int       // HTTP status, if the error came from an API response
+	ResetAt time.Time // when a rate limit resets, if known
+	Err     error
+}
+
+func (e *CLIError) Error() string {
+	return e.Message
+}
+
+func (e *CLIError) Unwrap() error {
+	return e.Err
+}
+
+// newError creates an error of a kind
+func newError(kind ErrorKind, format string, args ...interface{}) *CLIError {
+	err := fmt.Errorf(format, args...)
+	return &CLIError{Kind: kind, Message: err.Error(), Err: errors.Unwrap(err)}
+}
+
+// ErrorKindOf returns the kind of an error, KindError if it has none
+func ErrorKindOf(err error) ErrorKind {
+	var cliErr *CLIError
+	if errors.As(err, &cliErr) {
+		return cliErr.Kind
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return KindNetwork
+	}
+	return KindError
+}
+
+// ExitCode returns the exit code for an error
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	return exitCodes[ErrorKindOf(err)]
+}
+
+// restError classifies a failed REST API response
+func restError(resp *http.Response, body []byte) *CLIError {
+	message := fmt.Sprintf("GitHub API error %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	return &CLIError{
+		Kind:    statusErrorKind(resp.StatusCode, resp.Header, string(body)),
+		Message: message,
+		Status:  resp.StatusCode,
+		ResetAt: rateLimitReset(resp.Header),
+	}
+}
+
+// statusErrorKind classifies an HTTP error status. GitHub answers 403 both for
+// missing permissions and for exceeded rate limits.
+func statusErrorKind(status int, header http.Header, body string) ErrorKind {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return KindRateLimited
+	case status == http.StatusForbidden && (header.Get("X-RateLimit-Remaining") == "0" || strings.Contains(strings.ToLower(body), "rate limit")):
+		return KindRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return KindAuth
+	case status == http.StatusNotFound || status == http.StatusGone:
+		return KindNotFound
+	case status >= 500:
+		return KindNetwork
+	default:
+		return KindError
+	}
+}
+
+// rateLimitReset returns when the rate limit resets, from the
+// X-RateLimit-Reset or Retry-After header
+func rateLimitReset(header http.Header) time.Time {
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil && header.Get("X-RateLimit-Remaining") == "0" {
+		return time.Unix(reset, 0).UTC()
+	}
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second).UTC()
+	}
+	return time.Time{}
+}
+
+// graphQLStatusPattern finds the HTTP status in a GraphQL client error
+var graphQLStatusPattern = regexp.MustCompile(`non-200 OK status code: (\d{3})`)
+
+// classifyGraphQLError gives a GraphQL client error a kind. The client
+// reports HTTP failures and GraphQL errors as plain messages, so the kind is
+// inferred from them.
+func classifyGraphQLError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var cliErr *CLIError
+	if errors.As(err, &cliErr) {
+		return err
+	}
+
+	message := err.Error()
+	lower := strings.ToLower(message)
+	kind := KindError
+	status := 0
+
+	switch {
+	case graphQLStatusPattern.MatchString(message):
+		status, _ = strconv.Atoi(graphQLStatusPattern.FindStringSubmatch(message)[1])
+		kind = statusErrorKind(status, http.Header{}, message)
+	case strings.Contains(lower, "rate limit"):
+		kind = KindRateLimited
+	case strings.Contains(message, "Could not resolve to a"):
+		kind = KindNotFound
+	case strings.Contains(message, "SAML") || strings.Contains(lower, "resource not accessible"):
+		kind = KindAuth
+	default:
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			kind = KindNetwork
+		}
+	}
+
+	return &CLIError{Kind: kind, Message: message, Status: status, Err: err}
+}
+
+// errorHint suggests what to do about an error of a kind
+func errorHint(kind ErrorKind, repo string) string {
+	switch kind {
+	case KindAuth:
+		if repo != "" {
+			return fmt.Sprintf("Check the token's scopes and access with: pr-review-cli auth status --repo %s", repo)
+		}
+		return "Check the token's scopes and access with: pr-review-cli auth status"
+	case KindNotFound:
+		return "Check the owner, repository and PR number; private repositories are reported as not found when the token can't see them"
+	case KindRateLimited:
+		return "Wait for the rate limit to reset, then retry"
+	case KindNetwork:
+		return "Check your connection and --host, then retry"
+	case KindUnresolved:
+		return "Address or resolve the remaining review feedback"
+	case KindPRClosed:
+		return "The pull request is no longer open; drop --require-open to fetch it anyway"
+	default:
+		return ""
+	}
+}
+
+// Error output formats
+const (
+	ErrorFormatText = "text"
+	ErrorFormatJSON = "json"
+)
+
+// ErrorReport is the JSON object written to stderr with --error-format json
+type ErrorReport struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes one failure
+type ErrorDetail struct {
+	Kind     ErrorKind `json:"kind"`
+	ExitCode int       `json:"exit_code"`
+	Message  string    `json:"message"`
+	PR       string    `json:"pr,omitempty"`
+	Status   int       `json:"status,omitempty"`
+	ResetAt  string    `json:"reset_at,omitempty"`
+	Hint     string    `json:"hint,omitempty"`
+}
+
+// ErrorReporter writes errors to stderr as text or, with --error-format json,
+// as one JSON object per line. The zero value writes text.
+type ErrorReporter struct {
+	Format string
+	out    io.Writer
+}
+
+// NewErrorReporter creates a reporter for an --error-format value
+func NewErrorReporter(format string) ErrorReporter {
+	return ErrorReporter{Format: format, out: os.Stderr}
+}
+
+// isValidErrorFormat reports whether an --error-format value is supported
+func isValidErrorFormat(format string) bool {
+	return format == ErrorFormatText || format == ErrorFormatJSON
+}
+
+// Report writes an error. context describes what failed, e.g. "Error
+// fetching owner/repo#1"; ref is the PR concerned, if any.
+func (r ErrorReporter) Report(context string, err error, ref *PRRef) {
+	out := r.out
+	if out == nil {
+		out = os.Stderr
+	}
+
+	kind := ErrorKindOf(err)
+	repo := ""
+	if ref != nil {
+		repo = ref.Owner + "/" + ref.Repo
+	}
+	hint := errorHint(kind, repo)
+
+	if r.Format != ErrorFormatJSON {
+		fmt.Fprintf(out, "%s: %v\n", context, err)
+		if hint != "" {
+			fmt.Fprintf(out, "Hint: %s\n", hint)
+		}
+		return
+	}
+
+	detail := ErrorDetail{
+		Kind:     kind,
+		ExitCode: exitCodes[kind],
+		Message:  err.Error(),
+		Hint:     hint,
+	}
+	if ref != nil {
+		detail.PR = ref.String()
+	}
+	var cliErr *CLIError
+	if errors.As(err, &cliErr) {
+		detail.Status = cliErr.Status
+		if !cliErr.ResetAt.IsZero() {
+			detail.ResetAt = cliErr.ResetAt.Format(time.RFC3339)
+		}
+	}
+
+	data, marshalErr := json.Marshal(ErrorReport{Error: detail})
+	if marshalErr != nil {
+		fmt.Fprintf(out, "%s: %v\n", context, err)
+		return
+	}
+	fmt.Fprintln(out, string(data))
+}
+
+// Exit reports an error and exits with its exit code
+func (r ErrorReporter) Exit(context string, err error, ref *PRRef) {
+	r.Report(context, err, ref)
+	os.Exit(ExitCode(err))
+}
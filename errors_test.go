@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{errors.New("plain"), ExitError},
+		{newError(KindUsage, "bad flag"), ExitUsage},
+		{newError(KindAuth, "no token"), ExitAuth},
+		{newError(KindNotFound, "no PR"), ExitNotFound},
+		{newError(KindPRClosed, "merged"), ExitPRClosed},
+		{newError(KindRateLimited, "slow down"), ExitRateLimited},
+		{newError(KindNetwork, "offline"), ExitNetwork},
+		{newError(KindPartial, "some failed"), ExitPartial},
+		{newError(KindUnresolved, "threads left"), ExitUnresolved},
+		{fmt.Errorf("wrapped: %w", newError(KindAuth, "no token")), ExitAuth},
+	}
+
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestStatusErrorKind(t *testing.T) {
+	exhausted := http.Header{}
+	exhausted.Set("X-RateLimit-Remaining", "0")
+
+	tests := []struct {
+		status int
+		header http.Header
+		body   string
+		want   ErrorKind
+	}{
+		{http.StatusUnauthorized, http.Header{}, `{"message":"Bad credentials"}`, KindAuth},
+		{http.StatusForbidden, http.Header{}, `{"message":"Resource not accessible by integration"}`, KindAuth},
+		{http.StatusForbidden, exhausted, `{"message":"API rate limit exceeded"}`, KindRateLimited},
+		{http.StatusForbidden, http.Header{}, `{"message":"You have exceeded a secondary rate limit"}`, KindRateLimited},
+		{http.StatusTooManyRequests, http.Header{}, "", KindRateLimited},
+		{http.StatusNotFound, http.Header{}, `{"message":"Not Found"}`, KindNotFound},
+		{http.StatusBadGateway, http.Header{}, "", KindNetwork},
+		{http.StatusUnprocessableEntity, http.Header{}, "", KindError},
+	}
+
+	for _, tt := range tests {
+		if got := statusErrorKind(tt.status, tt.header, tt.body); got != tt.want {
+			t.Errorf("statusErrorKind(%d, %q) = %q, want %q", tt.status, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyGraphQLError(t *testing.T) {
+	tests := []struct {
+		message string
+		want    ErrorKind
+	}{
+		{"non-200 OK status code: 401 Unauthorized body: \"Bad credentials\"", KindAuth},
+		{"non-200 OK status code: 502 Bad Gateway body: \"\"", KindNetwork},
+		{"API rate limit exceeded for user ID 1.", KindRateLimited},
+		{"Could not resolve to a Repository with the name 'o/r'.", KindNotFound},
+		{"Resource not accessible by integration", KindAuth},
+		{"Something else went wrong", KindError},
+	}
+
+	for _, tt := range tests {
+		if got := ErrorKindOf(classifyGraphQLError(errors.New(tt.message))); got != tt.want {
+			t.Errorf("classifyGraphQLError(%q) kind = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	notFound := newError(KindNotFound, "no PR")
+	auth := newError(KindAuth, "no token")
+
+	tests := []struct {
+		name     string
+		failures []error
+		total    int
+		want     int
+	}{
+		{"none failed", nil, 3, ExitOK},
+		{"some failed", []error{notFound}, 3, ExitPartial},
+		{"all failed alike", []error{notFound, notFound}, 2, ExitNotFound},
+		{"all failed differently", []error{notFound, auth}, 2, ExitPartial},
+	}
+
+	for _, tt := range tests {
+		if got := ExitCode(batchError(tt.failures, tt.total)); got != tt.want {
+			t.Errorf("%s: exit code %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,517 @@
+package fakegithub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file parses and executes GraphQL documents. It covers what GraphQL
+// clients such as githubv4 send: one query or mutation with variables,
+// aliases, arguments and inline fragments. Named fragments and directives
+// are not supported.
+
+// operation is a parsed query or mutation
+type operation struct {
+	kind       string // "query" or "mutation"
+	selections []selection
+}
+
+// selection is a field, or an inline fragment if typeCondition is set
+type selection struct {
+	alias         string
+	name          string
+	args          map[string]interface{}
+	selections    []selection
+	typeCondition string
+}
+
+// variable is a reference to a variable in an argument value
+type variable string
+
+// enum is an enum value in an argument value
+type enum string
+
+// parser reads a GraphQL document token by token
+type parser struct {
+	src string
+	pos int
+	tok string // the current token; strings keep their quotes
+}
+
+// parseOperation parses a document holding one operation
+func parseOperation(src string) (*operation, error) {
+	p := &parser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	op := &operation{kind: "query"}
+	if p.tok != "{" {
+		if p.tok != "query" && p.tok != "mutation" {
+			return nil, fmt.Errorf("expected query or mutation, got %q", p.tok)
+		}
+		op.kind = p.tok
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if isName(p.tok) {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+		if p.tok == "(" {
+			// Variable definitions; the values come with the request
+			if err := p.skipBalanced("(", ")"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != "" {
+		return nil, fmt.Errorf("unexpected %q after the operation", p.tok)
+	}
+	op.selections = selections
+	return op, nil
+}
+
+// next moves to the next token. Whitespace, commas and comments are ignored.
+func (p *parser) next() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+			continue
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		break
+	}
+	if p.pos >= len(p.src) {
+		p.tok = ""
+		return nil
+	}
+
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+	case strings.ContainsRune("!$():=@[]{}|", rune(c)):
+		p.pos++
+	case c == '"':
+		p.pos++
+		for p.pos < len(p.src) && p.src[p.pos] != '"' {
+			if p.src[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.pos >= len(p.src) {
+			return fmt.Errorf("unterminated string")
+		}
+		p.pos++
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		p.pos++
+		for p.pos < len(p.src) && strings.ContainsRune("0123456789.eE+-", rune(p.src[p.pos])) {
+			p.pos++
+		}
+	case isNameChar(c):
+		for p.pos < len(p.src) && isNameChar(p.src[p.pos]) {
+			p.pos++
+		}
+	default:
+		return fmt.Errorf("unexpected character %q", c)
+	}
+	p.tok = p.src[start:p.pos]
+	return nil
+}
+
+// expect checks the current token and moves past it
+func (p *parser) expect(tok string) error {
+	if p.tok != tok {
+		return fmt.Errorf("expected %q, got %q", tok, p.tok)
+	}
+	return p.next()
+}
+
+// name reads a name
+func (p *parser) name() (string, error) {
+	if !isName(p.tok) {
+		return "", fmt.Errorf("expected a name, got %q", p.tok)
+	}
+	name := p.tok
+	return name, p.next()
+}
+
+// skipBalanced skips from an opening token to its closing token
+func (p *parser) skipBalanced(open, close string) error {
+	depth := 0
+	for {
+		switch p.tok {
+		case "":
+			return fmt.Errorf("expected %q", close)
+		case open:
+			depth++
+		case close:
+			depth--
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// selectionSet parses { selection ... }
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for p.tok != "}" {
+		if p.tok == "" {
+			return nil, fmt.Errorf("expected \"}\"")
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	return selections, p.next()
+}
+
+// selection parses a field or an inline fragment
+func (p *parser) selection() (selection, error) {
+	var sel selection
+
+	if p.tok == "..." {
+		if err := p.next(); err != nil {
+			return sel, err
+		}
+		if p.tok != "on" {
+			return sel, fmt.Errorf("named fragments are not supported")
+		}
+		if err := p.next(); err != nil {
+			return sel, err
+		}
+		typeName, err := p.name()
+		if err != nil {
+			return sel, err
+		}
+		sel.typeCondition = typeName
+		sel.selections, err = p.selectionSet()
+		return sel, err
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return sel, err
+	}
+	sel.name = name
+	if p.tok == ":" {
+		if err := p.next(); err != nil {
+			return sel, err
+		}
+		sel.alias = name
+		if sel.name, err = p.name(); err != nil {
+			return sel, err
+		}
+	}
+
+	if p.tok == "(" {
+		if sel.args, err = p.arguments(); err != nil {
+			return sel, err
+		}
+	}
+	if p.tok == "@" {
+		return sel, fmt.Errorf("directives are not supported")
+	}
+	if p.tok == "{" {
+		if sel.selections, err = p.selectionSet(); err != nil {
+			return sel, err
+		}
+	}
+	return sel, nil
+}
+
+// arguments parses (name: value ...)
+func (p *parser) arguments() (map[string]interface{}, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	args := make(map[string]interface{})
+	for p.tok != ")" {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if args[name], err = p.value(); err != nil {
+			return nil, err
+		}
+	}
+	return args, p.next()
+}
+
+// value parses an argument value. Numbers become float64, as in JSON
+// variables.
+func (p *parser) value() (interface{}, error) {
+	tok := p.tok
+	switch {
+	case tok == "$":
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return variable(name), err
+	case tok == "[":
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		list := []interface{}{}
+		for p.tok != "]" {
+			if p.tok == "" {
+				return nil, fmt.Errorf("expected \"]\"")
+			}
+			item, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.next()
+	case tok == "{":
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		object := make(map[string]interface{})
+		for p.tok != "}" {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.value(); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.next()
+	case strings.HasPrefix(tok, `"`):
+		var s string
+		if err := json.Unmarshal([]byte(tok), &s); err != nil {
+			return nil, fmt.Errorf("invalid string %s", tok)
+		}
+		return s, p.next()
+	case tok == "true" || tok == "false":
+		return tok == "true", p.next()
+	case tok == "null":
+		return nil, p.next()
+	case isName(tok):
+		return enum(tok), p.next()
+	default:
+		n, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected %q", tok)
+		}
+		return n, p.next()
+	}
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isName(tok string) bool {
+	return tok != "" && isNameChar(tok[0]) && (tok[0] < '0' || tok[0] > '9')
+}
+
+// resolveValue replaces variables in an argument value with their values
+// and enums with their names
+func resolveValue(value interface{}, variables map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case variable:
+		return variables[string(v)]
+	case enum:
+		return string(v)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = resolveValue(item, variables)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			object[name] = resolveValue(item, variables)
+		}
+		return object
+	default:
+		return v
+	}
+}
+
+// object is a GraphQL type that can resolve its fields
+type object interface {
+	typeName() string
+	field(name string, args arguments) (interface{}, error)
+}
+
+// arguments are the resolved arguments of a field
+type arguments map[string]interface{}
+
+func (a arguments) string(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+func (a arguments) int(name string, fallback int) int {
+	if n, ok := a[name].(float64); ok {
+		return int(n)
+	}
+	return fallback
+}
+
+func (a arguments) input() arguments {
+	input, _ := a["input"].(map[string]interface{})
+	return arguments(input)
+}
+
+// queryError is a GraphQL error, reported in the response's errors list
+type queryError struct {
+	Type    string        `json:"type,omitempty"`
+	Path    []interface{} `json:"path,omitempty"`
+	Message string        `json:"message"`
+}
+
+func (e *queryError) Error() string {
+	return e.Message
+}
+
+// errorf creates a query error of a GitHub error type, e.g. NOT_FOUND
+func errorf(errorType, format string, args ...interface{}) *queryError {
+	return &queryError{Type: errorType, Message: fmt.Sprintf(format, args...)}
+}
+
+// result is a JSON object that keeps the order of its fields, the order they
+// were selected in
+type result []resultField
+
+type resultField struct {
+	key   string
+	value interface{}
+}
+
+func (r result) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// executor runs an operation and collects the errors of the fields that
+// failed. A failed field is null in the result, as on GitHub.
+type executor struct {
+	variables map[string]interface{}
+	errors    []*queryError
+}
+
+// execute resolves the selections on an object
+func (e *executor) execute(obj object, selections []selection, path []interface{}) result {
+	var out result
+	for _, sel := range selections {
+		if sel.typeCondition != "" {
+			if sel.typeCondition == obj.typeName() {
+				out = append(out, e.execute(obj, sel.selections, path)...)
+			}
+			continue
+		}
+
+		key := sel.name
+		if sel.alias != "" {
+			key = sel.alias
+		}
+		fieldPath := append(append([]interface{}{}, path...), key)
+
+		var value interface{}
+		if sel.name == "__typename" {
+			value = obj.typeName()
+		} else {
+			args := make(arguments, len(sel.args))
+			for name, arg := range sel.args {
+				args[name] = resolveValue(arg, e.variables)
+			}
+			resolved, err := obj.field(sel.name, args)
+			if err != nil {
+				e.fail(err, fieldPath)
+			} else {
+				value = e.complete(resolved, sel, fieldPath)
+			}
+		}
+		out = append(out, resultField{key: key, value: value})
+	}
+	return out
+}
+
+// complete turns a resolved field value into its result
+func (e *executor) complete(value interface{}, sel selection, path []interface{}) interface{} {
+	switch v := value.(type) {
+	case object:
+		if v == nil {
+			return nil
+		}
+		if sel.selections == nil {
+			e.fail(errorf("", "Field '%s' of type '%s' must have a selection of subfields", sel.name, v.typeName()), path)
+			return nil
+		}
+		return e.execute(v, sel.selections, path)
+	case []object:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = e.complete(item, sel, append(append([]interface{}{}, path...), i))
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// fail records the error of a field
+func (e *executor) fail(err error, path []interface{}) {
+	qErr, ok := err.(*queryError)
+	if !ok {
+		qErr = &queryError{Message: err.Error()}
+	}
+	qErr.Path = path
+	e.errors = append(e.errors, qErr)
+}
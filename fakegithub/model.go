@@ -0,0 +1,271 @@
+// Package fakegithub is a fake GitHub API for end-to-end tests. It serves the
+// GraphQL queries and mutations and the REST endpoints pr-review-cli uses, over
+// an in-memory model of repositories and pull requests that can be seeded from
+// JSON.
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Data is the state of a fake GitHub: who is signed in and the repositories
+// they can see. Seed files hold a Data as JSON.
+type Data struct {
+	Viewer       string        `json:"viewer"`
+	Repositories []*Repository `json:"repositories"`
+
+	lastDatabaseID int64
+}
+
+// Repository is a repository with its pull requests
+type Repository struct {
+	Owner        string         `json:"owner"`
+	Name         string         `json:"name"`
+	Private      bool           `json:"private,omitempty"`
+	Permission   string         `json:"permission,omitempty"` // the viewer's: ADMIN, MAINTAIN, WRITE, TRIAGE or READ; default WRITE
+	Commits      []*Commit      `json:"commits,omitempty"`
+	PullRequests []*PullRequest `json:"pull_requests"`
+}
+
+// Commit is a commit and the files in it that can be read, e.g. CODEOWNERS
+type Commit struct {
+	SHA   string            `json:"sha"`
+	Date  time.Time         `json:"date"`
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// PullRequest is a pull request with its review threads and general comments
+type PullRequest struct {
+	Number         int        `json:"number"`
+	Title          string     `json:"title"`
+	Author         string     `json:"author"`
+	State          string     `json:"state,omitempty"` // OPEN, CLOSED or MERGED; default OPEN
+	Draft          bool       `json:"draft,omitempty"`
+	ReviewDecision string     `json:"review_decision,omitempty"` // APPROVED, CHANGES_REQUESTED or REVIEW_REQUIRED
+	BaseSHA        string     `json:"base_sha,omitempty"`
+	HeadSHA        string     `json:"head_sha,omitempty"`
+	CreatedAt      time.Time  `json:"created_at,omitempty"`
+	UpdatedAt      time.Time  `json:"updated_at,omitempty"`
+	Threads        []*Thread  `json:"threads,omitempty"`
+	Comments       []*Comment `json:"comments,omitempty"`
+
+	id string
+}
+
+// Thread is a review thread: comments on a line or range of a file
+type Thread struct {
+	ID        string     `json:"id,omitempty"`
+	Path      string     `json:"path"`
+	Line      *int       `json:"line,omitempty"`
+	StartLine *int       `json:"start_line,omitempty"`
+	Side      string     `json:"side,omitempty"` // LEFT or RIGHT; default RIGHT
+	Resolved  bool       `json:"resolved,omitempty"`
+	Outdated  bool       `json:"outdated,omitempty"`
+	Comments  []*Comment `json:"comments"`
+}
+
+// Comment is a review comment in a thread or a general PR comment
+type Comment struct {
+	ID         string    `json:"id,omitempty"`
+	DatabaseID int64     `json:"database_id,omitempty"`
+	Author     string    `json:"author"`
+	Bot        bool      `json:"bot,omitempty"`
+	Body       string    `json:"body"`
+	DiffHunk   string    `json:"diff_hunk,omitempty"` // review comments only
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+}
+
+// LoadData reads a seed file
+func LoadData(path string) (*Data, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening seed file: %w", err)
+	}
+	defer f.Close()
+
+	data, err := ReadData(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
+}
+
+// ReadData reads seed JSON
+func ReadData(r io.Reader) (*Data, error) {
+	var data Data
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("parsing seed data: %w", err)
+	}
+	return &data, nil
+}
+
+// normalize checks seed data and fills in what it leaves out: IDs, defaults
+// and timestamps
+func (d *Data) normalize(now time.Time) error {
+	if d.Viewer == "" {
+		d.Viewer = "octocat"
+	}
+
+	// New database IDs follow the largest one the seed gives
+	d.lastDatabaseID = 1000
+	d.eachComment(func(_ *PullRequest, _ *Thread, comment *Comment) {
+		if comment.DatabaseID > d.lastDatabaseID {
+			d.lastDatabaseID = comment.DatabaseID
+		}
+	})
+
+	repos := make(map[string]bool)
+	for _, repo := range d.Repositories {
+		if repo.Owner == "" || repo.Name == "" {
+			return fmt.Errorf("repository needs an owner and a name")
+		}
+		fullName := repo.Owner + "/" + repo.Name
+		if repos[fullName] {
+			return fmt.Errorf("repository %s is listed twice", fullName)
+		}
+		repos[fullName] = true
+
+		if repo.Permission == "" {
+			repo.Permission = "WRITE"
+		}
+		if !isOneOf(repo.Permission, "ADMIN", "MAINTAIN", "WRITE", "TRIAGE", "READ") {
+			return fmt.Errorf("%s: invalid permission %q", fullName, repo.Permission)
+		}
+
+		numbers := make(map[int]bool)
+		for _, pr := range repo.PullRequests {
+			if pr.Number <= 0 || numbers[pr.Number] {
+				return fmt.Errorf("%s: pull request needs a unique positive number, got %d", fullName, pr.Number)
+			}
+			numbers[pr.Number] = true
+			pr.id = fmt.Sprintf("PR_%s_%s_%d", repo.Owner, repo.Name, pr.Number)
+
+			if pr.State == "" {
+				pr.State = "OPEN"
+			}
+			if !isOneOf(pr.State, "OPEN", "CLOSED", "MERGED") {
+				return fmt.Errorf("%s#%d: invalid state %q", fullName, pr.Number, pr.State)
+			}
+			if pr.Author == "" {
+				pr.Author = d.Viewer
+			}
+			if pr.CreatedAt.IsZero() {
+				pr.CreatedAt = now
+			}
+			latest := pr.CreatedAt
+
+			for _, thread := range pr.Threads {
+				if len(thread.Comments) == 0 {
+					return fmt.Errorf("%s#%d: thread on %s has no comments", fullName, pr.Number, thread.Path)
+				}
+				if thread.Side == "" {
+					thread.Side = "RIGHT"
+				}
+				for _, comment := range thread.Comments {
+					latest = d.normalizeComment(comment, "PRRC", pr.CreatedAt, latest)
+				}
+				if thread.ID == "" {
+					thread.ID = fmt.Sprintf("PRRT_%d", thread.Comments[0].DatabaseID)
+				}
+			}
+			for _, comment := range pr.Comments {
+				latest = d.normalizeComment(comment, "IC", pr.CreatedAt, latest)
+			}
+
+			if pr.UpdatedAt.IsZero() {
+				pr.UpdatedAt = latest
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeComment fills in a comment's IDs and time and returns the later of
+// latest and the comment's time
+func (d *Data) normalizeComment(comment *Comment, prefix string, created, latest time.Time) time.Time {
+	if comment.DatabaseID == 0 {
+		comment.DatabaseID = d.newDatabaseID()
+	}
+	if comment.ID == "" {
+		comment.ID = fmt.Sprintf("%s_%d", prefix, comment.DatabaseID)
+	}
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = created
+	}
+	if comment.CreatedAt.After(latest) {
+		return comment.CreatedAt
+	}
+	return latest
+}
+
+// newDatabaseID returns an unused comment database ID
+func (d *Data) newDatabaseID() int64 {
+	d.lastDatabaseID++
+	return d.lastDatabaseID
+}
+
+// eachComment calls fn for every comment, with the thread it is in or nil for
+// general comments
+func (d *Data) eachComment(fn func(pr *PullRequest, thread *Thread, comment *Comment)) {
+	for _, repo := range d.Repositories {
+		for _, pr := range repo.PullRequests {
+			for _, thread := range pr.Threads {
+				for _, comment := range thread.Comments {
+					fn(pr, thread, comment)
+				}
+			}
+			for _, comment := range pr.Comments {
+				fn(pr, nil, comment)
+			}
+		}
+	}
+}
+
+// repository finds a repository by owner and name, ignoring case as GitHub does
+func (d *Data) repository(owner, name string) *Repository {
+	for _, repo := range d.Repositories {
+		if strings.EqualFold(repo.Owner, owner) && strings.EqualFold(repo.Name, name) {
+			return repo
+		}
+	}
+	return nil
+}
+
+// pullRequest finds a pull request by number
+func (r *Repository) pullRequest(number int) *PullRequest {
+	for _, pr := range r.PullRequests {
+		if pr.Number == number {
+			return pr
+		}
+	}
+	return nil
+}
+
+// commit finds a commit by full or abbreviated SHA
+func (r *Repository) commit(sha string) *Commit {
+	if len(sha) < 4 {
+		return nil
+	}
+	for _, commit := range r.Commits {
+		if strings.HasPrefix(commit.SHA, sha) {
+			return commit
+		}
+	}
+	return nil
+}
+
+func isOneOf(value string, options ...string) bool {
+	for _, option := range options {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,586 @@
+package fakegithub
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// This file holds the GraphQL types of the fake: the subset of GitHub's
+// schema pr-review-cli queries, plus the mutations that change review threads.
+
+// maxPageSize is GitHub's limit on first
+const maxPageSize = 100
+
+// unknownField is the error for a field the fake doesn't serve
+func unknownField(obj object, name string) error {
+	return errorf("", "Field '%s' doesn't exist on type '%s'", name, obj.typeName())
+}
+
+// fields is an object whose fields are fixed values, e.g. an actor or a
+// mutation payload
+type fields struct {
+	name   string
+	values map[string]interface{}
+}
+
+func (f fields) typeName() string { return f.name }
+
+func (f fields) field(name string, _ arguments) (interface{}, error) {
+	value, ok := f.values[name]
+	if !ok {
+		return nil, unknownField(f, name)
+	}
+	return value, nil
+}
+
+// actor returns a user or bot
+func actor(login string, bot bool) object {
+	typeName := "User"
+	if bot {
+		typeName = "Bot"
+	}
+	return fields{name: typeName, values: map[string]interface{}{"login": login}}
+}
+
+// dateTime formats a time as GitHub does
+func dateTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// connection is a page of a list, selected with first and after
+type connection struct {
+	name     string
+	nodes    []object
+	start    int // index of the first node on the page
+	end      int // index after the last node on the page
+	total    int
+	pageSize int
+}
+
+// paginate returns the page of nodes that a connection's first and after
+// arguments select. pageSize, if positive, caps the page below first, so small
+// seeds can exercise pagination.
+func paginate(name string, nodes []object, args arguments, pageSize int) (object, error) {
+	first := args.int("first", -1)
+	if first < 0 {
+		return nil, errorf("MISSING_PAGINATION_BOUNDARIES", "You must provide a `first` value to properly paginate the `%s` connection.", name)
+	}
+	if first > maxPageSize {
+		return nil, errorf("EXCESSIVE_PAGINATION", "Requesting %d records on the `%s` connection exceeds the `first` limit of %d records.", first, name, maxPageSize)
+	}
+	if pageSize > 0 && first > pageSize {
+		first = pageSize
+	}
+
+	start := 0
+	if after := args.string("after"); after != "" {
+		index, err := decodeCursor(after)
+		if err != nil || index > len(nodes) {
+			return nil, errorf("INVALID_CURSOR_ARGUMENTS", "`%s` does not appear to be a valid cursor.", after)
+		}
+		start = index
+	}
+	end := start + first
+	if end > len(nodes) {
+		end = len(nodes)
+	}
+
+	return &connection{name: name, nodes: nodes, start: start, end: end, total: len(nodes)}, nil
+}
+
+// encodeCursor returns the cursor after the node at index-1
+func encodeCursor(index int) string {
+	return base64.StdEncoding.EncodeToString([]byte("cursor:" + strconv.Itoa(index)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	value, found := strings.CutPrefix(string(data), "cursor:")
+	if !found {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return strconv.Atoi(value)
+}
+
+func (c *connection) typeName() string { return c.name }
+
+func (c *connection) field(name string, _ arguments) (interface{}, error) {
+	switch name {
+	case "nodes":
+		return c.nodes[c.start:c.end], nil
+	case "edges":
+		edges := make([]object, 0, c.end-c.start)
+		for i := c.start; i < c.end; i++ {
+			edges = append(edges, fields{name: c.name + "Edge", values: map[string]interface{}{
+				"cursor": encodeCursor(i + 1),
+				"node":   c.nodes[i],
+			}})
+		}
+		return edges, nil
+	case "totalCount", "issueCount":
+		return c.total, nil
+	case "pageInfo":
+		info := map[string]interface{}{
+			"hasNextPage":     c.end < c.total,
+			"hasPreviousPage": c.start > 0,
+			"startCursor":     nil,
+			"endCursor":       nil,
+		}
+		if c.end > c.start {
+			info["startCursor"] = encodeCursor(c.start + 1)
+			info["endCursor"] = encodeCursor(c.end)
+		}
+		return fields{name: "PageInfo", values: info}, nil
+	default:
+		return nil, unknownField(c, name)
+	}
+}
+
+// queryRoot is the Query type
+type queryRoot struct {
+	s *Server
+}
+
+func (q queryRoot) typeName() string { return "Query" }
+
+func (q queryRoot) field(name string, args arguments) (interface{}, error) {
+	switch name {
+	case "viewer":
+		return actor(q.s.data.Viewer, false), nil
+	case "repository":
+		repo := q.s.data.repository(args.string("owner"), args.string("name"))
+		if repo == nil {
+			return nil, errorf("NOT_FOUND", "Could not resolve to a Repository with the name '%s/%s'.", args.string("owner"), args.string("name"))
+		}
+		return &repositoryObject{s: q.s, repo: repo}, nil
+	case "node":
+		node := q.s.node(args.string("id"))
+		if node == nil {
+			return nil, errorf("NOT_FOUND", "Could not resolve to a node with the global id of '%s'", args.string("id"))
+		}
+		return node, nil
+	case "search":
+		return q.s.search(args)
+	default:
+		return nil, unknownField(q, name)
+	}
+}
+
+// node finds a pull request, review thread or comment by ID
+func (s *Server) node(id string) object {
+	var found object
+	for _, repo := range s.data.Repositories {
+		for _, pr := range repo.PullRequests {
+			if pr.id == id {
+				return &pullRequestObject{s: s, repo: repo, pr: pr}
+			}
+			for _, thread := range pr.Threads {
+				if thread.ID == id {
+					return &threadObject{s: s, repo: repo, pr: pr, thread: thread}
+				}
+			}
+		}
+	}
+	s.data.eachComment(func(pr *PullRequest, thread *Thread, comment *Comment) {
+		if comment.ID == id && found == nil {
+			found = s.comment(pr, thread, comment)
+		}
+	})
+	return found
+}
+
+// search runs an issue search. It understands the qualifiers pr-review-cli
+// uses: is:pr, is:issue, is:open, is:closed, is:merged, is:draft, repo:,
+// org:, user:, author: and review-requested:, which matches any open pull
+// request since the fake has no review requests. Other qualifiers are
+// ignored and other words must appear in the title.
+func (s *Server) search(args arguments) (interface{}, error) {
+	if args.string("type") != "ISSUE" {
+		return nil, errorf("", "The fake only supports searches of type ISSUE")
+	}
+
+	var matches []object
+	for _, repo := range s.data.Repositories {
+		for _, pr := range repo.PullRequests {
+			if s.matchesSearch(repo, pr, args.string("query")) {
+				matches = append(matches, &pullRequestObject{s: s, repo: repo, pr: pr})
+			}
+		}
+	}
+
+	// Most recently updated first, as with GitHub's default sort by best match
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].(*pullRequestObject).pr.UpdatedAt.After(matches[j].(*pullRequestObject).pr.UpdatedAt)
+	})
+	return paginate("SearchResultItemConnection", matches, args, s.PageSize)
+}
+
+func (s *Server) matchesSearch(repo *Repository, pr *PullRequest, query string) bool {
+	for _, term := range strings.Fields(query) {
+		qualifier, value, found := strings.Cut(term, ":")
+		if !found {
+			if !strings.Contains(strings.ToLower(pr.Title), strings.ToLower(term)) {
+				return false
+			}
+			continue
+		}
+
+		switch qualifier {
+		case "is":
+			switch value {
+			case "issue":
+				return false
+			case "open", "closed", "merged":
+				if pr.State != strings.ToUpper(value) && !(value == "closed" && pr.State == "MERGED") {
+					return false
+				}
+			case "draft":
+				if !pr.Draft {
+					return false
+				}
+			}
+		case "repo":
+			if !strings.EqualFold(value, repo.Owner+"/"+repo.Name) {
+				return false
+			}
+		case "org", "user":
+			if !strings.EqualFold(value, repo.Owner) {
+				return false
+			}
+		case "author":
+			if value == "@me" {
+				value = s.data.Viewer
+			}
+			if !strings.EqualFold(value, pr.Author) {
+				return false
+			}
+		case "review-requested":
+			if pr.State != "OPEN" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// repositoryObject is the Repository type
+type repositoryObject struct {
+	s    *Server
+	repo *Repository
+}
+
+func (r *repositoryObject) typeName() string { return "Repository" }
+
+func (r *repositoryObject) field(name string, args arguments) (interface{}, error) {
+	switch name {
+	case "name":
+		return r.repo.Name, nil
+	case "nameWithOwner":
+		return r.repo.Owner + "/" + r.repo.Name, nil
+	case "owner":
+		return fields{name: "Organization", values: map[string]interface{}{"login": r.repo.Owner}}, nil
+	case "isPrivate":
+		return r.repo.Private, nil
+	case "viewerPermission":
+		return r.repo.Permission, nil
+	case "url":
+		return r.s.webURL(r.repo.Owner, r.repo.Name), nil
+	case "pullRequest":
+		number := args.int("number", 0)
+		pr := r.repo.pullRequest(number)
+		if pr == nil {
+			return nil, errorf("NOT_FOUND", "Could not resolve to a PullRequest with the number of %d.", number)
+		}
+		return &pullRequestObject{s: r.s, repo: r.repo, pr: pr}, nil
+	case "pullRequests":
+		return r.pullRequests(args)
+	case "object":
+		return r.object(args.string("expression")), nil
+	default:
+		return nil, unknownField(r, name)
+	}
+}
+
+// pullRequests lists pull requests, newest first unless orderBy says
+// otherwise
+func (r *repositoryObject) pullRequests(args arguments) (interface{}, error) {
+	prs := append([]*PullRequest{}, r.repo.PullRequests...)
+
+	if states, ok := args["states"].([]interface{}); ok {
+		filtered := prs[:0]
+		for _, pr := range prs {
+			for _, state := range states {
+				if state == pr.State {
+					filtered = append(filtered, pr)
+					break
+				}
+			}
+		}
+		prs = filtered
+	}
+
+	orderBy, _ := args["orderBy"].(map[string]interface{})
+	field, _ := orderBy["field"].(string)
+	ascending := orderBy["direction"] == "ASC"
+	sort.SliceStable(prs, func(i, j int) bool {
+		a, b := prs[i].CreatedAt, prs[j].CreatedAt
+		if field == "UPDATED_AT" {
+			a, b = prs[i].UpdatedAt, prs[j].UpdatedAt
+		}
+		if a.Equal(b) {
+			return (prs[i].Number < prs[j].Number) == ascending
+		}
+		return a.Before(b) == ascending
+	})
+
+	nodes := make([]object, len(prs))
+	for i, pr := range prs {
+		nodes[i] = &pullRequestObject{s: r.s, repo: r.repo, pr: pr}
+	}
+	return paginate("PullRequestConnection", nodes, args, r.s.PageSize)
+}
+
+// object finds the git object an expression names: "SHA" for a commit or
+// "SHA:path" for a file. Objects that don't exist are null, as on GitHub.
+func (r *repositoryObject) object(expression string) interface{} {
+	sha, path, isFile := strings.Cut(expression, ":")
+	commit := r.repo.commit(sha)
+	if commit == nil {
+		return nil
+	}
+	if !isFile {
+		return fields{name: "Commit", values: map[string]interface{}{
+			"oid":           commit.SHA,
+			"committedDate": dateTime(commit.Date),
+		}}
+	}
+	text, ok := commit.Files[path]
+	if !ok {
+		return nil
+	}
+	return fields{name: "Blob", values: map[string]interface{}{"text": text}}
+}
+
+// pullRequestObject is the PullRequest type
+type pullRequestObject struct {
+	s    *Server
+	repo *Repository
+	pr   *PullRequest
+}
+
+func (p *pullRequestObject) typeName() string { return "PullRequest" }
+
+func (p *pullRequestObject) field(name string, args arguments) (interface{}, error) {
+	switch name {
+	case "id":
+		return p.pr.id, nil
+	case "number":
+		return p.pr.Number, nil
+	case "title":
+		return p.pr.Title, nil
+	case "url":
+		return p.s.webURL(p.repo.Owner, p.repo.Name, "pull", strconv.Itoa(p.pr.Number)), nil
+	case "state":
+		return p.pr.State, nil
+	case "isDraft":
+		return p.pr.Draft, nil
+	case "reviewDecision":
+		if p.pr.ReviewDecision == "" {
+			return nil, nil
+		}
+		return p.pr.ReviewDecision, nil
+	case "createdAt":
+		return dateTime(p.pr.CreatedAt), nil
+	case "updatedAt":
+		return dateTime(p.pr.UpdatedAt), nil
+	case "author":
+		return actor(p.pr.Author, false), nil
+	case "baseRefOid":
+		return p.pr.BaseSHA, nil
+	case "headRefOid":
+		return p.pr.HeadSHA, nil
+	case "repository":
+		return &repositoryObject{s: p.s, repo: p.repo}, nil
+	case "reviewThreads":
+		nodes := make([]object, len(p.pr.Threads))
+		for i, thread := range p.pr.Threads {
+			nodes[i] = &threadObject{s: p.s, repo: p.repo, pr: p.pr, thread: thread}
+		}
+		return paginate("PullRequestReviewThreadConnection", nodes, args, p.s.PageSize)
+	case "comments":
+		nodes := make([]object, len(p.pr.Comments))
+		for i, comment := range p.pr.Comments {
+			nodes[i] = p.s.comment(p.pr, nil, comment)
+		}
+		return paginate("IssueCommentConnection", nodes, args, p.s.PageSize)
+	default:
+		return nil, unknownField(p, name)
+	}
+}
+
+// threadObject is the PullRequestReviewThread type
+type threadObject struct {
+	s      *Server
+	repo   *Repository
+	pr     *PullRequest
+	thread *Thread
+}
+
+func (t *threadObject) typeName() string { return "PullRequestReviewThread" }
+
+func (t *threadObject) field(name string, args arguments) (interface{}, error) {
+	switch name {
+	case "id":
+		return t.thread.ID, nil
+	case "isResolved":
+		return t.thread.Resolved, nil
+	case "isOutdated":
+		return t.thread.Outdated, nil
+	case "path":
+		return t.thread.Path, nil
+	case "line":
+		return t.thread.Line, nil
+	case "startLine":
+		return t.thread.StartLine, nil
+	case "diffSide":
+		return t.thread.Side, nil
+	case "pullRequest":
+		return &pullRequestObject{s: t.s, repo: t.repo, pr: t.pr}, nil
+	case "comments":
+		nodes := make([]object, len(t.thread.Comments))
+		for i, comment := range t.thread.Comments {
+			nodes[i] = t.s.comment(t.pr, t.thread, comment)
+		}
+		return paginate("PullRequestReviewCommentConnection", nodes, args, t.s.PageSize)
+	default:
+		return nil, unknownField(t, name)
+	}
+}
+
+// comment returns a review comment, or a general comment if thread is nil
+func (s *Server) comment(pr *PullRequest, thread *Thread, comment *Comment) object {
+	repo := s.repositoryOf(pr)
+	prURL := s.webURL(repo.Owner, repo.Name, "pull", strconv.Itoa(pr.Number))
+
+	values := map[string]interface{}{
+		"id":         comment.ID,
+		"databaseId": comment.DatabaseID,
+		"body":       comment.Body,
+		"createdAt":  dateTime(comment.CreatedAt),
+		"author":     actor(comment.Author, comment.Bot),
+	}
+	if thread == nil {
+		values["url"] = fmt.Sprintf("%s#issuecomment-%d", prURL, comment.DatabaseID)
+		return fields{name: "IssueComment", values: values}
+	}
+
+	values["url"] = fmt.Sprintf("%s#discussion_r%d", prURL, comment.DatabaseID)
+	values["diffHunk"] = comment.DiffHunk
+	values["path"] = thread.Path
+	// Replies answer the comment that started the thread
+	values["replyTo"] = nil
+	if first := thread.Comments[0]; first != comment {
+		values["replyTo"] = s.comment(pr, thread, first)
+	}
+	return fields{name: "PullRequestReviewComment", values: values}
+}
+
+// repositoryOf finds the repository of a pull request
+func (s *Server) repositoryOf(pr *PullRequest) *Repository {
+	for _, repo := range s.data.Repositories {
+		for _, candidate := range repo.PullRequests {
+			if candidate == pr {
+				return repo
+			}
+		}
+	}
+	return nil
+}
+
+// mutationRoot is the Mutation type
+type mutationRoot struct {
+	s *Server
+}
+
+func (m mutationRoot) typeName() string { return "Mutation" }
+
+func (m mutationRoot) field(name string, args arguments) (interface{}, error) {
+	input := args.input()
+	switch name {
+	case "resolveReviewThread", "unresolveReviewThread":
+		thread, err := m.thread(input.string("threadId"))
+		if err != nil {
+			return nil, err
+		}
+		thread.thread.Resolved = name == "resolveReviewThread"
+		thread.pr.UpdatedAt = m.s.now()
+		return m.payload(name, input, map[string]interface{}{"thread": thread}), nil
+
+	case "addPullRequestReviewThreadReply":
+		thread, err := m.thread(input.string("pullRequestReviewThreadId"))
+		if err != nil {
+			return nil, err
+		}
+		comment := m.s.newComment("PRRC", input.string("body"))
+		comment.DiffHunk = thread.thread.Comments[0].DiffHunk
+		thread.thread.Comments = append(thread.thread.Comments, comment)
+		thread.pr.UpdatedAt = comment.CreatedAt
+		return m.payload(name, input, map[string]interface{}{
+			"comment": m.s.comment(thread.pr, thread.thread, comment),
+		}), nil
+
+	case "addComment":
+		node := m.s.node(input.string("subjectId"))
+		pr, ok := node.(*pullRequestObject)
+		if !ok {
+			return nil, errorf("NOT_FOUND", "Could not resolve to a node with the global id of '%s'", input.string("subjectId"))
+		}
+		comment := m.s.newComment("IC", input.string("body"))
+		pr.pr.Comments = append(pr.pr.Comments, comment)
+		pr.pr.UpdatedAt = comment.CreatedAt
+		return m.payload(name, input, map[string]interface{}{
+			"subject": pr,
+			"commentEdge": fields{name: "IssueCommentEdge", values: map[string]interface{}{
+				"cursor": encodeCursor(len(pr.pr.Comments)),
+				"node":   m.s.comment(pr.pr, nil, comment),
+			}},
+		}), nil
+
+	default:
+		return nil, unknownField(m, name)
+	}
+}
+
+// thread finds the review thread a mutation acts on
+func (m mutationRoot) thread(id string) (*threadObject, error) {
+	thread, ok := m.s.node(id).(*threadObject)
+	if !ok {
+		return nil, errorf("NOT_FOUND", "Could not resolve to a node with the global id of '%s'", id)
+	}
+	return thread, nil
+}
+
+// payload returns a mutation's payload, e.g. ResolveReviewThreadPayload
+func (m mutationRoot) payload(mutation string, input arguments, values map[string]interface{}) object {
+	values["clientMutationId"] = input["clientMutationId"]
+	return fields{name: strings.ToUpper(mutation[:1]) + mutation[1:] + "Payload", values: values}
+}
+
+// newComment creates a comment by the viewer
+func (s *Server) newComment(prefix, body string) *Comment {
+	id := s.data.newDatabaseID()
+	return &Comment{
+		ID:         fmt.Sprintf("%s_%d", prefix, id),
+		DatabaseID: id,
+		Author:     s.data.Viewer,
+		Body:       body,
+		CreatedAt:  s.now(),
+	}
+}
@@ -0,0 +1,453 @@
+package fakegithub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate limit the fake reports. Every request counts against it except 304
+// Not Modified responses, as on GitHub.
+const defaultRateLimit = 5000
+
+// Server is a fake GitHub API. It serves GraphQL at /graphql and REST at /,
+// as api.github.com does, and also at /api/graphql and /api/v3, as GitHub
+// Enterprise Server does, so pr-review-cli can use it with --host.
+type Server struct {
+	// Token, if set, is the only token the server accepts. Otherwise any
+	// token is accepted, but one is still required.
+	Token string
+
+	// PageSize, if positive, caps the size of GraphQL pages below the first
+	// argument, so small seeds exercise pagination.
+	PageSize int
+
+	// RateLimit, if positive, replaces the 5000 requests an hour GitHub
+	// allows. Once it is used up, requests fail as on GitHub.
+	RateLimit int
+
+	// Scopes, if not nil, replace the repo and read:org OAuth scopes
+	// reported in X-OAuth-Scopes, as for a classic token
+	Scopes []string
+
+	// FineGrained leaves out X-OAuth-Scopes, as GitHub does for
+	// fine-grained personal access tokens and app tokens
+	FineGrained bool
+
+	// WebURL is the base of the html URLs of pull requests and comments
+	WebURL string
+
+	// Logger, if set, logs every request
+	Logger *log.Logger
+
+	mu       sync.Mutex
+	data     *Data
+	requests int
+	resetAt  time.Time
+}
+
+// New creates a server over seed data, which it takes ownership of. A nil
+// seed starts with no repositories.
+func New(data *Data) (*Server, error) {
+	if data == nil {
+		data = &Data{}
+	}
+	now := time.Now()
+	if err := data.normalize(now); err != nil {
+		return nil, fmt.Errorf("invalid seed data: %w", err)
+	}
+	return &Server{
+		WebURL:  "https://github.com",
+		data:    data,
+		resetAt: now.Add(time.Hour),
+	}, nil
+}
+
+// now returns the current time, for timestamps of changes
+func (s *Server) now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// webURL joins a path to the web URL
+func (s *Server) webURL(parts ...string) string {
+	return strings.TrimSuffix(s.WebURL, "/") + "/" + strings.Join(parts, "/")
+}
+
+// Requests returns how many requests have counted against the rate limit
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// WriteData writes the current state, including changes made by mutations,
+// as seed JSON
+func (s *Server) WriteData(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s.data)
+}
+
+// statusRecorder remembers the status of a response, for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// ServeHTTP routes a request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.mu.Lock()
+	s.route(recorder, r)
+	s.mu.Unlock()
+
+	if s.Logger != nil {
+		s.Logger.Printf("%s %s %d", r.Method, r.URL.RequestURI(), recorder.status)
+	}
+}
+
+// route handles a request; s.mu is held
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if path == "/api/graphql" {
+		path = "/graphql"
+	} else {
+		path = strings.TrimPrefix(path, "/api/v3")
+	}
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+	// Installation tokens are requested with an app's JWT, not a token
+	if r.Method == http.MethodPost && len(parts) == 4 && parts[0] == "app" && parts[1] == "installations" && parts[3] == "access_tokens" {
+		s.installationToken(w, r)
+		return
+	}
+
+	if !s.authorized(r) {
+		s.writeJSON(w, r, http.StatusUnauthorized, map[string]string{
+			"message":           "Bad credentials",
+			"documentation_url": "https://docs.github.com/rest",
+		})
+		return
+	}
+
+	if s.remaining() == 0 && path != "/rate_limit" {
+		s.rateLimited(w, r, path == "/graphql")
+		return
+	}
+
+	switch {
+	case path == "/graphql" && r.Method == http.MethodPost:
+		s.graphQL(w, r)
+	case r.Method != http.MethodGet:
+		s.notFound(w, r)
+	case path == "/user":
+		s.writeJSON(w, r, http.StatusOK, map[string]interface{}{"login": s.data.Viewer, "id": 1, "type": "User"})
+	case path == "/rate_limit":
+		s.rateLimitStatus(w, r)
+	case len(parts) == 3 && parts[0] == "repos":
+		s.repository(w, r, parts[1], parts[2])
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "pulls" && parts[5] == "comments":
+		s.reviewComments(w, r, parts[1], parts[2], parts[4])
+	default:
+		s.notFound(w, r)
+	}
+}
+
+// authorized reports whether a request carries an accepted token
+func (s *Server) authorized(r *http.Request) bool {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || token == "" || !(strings.EqualFold(scheme, "bearer") || strings.EqualFold(scheme, "token")) {
+		return false
+	}
+	return s.Token == "" || token == s.Token
+}
+
+// writeJSON writes a JSON response that counts against the rate limit
+func (s *Server) writeJSON(w http.ResponseWriter, r *http.Request, status int, body interface{}) {
+	s.write(w, r, status, body, true)
+}
+
+// write writes a JSON response with GitHub's rate limit headers. GET
+// responses carry an ETag and answer a matching If-None-Match with 304 Not
+// Modified, which doesn't count against the rate limit.
+func (s *Server) write(w http.ResponseWriter, r *http.Request, status int, body interface{}, counts bool) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	if !s.FineGrained {
+		scopes := "repo, read:org"
+		if s.Scopes != nil {
+			scopes = strings.Join(s.Scopes, ", ")
+		}
+		header.Set("X-OAuth-Scopes", scopes)
+	}
+
+	if r.Method == http.MethodGet && status == http.StatusOK {
+		sum := sha256.Sum256(data)
+		etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+		header.Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			s.setRateLimitHeaders(header)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	if counts {
+		s.requests++
+	}
+	s.setRateLimitHeaders(header)
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+func (s *Server) setRateLimitHeaders(header http.Header) {
+	header.Set("X-RateLimit-Limit", strconv.Itoa(s.rateLimit()))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(s.remaining()))
+	header.Set("X-RateLimit-Used", strconv.Itoa(s.requests))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(s.resetAt.Unix(), 10))
+}
+
+func (s *Server) rateLimit() int {
+	if s.RateLimit > 0 {
+		return s.RateLimit
+	}
+	return defaultRateLimit
+}
+
+func (s *Server) remaining() int {
+	if s.requests >= s.rateLimit() {
+		return 0
+	}
+	return s.rateLimit() - s.requests
+}
+
+// rateLimited answers a request made after the rate limit ran out: REST with
+// 403, GraphQL with a RATE_LIMITED error. Neither counts.
+func (s *Server) rateLimited(w http.ResponseWriter, r *http.Request, graphQL bool) {
+	message := "API rate limit exceeded for user ID 1."
+	if graphQL {
+		s.write(w, r, http.StatusOK, graphQLResponse{Errors: []*queryError{errorf("RATE_LIMITED", "%s", message)}}, false)
+		return
+	}
+	s.write(w, r, http.StatusForbidden, map[string]string{
+		"message":           message,
+		"documentation_url": "https://docs.github.com/rest/overview/resources-in-the-rest-api#rate-limiting",
+	}, false)
+}
+
+func (s *Server) notFound(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, r, http.StatusNotFound, map[string]string{
+		"message":           "Not Found",
+		"documentation_url": "https://docs.github.com/rest",
+	})
+}
+
+// graphQLRequest is the body of a GraphQL request
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// graphQLResponse is the body of a GraphQL response
+type graphQLResponse struct {
+	Data   interface{}   `json:"data,omitempty"`
+	Errors []*queryError `json:"errors,omitempty"`
+}
+
+// graphQL runs a query or mutation
+func (s *Server) graphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeJSON(w, r, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+
+	op, err := parseOperation(req.Query)
+	if err != nil {
+		s.writeJSON(w, r, http.StatusOK, graphQLResponse{Errors: []*queryError{errorf("", "Parse error: %v", err)}})
+		return
+	}
+
+	exec := &executor{variables: req.Variables}
+	var root object = queryRoot{s: s}
+	if op.kind == "mutation" {
+		root = mutationRoot{s: s}
+	}
+	data := exec.execute(root, op.selections, nil)
+	s.writeJSON(w, r, http.StatusOK, graphQLResponse{Data: data, Errors: exec.errors})
+}
+
+// installationToken issues a GitHub App installation token. The app's JWT
+// is not verified.
+func (s *Server) installationToken(w http.ResponseWriter, r *http.Request) {
+	if scheme, _, _ := strings.Cut(r.Header.Get("Authorization"), " "); !strings.EqualFold(scheme, "bearer") {
+		s.writeJSON(w, r, http.StatusUnauthorized, map[string]string{"message": "A JSON web token could not be decoded"})
+		return
+	}
+	token := s.Token
+	if token == "" {
+		token = "ghs_fakeinstallationtoken"
+	}
+	s.writeJSON(w, r, http.StatusCreated, map[string]string{
+		"token":      token,
+		"expires_at": dateTime(s.now().Add(time.Hour)),
+	})
+}
+
+// rateLimitStatus answers GET /rate_limit
+func (s *Server) rateLimitStatus(w http.ResponseWriter, r *http.Request) {
+	rate := map[string]interface{}{
+		"limit":     s.rateLimit(),
+		"remaining": s.remaining(),
+		"used":      s.requests,
+		"reset":     s.resetAt.Unix(),
+	}
+	// Checking the rate limit doesn't count against it
+	s.write(w, r, http.StatusOK, map[string]interface{}{
+		"resources": map[string]interface{}{"core": rate, "graphql": rate},
+		"rate":      rate,
+	}, false)
+}
+
+// repository answers GET /repos/{owner}/{repo}
+func (s *Server) repository(w http.ResponseWriter, r *http.Request, owner, name string) {
+	repo := s.data.repository(owner, name)
+	if repo == nil {
+		s.notFound(w, r)
+		return
+	}
+
+	level := map[string]int{"READ": 1, "TRIAGE": 2, "WRITE": 3, "MAINTAIN": 4, "ADMIN": 5}[repo.Permission]
+	s.writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"name":      repo.Name,
+		"full_name": repo.Owner + "/" + repo.Name,
+		"owner":     map[string]string{"login": repo.Owner},
+		"private":   repo.Private,
+		"html_url":  s.webURL(repo.Owner, repo.Name),
+		"permissions": map[string]bool{
+			"pull":     level >= 1,
+			"triage":   level >= 2,
+			"push":     level >= 3,
+			"maintain": level >= 4,
+			"admin":    level >= 5,
+		},
+	})
+}
+
+// reviewComments answers GET /repos/{owner}/{repo}/pulls/{number}/comments,
+// paginated with per_page and page and a Link header as on GitHub
+func (s *Server) reviewComments(w http.ResponseWriter, r *http.Request, owner, name, number string) {
+	repo := s.data.repository(owner, name)
+	n, err := strconv.Atoi(number)
+	if repo == nil || err != nil || repo.pullRequest(n) == nil {
+		s.notFound(w, r)
+		return
+	}
+	pr := repo.pullRequest(n)
+
+	var comments []map[string]interface{}
+	prURL := s.webURL(repo.Owner, repo.Name, "pull", number)
+	for _, thread := range pr.Threads {
+		first := thread.Comments[0]
+		for _, comment := range thread.Comments {
+			var position interface{}
+			if !thread.Outdated {
+				position = thread.Line
+			}
+			item := map[string]interface{}{
+				"id":                     comment.DatabaseID,
+				"node_id":                comment.ID,
+				"pull_request_review_id": comment.DatabaseID,
+				"diff_hunk":              comment.DiffHunk,
+				"path":                   thread.Path,
+				"commit_id":              pr.HeadSHA,
+				"original_commit_id":     pr.HeadSHA,
+				"user":                   map[string]interface{}{"login": comment.Author, "type": actor(comment.Author, comment.Bot).typeName()},
+				"body":                   comment.Body,
+				"created_at":             dateTime(comment.CreatedAt),
+				"updated_at":             dateTime(comment.CreatedAt),
+				"html_url":               fmt.Sprintf("%s#discussion_r%d", prURL, comment.DatabaseID),
+				"pull_request_url":       fmt.Sprintf("%s/repos/%s/%s/pulls/%s", s.apiURL(r), repo.Owner, repo.Name, number),
+				"start_line":             thread.StartLine,
+				"original_start_line":    thread.StartLine,
+				"line":                   thread.Line,
+				"original_line":          thread.Line,
+				"side":                   thread.Side,
+				"position":               position,
+				"original_position":      thread.Line,
+			}
+			if comment != first {
+				item["in_reply_to_id"] = first.DatabaseID
+			}
+			comments = append(comments, item)
+		}
+	}
+
+	perPage := queryInt(r, "per_page", 30)
+	if perPage > 100 {
+		perPage = 100
+	}
+	page := queryInt(r, "page", 1)
+	lastPage := (len(comments) + perPage - 1) / perPage
+	start := (page - 1) * perPage
+	if start > len(comments) {
+		start = len(comments)
+	}
+	end := start + perPage
+	if end > len(comments) {
+		end = len(comments)
+	}
+
+	if page < lastPage {
+		link := func(page int, rel string) string {
+			u := *r.URL
+			q := u.Query()
+			q.Set("page", strconv.Itoa(page))
+			q.Set("per_page", strconv.Itoa(perPage))
+			u.RawQuery = q.Encode()
+			return fmt.Sprintf(`<%s%s>; rel="%s"`, s.apiURL(r), strings.TrimPrefix(u.RequestURI(), "/api/v3"), rel)
+		}
+		w.Header().Set("Link", link(page+1, "next")+", "+link(lastPage, "last"))
+	}
+
+	s.writeJSON(w, r, http.StatusOK, append([]map[string]interface{}{}, comments[start:end]...))
+}
+
+// apiURL returns the REST base URL a request was sent to
+func (s *Server) apiURL(r *http.Request) string {
+	base := "http://" + r.Host
+	if strings.HasPrefix(r.URL.Path, "/api/v3") {
+		base += "/api/v3"
+	}
+	return base
+}
+
+// queryInt reads a positive integer query parameter
+func queryInt(r *http.Request, name string, fallback int) int {
+	n, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || n < 1 {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// isBotAuthor reports whether a comment author is a bot account. GraphQL
+// reports GitHub Apps with the Bot actor type, while bots posting through the
+// REST API or as regular users usually carry a "[bot]" login suffix.
+func isBotAuthor(login, actorType string) bool {
+	return actorType == "Bot" || strings.HasSuffix(strings.ToLower(login), "[bot]")
+}
+
+// normalizeLogin strips the @ prefix and [bot] suffix and lowercases a login
+// so that "@Dependabot", "dependabot" and "dependabot[bot]" compare equal
+func normalizeLogin(login string) string {
+	login = strings.ToLower(strings.TrimSpace(login))
+	login = strings.TrimPrefix(login, "@")
+	return strings.TrimSuffix(login, "[bot]")
+}
+
+// loginIn reports whether a login matches any entry of a login list
+func loginIn(login string, logins []string) bool {
+	normalized := normalizeLogin(login)
+	for _, candidate := range logins {
+		if normalizeLogin(candidate) == normalized {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAuthorFilters reports whether any author-based filter is active
+func (o FetchOptions) hasAuthorFilters() bool {
+	return len(o.Authors) > 0 || len(o.ExcludeAuthors) > 0 || o.ExcludeBots || len(o.ThreadStartedBy) > 0
+}
+
+// excludesCommentAuthor reports whether comments by this author are filtered out
+func (o FetchOptions) excludesCommentAuthor(login string, isBot bool) bool {
+	if o.ExcludeBots && isBot {
+		return true
+	}
+	return loginIn(login, o.ExcludeAuthors)
+}
+
+// filterThreadAuthors applies the author filters to a thread. A thread started
+// by an excluded author is dropped entirely; excluded replies are removed from
+// threads that are kept. It returns false if the thread should be dropped.
+func (o FetchOptions) filterThreadAuthors(thread *ReviewThread) bool {
+	if !o.hasAuthorFilters() {
+		return true
+	}
+	if len(thread.Comments) == 0 {
+		return len(o.Authors) == 0 && len(o.ThreadStartedBy) == 0
+	}
+
+	starter := thread.Comments[0]
+	if o.excludesCommentAuthor(starter.Author, starter.IsBot) {
+		return false
+	}
+	if len(o.ThreadStartedBy) > 0 && !loginIn(starter.Author, o.ThreadStartedBy) {
+		return false
+	}
+
+	kept := make([]ThreadComment, 0, len(thread.Comments))
+	matchedAuthor := len(o.Authors) == 0
+	for _, comment := range thread.Comments {
+		if o.excludesCommentAuthor(comment.Author, comment.IsBot) {
+			continue
+		}
+		if loginIn(comment.Author, o.Authors) {
+			matchedAuthor = true
+		}
+		kept = append(kept, comment)
+	}
+	thread.Comments = kept
+
+	return matchedAuthor
+}
+
+// keepGeneralCommentAuthor applies the author filters to a general comment,
+// which counts as a thread of its own started by its author
+func (o FetchOptions) keepGeneralCommentAuthor(comment GeneralComment) bool {
+	if o.excludesCommentAuthor(comment.Author, comment.IsBot) {
+		return false
+	}
+	if len(o.Authors) > 0 && !loginIn(comment.Author, o.Authors) {
+		return false
+	}
+	if len(o.ThreadStartedBy) > 0 && !loginIn(comment.Author, o.ThreadStartedBy) {
+		return false
+	}
+	return true
+}
+
+// DescribeFilters lists the active filters for the response summary
+func (o FetchOptions) DescribeFilters() []string {
+	var filters []string
+
+	if len(o.Authors) > 0 {
+		filters = append(filters, fmt.Sprintf("author=%s", strings.Join(o.Authors, ",")))
+	}
+	if len(o.ExcludeAuthors) > 0 {
+		filters = append(filters, fmt.Sprintf("exclude-author=%s", strings.Join(o.ExcludeAuthors, ",")))
+	}
+	if o.ExcludeBots {
+		filters = append(filters, "exclude-bots")
+	}
+	if len(o.ThreadStartedBy) > 0 {
+		filters = append(filters, fmt.Sprintf("thread-started-by=%s", strings.Join(o.ThreadStartedBy, ",")))
+	}
+	if len(o.Paths) > 0 {
+		filters = append(filters, fmt.Sprintf("path=%s", strings.Join(o.Paths, ",")))
+	}
+	if len(o.ExcludePaths) > 0 {
+		filters = append(filters, fmt.Sprintf("exclude-path=%s", strings.Join(o.ExcludePaths, ",")))
+	}
+	if len(o.CodeownersTeams) > 0 {
+		filters = append(filters, fmt.Sprintf("codeowners-team=%s", strings.Join(o.CodeownersTeams, ",")))
+	}
+	if o.SinceCommit != "" {
+		filters = append(filters, fmt.Sprintf("since-commit=%s", o.SinceCommit))
+	}
+	if !o.Since.IsZero() {
+		filters = append(filters, fmt.Sprintf("since=%s", o.Since.Format(time.RFC3339)))
+	}
+	if !o.Until.IsZero() {
+		filters = append(filters, fmt.Sprintf("until=%s", o.Until.Format(time.RFC3339)))
+	}
+	if o.NeedsResponseFrom != "" {
+		filters = append(filters, fmt.Sprintf("needs-response-from=%s", o.NeedsResponseFrom))
+	}
+	if o.OnlyInvolved {
+		filters = append(filters, "only-involved")
+	}
+	if o.Grep != nil {
+		filters = append(filters, fmt.Sprintf("grep=%s", o.Grep.String()))
+	}
+	if len(o.Categories) > 0 {
+		filters = append(filters, fmt.Sprintf("category=%s", strings.Join(o.Categories, ",")))
+	}
+	if o.hasTimeFilters() || o.SinceCommit != "" {
+		timeField := o.TimeField
+		if timeField == "" {
+			timeField = timeFieldCreated
+		}
+		filters = append(filters, fmt.Sprintf("time-field=%s", timeField))
+	}
+
+	return filters
+}
+
+// pathPattern is a compiled gitignore-style path pattern, as used by
+// CODEOWNERS and the --path/--exclude-path flags
+type pathPattern struct {
+	segments []string
+	// matchesDirectory allows the pattern to match a directory and so
+	// everything below it, e.g. "docs/" or "services/billing"
+	matchesDirectory bool
+}
+
+// compilePathPattern converts a gitignore-style pattern into path segments.
+// Patterns without a slash (other than a trailing one) match at any depth,
+// a leading slash anchors the pattern to the repository root,
+// "*" matches within a single path segment and "**" across segments.
+func compilePathPattern(pattern string) pathPattern {
+	pattern = strings.TrimSpace(pattern)
+	anchored := strings.HasPrefix(pattern, "/")
+	directory := strings.HasSuffix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+
+	if !anchored && !strings.Contains(pattern, "/") && !strings.HasPrefix(pattern, "**") {
+		pattern = "**/" + pattern
+	}
+
+	segments := strings.Split(pattern, "/")
+	last := segments[len(segments)-1]
+
+	return pathPattern{
+		segments:         segments,
+		matchesDirectory: directory || !strings.Contains(last, "*"),
+	}
+}
+
+// Match reports whether a repository-relative file path matches the pattern
+func (p pathPattern) Match(filePath string) bool {
+	parts := strings.Split(strings.Trim(filePath, "/"), "/")
+	return matchSegments(p.segments, parts, p.matchesDirectory)
+}
+
+// matchSegments matches pattern segments against path segments. With
+// allowPrefix, the pattern may match a leading directory of the path.
+func matchSegments(pattern, parts []string, allowPrefix bool) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0 || allowPrefix
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:], allowPrefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+
+	matched, err := path.Match(pattern[0], parts[0])
+	if err != nil || !matched {
+		return false
+	}
+
+	return matchSegments(pattern[1:], parts[1:], allowPrefix)
+}
+
+// matchesAnyPath reports whether a file path matches any of the patterns
+func matchesAnyPath(filePath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if compilePathPattern(pattern).Match(filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepPath applies the path and CODEOWNERS filters to a thread's file
+func (o FetchOptions) keepPath(filePath string) bool {
+	if len(o.Paths) > 0 && !matchesAnyPath(filePath, o.Paths) {
+		return false
+	}
+	if len(o.ExcludePaths) > 0 && matchesAnyPath(filePath, o.ExcludePaths) {
+		return false
+	}
+	if len(o.CodeownersTeams) > 0 {
+		if o.codeowners == nil || !o.codeowners.OwnedByAny(filePath, o.CodeownersTeams) {
+			return false
+		}
+	}
+	return true
+}
+
+// viewerAlias refers to the authenticated user in login filters
+const viewerAlias = "@me"
+
+// referencesViewer reports whether any login filter uses @me
+func (o FetchOptions) referencesViewer() bool {
+	if strings.EqualFold(o.NeedsResponseFrom, viewerAlias) {
+		return true
+	}
+	for _, logins := range [][]string{o.Authors, o.ExcludeAuthors, o.ThreadStartedBy} {
+		for _, login := range logins {
+			if strings.EqualFold(login, viewerAlias) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withViewer returns a copy of the options with @me replaced by the viewer's login
+func (o FetchOptions) withViewer(viewer string) FetchOptions {
+	replace := func(logins []string) []string {
+		if logins == nil {
+			return nil
+		}
+		resolved := make([]string, len(logins))
+		for i, login := range logins {
+			if strings.EqualFold(login, viewerAlias) {
+				login = viewer
+			}
+			resolved[i] = login
+		}
+		return resolved
+	}
+
+	o.Authors = replace(o.Authors)
+	o.ExcludeAuthors = replace(o.ExcludeAuthors)
+	o.ThreadStartedBy = replace(o.ThreadStartedBy)
+	if strings.EqualFold(o.NeedsResponseFrom, viewerAlias) {
+		o.NeedsResponseFrom = viewer
+	}
+
+	return o
+}
+
+// mentions reports whether a comment body @mentions a login. The mention
+// must stand alone: not inside an e-mail address or a longer login.
+func mentions(body, login string) bool {
+	pattern := `(?i)(^|[^\w-])@` + regexp.QuoteMeta(normalizeLogin(login)) + `($|[^\w-])`
+	matched, _ := regexp.MatchString(pattern, body)
+	return matched
+}
+
+// needsResponse applies the turn-taking filter: the thread must be unresolved
+// and its last comment must be by someone other than NeedsResponseFrom
+func (o FetchOptions) needsResponse(thread ReviewThread, prAuthor string) bool {
+	if o.NeedsResponseFrom == "" {
+		return true
+	}
+	if thread.IsResolved || len(thread.Comments) == 0 {
+		return false
+	}
+
+	user := o.NeedsResponseFrom
+	last := thread.Comments[len(thread.Comments)-1]
+	if loginIn(last.Author, []string{user}) {
+		return false
+	}
+
+	if !o.OnlyInvolved || loginIn(prAuthor, []string{user}) {
+		return true
+	}
+	for _, comment := range thread.Comments {
+		if loginIn(comment.Author, []string{user}) || mentions(comment.Body, user) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterGeneralNeedsResponse keeps the general comments made by others after
+// NeedsResponseFrom last took part in the PR conversation
+func (o FetchOptions) filterGeneralNeedsResponse(comments []GeneralComment, prAuthor string) []GeneralComment {
+	if o.NeedsResponseFrom == "" {
+		return comments
+	}
+
+	user := o.NeedsResponseFrom
+	lastOwn := -1
+	for i, comment := range comments {
+		if loginIn(comment.Author, []string{user}) {
+			lastOwn = i
+		}
+	}
+
+	involved := lastOwn >= 0 || loginIn(prAuthor, []string{user})
+	filtered := make([]GeneralComment, 0, len(comments))
+	for _, comment := range comments[lastOwn+1:] {
+		if o.OnlyInvolved && !involved && !mentions(comment.Body, user) {
+			continue
+		}
+		filtered = append(filtered, comment)
+	}
+	return filtered
+}
+
+// CompileGrepPattern builds the --grep regular expression, optionally
+// treating the pattern as a fixed string and matching case-insensitively
+func CompileGrepPattern(pattern string, fixed, ignoreCase bool) (*regexp.Regexp, error) {
+	if fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --grep pattern: %w", err)
+	}
+	return re, nil
+}
+
+// keepThreadGrep keeps threads with a comment (or, with GrepDiffHunks, a
+// diff hunk) matching the --grep pattern
+func (o FetchOptions) keepThreadGrep(thread ReviewThread) bool {
+	if o.Grep == nil {
+		return true
+	}
+	if o.GrepDiffHunks && o.Grep.MatchString(thread.DiffHunk) {
+		return true
+	}
+	for _, comment := range thread.Comments {
+		if o.Grep.MatchString(comment.Body) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepGeneralCommentGrep keeps general comments matching the --grep pattern
+func (o FetchOptions) keepGeneralCommentGrep(comment GeneralComment) bool {
+	return o.Grep == nil || o.Grep.MatchString(comment.Body)
+}
+
+// classifier returns the configured classifier or the built-in one
+func (o FetchOptions) classifier() *Classifier {
+	if o.Classifier != nil {
+		return o.Classifier
+	}
+	return defaultClassifier
+}
+
+// keepCategory applies the --category filter
+func (o FetchOptions) keepCategory(category string) bool {
+	if len(o.Categories) == 0 {
+		return true
+	}
+	for _, wanted := range o.Categories {
+		if strings.EqualFold(wanted, category) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestIsBotAuthor(t *testing.T) {
+	tests := []struct {
+		login     string
+		actorType string
+		want      bool
+	}{
+		{"dependabot", "Bot", true},
+		{"renovate[bot]", "User", true},
+		{"Codecov[BOT]", "", true},
+		{"robot", "User", false},
+		{"alice", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := isBotAuthor(tt.login, tt.actorType); got != tt.want {
+			t.Errorf("isBotAuthor(%q, %q) = %v, want %v", tt.login, tt.actorType, got, tt.want)
+		}
+	}
+}
+
+func TestLoginIn(t *testing.T) {
+	tests := []struct {
+		login  string
+		logins []string
+		want   bool
+	}{
+		{"Dependabot[bot]", []string{"@dependabot"}, true},
+		{"alice", []string{"bob", " Alice "}, true},
+		{"alice", []string{"alice2"}, false},
+		{"alice", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := loginIn(tt.login, tt.logins); got != tt.want {
+			t.Errorf("loginIn(%q, %q) = %v, want %v", tt.login, tt.logins, got, tt.want)
+		}
+	}
+}
+
+func TestFilterThreadAuthors(t *testing.T) {
+	thread := func(authors ...string) ReviewThread {
+		var comments []ThreadComment
+		for _, author := range authors {
+			comments = append(comments, ThreadComment{
+				Author: author,
+				IsBot:  strings.HasSuffix(author, "[bot]"),
+			})
+		}
+		return ReviewThread{Comments: comments}
+	}
+
+	tests := []struct {
+		name        string
+		opts        FetchOptions
+		thread      ReviewThread
+		wantKept    bool
+		wantAuthors string
+	}{
+		{
+			name:        "no filters",
+			thread:      thread("alice", "bob"),
+			wantKept:    true,
+			wantAuthors: "alice,bob",
+		},
+		{
+			name:        "author took part",
+			opts:        FetchOptions{Authors: []string{"@Bob"}},
+			thread:      thread("alice", "bob"),
+			wantKept:    true,
+			wantAuthors: "alice,bob",
+		},
+		{
+			name:     "author didn't take part",
+			opts:     FetchOptions{Authors: []string{"carol"}},
+			thread:   thread("alice", "bob"),
+			wantKept: false,
+		},
+		{
+			name:        "excluded reply is removed",
+			opts:        FetchOptions{ExcludeAuthors: []string{"bob"}},
+			thread:      thread("alice", "bob", "alice"),
+			wantKept:    true,
+			wantAuthors: "alice,alice",
+		},
+		{
+			name:     "excluded starter drops the thread",
+			opts:     FetchOptions{ExcludeAuthors: []string{"alice"}},
+			thread:   thread("alice", "bob"),
+			wantKept: false,
+		},
+		{
+			name:     "bot starter with exclude-bots",
+			opts:     FetchOptions{ExcludeBots: true},
+			thread:   thread("renovate[bot]", "alice"),
+			wantKept: false,
+		},
+		{
+			name:        "bot reply with exclude-bots",
+			opts:        FetchOptions{ExcludeBots: true},
+			thread:      thread("alice", "renovate[bot]"),
+			wantKept:    true,
+			wantAuthors: "alice",
+		},
+		{
+			name:     "started by someone else",
+			opts:     FetchOptions{ThreadStartedBy: []string{"bob"}},
+			thread:   thread("alice", "bob"),
+			wantKept: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thread := tt.thread
+			kept := tt.opts.filterThreadAuthors(&thread)
+			if kept != tt.wantKept {
+				t.Fatalf("kept = %v, want %v", kept, tt.wantKept)
+			}
+			if !kept {
+				return
+			}
+			var authors []string
+			for _, comment := range thread.Comments {
+				authors = append(authors, comment.Author)
+			}
+			if got := strings.Join(authors, ","); got != tt.wantAuthors {
+				t.Errorf("authors = %q, want %q", got, tt.wantAuthors)
+			}
+		})
+	}
+}
+
+func TestKeepGeneralCommentAuthor(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    FetchOptions
+		comment GeneralComment
+		want    bool
+	}{
+		{name: "no filters", comment: GeneralComment{Author: "alice"}, want: true},
+		{name: "author", opts: FetchOptions{Authors: []string{"alice"}}, comment: GeneralComment{Author: "Alice"}, want: true},
+		{name: "other author", opts: FetchOptions{Authors: []string{"alice"}}, comment: GeneralComment{Author: "bob"}, want: false},
+		{name: "excluded", opts: FetchOptions{ExcludeAuthors: []string{"bob"}}, comment: GeneralComment{Author: "bob"}, want: false},
+		{name: "bot", opts: FetchOptions{ExcludeBots: true}, comment: GeneralComment{Author: "codecov", IsBot: true}, want: false},
+		{name: "started by", opts: FetchOptions{ThreadStartedBy: []string{"bob"}}, comment: GeneralComment{Author: "alice"}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.opts.keepGeneralCommentAuthor(tt.comment); got != tt.want {
+			t.Errorf("%s: kept = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPathPatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/tool/main.go", true},
+		{"*.go", "main.gox", false},
+		{"/main.go", "main.go", true},
+		{"/main.go", "cmd/main.go", false},
+		{"docs/", "docs/guide/index.md", true},
+		{"docs/", "src/docs.go", false},
+		{"services/billing", "services/billing/api.go", true},
+		{"services/*.go", "services/api.go", true},
+		{"services/*.go", "services/billing/api.go", false},
+		{"services/**/*.go", "services/billing/v2/api.go", true},
+		{"**/testdata/**", "pkg/parser/testdata/input.txt", true},
+		{"vendor/**", "vendor/github.com/x/y.go", true},
+		{"vendor/**", "internal/vendor.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := compilePathPattern(tt.pattern).Match(tt.path); got != tt.want {
+			t.Errorf("pattern %q on %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestKeepPath(t *testing.T) {
+	opts := FetchOptions{
+		Paths:        []string{"src/", "*.md"},
+		ExcludePaths: []string{"**/*_test.go", "src/generated/"},
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"src/main.go", true},
+		{"README.md", true},
+		{"src/main_test.go", false},
+		{"src/generated/api.go", false},
+		{"scripts/build.sh", false},
+	}
+
+	for _, tt := range tests {
+		if got := opts.keepPath(tt.path); got != tt.want {
+			t.Errorf("keepPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		body  string
+		login string
+		want  bool
+	}{
+		{"@alice can you look?", "alice", true},
+		{"cc @Alice", "alice", true},
+		{"ping (@alice)", "alice", true},
+		{"@alice, thoughts?", "@alice", true},
+		{"mail alice@example.com", "alice", false},
+		{"mail bob@alice.example.com", "alice", false},
+		{"@alice-bot ran the linter", "alice", false},
+		{"@alice_smith is out", "alice", false},
+		{"@alicejones", "alice", false},
+		{"no mention of alice", "alice", false},
+		{"thanks @dependabot", "dependabot[bot]", true},
+	}
+
+	for _, tt := range tests {
+		if got := mentions(tt.body, tt.login); got != tt.want {
+			t.Errorf("mentions(%q, %q) = %v, want %v", tt.body, tt.login, got, tt.want)
+		}
+	}
+}
+
+func TestNeedsResponse(t *testing.T) {
+	comment := func(author, body string) ThreadComment {
+		return ThreadComment{Author: author, Body: body}
+	}
+	me := FetchOptions{NeedsResponseFrom: "alice"}
+	involved := FetchOptions{NeedsResponseFrom: "alice", OnlyInvolved: true}
+
+	tests := []struct {
+		name     string
+		opts     FetchOptions
+		thread   ReviewThread
+		prAuthor string
+		want     bool
+	}{
+		{name: "no filter", thread: ReviewThread{IsResolved: true}, want: true},
+		{name: "reviewer spoke last", opts: me, thread: ReviewThread{Comments: []ThreadComment{comment("bob", "why?")}}, want: true},
+		{name: "user replied last", opts: me, thread: ReviewThread{Comments: []ThreadComment{comment("bob", "why?"), comment("Alice", "because")}}, want: false},
+		{name: "resolved", opts: me, thread: ReviewThread{IsResolved: true, Comments: []ThreadComment{comment("bob", "why?")}}, want: false},
+		{name: "involved as PR author", opts: involved, prAuthor: "alice", thread: ReviewThread{Comments: []ThreadComment{comment("bob", "why?")}}, want: true},
+		{name: "involved by taking part", opts: involved, thread: ReviewThread{Comments: []ThreadComment{comment("alice", "fixed"), comment("bob", "not quite")}}, want: true},
+		{name: "involved by mention", opts: involved, thread: ReviewThread{Comments: []ThreadComment{comment("bob", "@alice wdyt?")}}, want: true},
+		{name: "not involved", opts: involved, prAuthor: "carol", thread: ReviewThread{Comments: []ThreadComment{comment("bob", "alice@example.com?")}}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.opts.needsResponse(tt.thread, tt.prAuthor); got != tt.want {
+			t.Errorf("%s: needsResponse = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterGeneralNeedsResponse(t *testing.T) {
+	comments := []GeneralComment{
+		{ID: "1", Author: "bob", Body: "first look"},
+		{ID: "2", Author: "alice", Body: "thanks"},
+		{ID: "3", Author: "carol", Body: "one more thing"},
+		{ID: "4", Author: "dave", Body: "@alice see above"},
+	}
+
+	tests := []struct {
+		name     string
+		opts     FetchOptions
+		comments []GeneralComment
+		prAuthor string
+		want     string
+	}{
+		{name: "no filter", comments: comments, want: "1,2,3,4"},
+		{name: "after the user's last comment", opts: FetchOptions{NeedsResponseFrom: "alice"}, comments: comments, want: "3,4"},
+		{name: "user never commented", opts: FetchOptions{NeedsResponseFrom: "erin"}, comments: comments, want: "1,2,3,4"},
+		{name: "only involved: mentioned", opts: FetchOptions{NeedsResponseFrom: "alice", OnlyInvolved: true}, comments: []GeneralComment{comments[0], comments[3]}, want: "4"},
+		{name: "only involved: PR author", opts: FetchOptions{NeedsResponseFrom: "alice", OnlyInvolved: true}, comments: []GeneralComment{comments[0], comments[3]}, prAuthor: "alice", want: "1,4"},
+	}
+
+	for _, tt := range tests {
+		var ids []string
+		for _, comment := range tt.opts.filterGeneralNeedsResponse(tt.comments, tt.prAuthor) {
+			ids = append(ids, comment.ID)
+		}
+		if got := strings.Join(ids, ","); got != tt.want {
+			t.Errorf("%s: kept %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWithViewer(t *testing.T) {
+	opts := FetchOptions{
+		Authors:           []string{"@me", "bob"},
+		ThreadStartedBy:   []string{"@ME"},
+		NeedsResponseFrom: "@me",
+	}
+	if !opts.referencesViewer() {
+		t.Fatal("referencesViewer = false, want true")
+	}
+
+	resolved := opts.withViewer("alice")
+	if strings.Join(resolved.Authors, ",") != "alice,bob" || resolved.ThreadStartedBy[0] != "alice" || resolved.NeedsResponseFrom != "alice" || resolved.ExcludeAuthors != nil {
+		t.Errorf("resolved = %+v", resolved)
+	}
+	if opts.Authors[0] != "@me" {
+		t.Error("withViewer changed the original options")
+	}
+	if resolved.referencesViewer() {
+		t.Error("resolved options still reference @me")
+	}
+}
+
+func TestFetchNeedsResponseFromMe(t *testing.T) {
+	_, host := newFakeGitHub(t, nil)
+	client := NewGitHubGraphQLClientFromTokenSource(context.Background(), tokenSource("test"), host)
+
+	// octocat, the viewer, spoke last in the first thread; lint-bot in the third
+	opts := FetchOptions{IncludeResolved: true, IncludeOutdated: true, IncludeGeneral: true, NeedsResponseFrom: "@me"}
+	response, err := fetchGraphQLResponse(context.Background(), client, PRRef{Owner: "octo-org", Repo: "widgets", Number: 1}, opts)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(response.ReviewThreads) != 1 || response.ReviewThreads[0].File != "docs/cache.md" {
+		t.Errorf("threads = %+v, want only the one on docs/cache.md", response.ReviewThreads)
+	}
+	if len(response.GeneralComments) != 1 || response.GeneralComments[0].Author != "hubot" {
+		t.Errorf("general comments = %+v, want hubot's reply", response.GeneralComments)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+// stringListFlag collects values from repeated or comma-separated flags
+type stringListFlag []string
+
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringListFlag) Set(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			*f = append(*f, part)
+		}
+	}
+	return nil
+}
+
+// graphQLOnlyFlags are the fetch options the legacy REST path can't honour
+var graphQLOnlyFlags = []string{
+	"author", "exclude-author", "exclude-bots", "thread-started-by",
+	"path", "exclude-path", "codeowners-team",
+	"since", "until", "time-field", "since-commit",
+	"needs-response-from", "only-involved",
+	"grep", "grep-fixed", "i", "grep-diff",
+	"category", "category-rules",
+	"sort", "reviewer-weight", "layout", "columns", "template",
+	"out-todo", "require-open", "fail-on", "max-unresolved",
+}
+
+// givenFlags returns the names of the flags set on the command line
+func givenFlags(flags *flag.FlagSet) map[string]bool {
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	return given
+}
+
+// checkRESTFlags returns a usage error naming the GraphQL-only options among
+// the given flags, which the REST path would otherwise silently ignore
+func checkRESTFlags(given map[string]bool) error {
+	var names []string
+	for _, name := range graphQLOnlyFlags {
+		if given[name] {
+			names = append(names, "--"+name)
+		}
+	}
+
+	switch len(names) {
+	case 0:
+		return nil
+	case 1:
+		return newError(KindUsage, "%s requires the GraphQL API", names[0])
+	default:
+		return newError(KindUsage, "%s require the GraphQL API", strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestCheckRESTFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--format", "json", "--include-resolved"}},
+		{args: []string{"--exclude-bots"}, wantErr: "--exclude-bots requires the GraphQL API"},
+		{args: []string{"--sort", "age", "--author", "alice", "--since", "2d"}, wantErr: "--author, --since, --sort require the GraphQL API"},
+		{args: []string{"--grep", "todo", "-i"}, wantErr: "--grep, --i require the GraphQL API"},
+		{args: []string{"--fail-on", "blocking"}, wantErr: "--fail-on requires the GraphQL API"},
+	}
+
+	for _, tt := range tests {
+		flags := flag.NewFlagSet("fetch", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		flags.String("format", "claude", "")
+		flags.Bool("include-resolved", false, "")
+		for _, name := range graphQLOnlyFlags {
+			if name == "exclude-bots" || name == "i" {
+				flags.Bool(name, false, "")
+			} else {
+				flags.String(name, "", "")
+			}
+		}
+		if err := flags.Parse(tt.args); err != nil {
+			t.Fatalf("%q: %v", tt.args, err)
+		}
+
+		err := checkRESTFlags(givenFlags(flags))
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%q: %v", tt.args, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.wantErr || ExitCode(err) != ExitUsage {
+			t.Errorf("%q: err = %v, want %q with exit code %d", tt.args, err, tt.wantErr, ExitUsage)
+		}
+	}
+}
+
+func TestStringListFlag(t *testing.T) {
+	var list stringListFlag
+	for _, value := range []string{"alice, bob", "", "carol,,"} {
+		if err := list.Set(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := list.String(); got != "alice,bob,carol" {
+		t.Errorf("list = %q, want alice,bob,carol", got)
+	}
+}
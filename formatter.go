@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"regexp"
 	"sort"
 	"strings"
+	"text/tabwriter"
+	"text/template"
 )
 
 // Human format icons - easily changeable text/unicode markers
@@ -29,6 +32,9 @@ const (
 	iconReply      = "  |->"
 	iconThread     = "[Thread]"
 	iconGeneral    = "[General]"
+	iconFilter     = "[Filter]"
+	iconMatchStart = ">>"
+	iconMatchEnd   = "<<"
 )
 
 // FormatComments formats comments for human-readable output
@@ -219,23 +225,63 @@ func GenerateSummary(comments []ParsedComment, owner, repo string, prNumber int)
 // V2 Formatters for Thread-based GraphQL responses
 // ============================================================================
 
+// FormatOptions controls optional presentation features of the V2 formatters
+type FormatOptions struct {
+	// Highlight marks matches of this pattern in comment bodies (human format)
+	Highlight *regexp.Regexp
+	// Sort orders review threads: file (default), priority, age, activity or reviewer
+	Sort string
+	// ReviewerWeights ranks threads started by these reviewers higher when sorting by priority
+	ReviewerWeights map[string]int
+	// Layout is files (default) for per-file sections or ranked for a single task list (claude format)
+	Layout string
+	// Columns selects and orders the columns of the csv and tsv formats
+	Columns []string
+	// JSONVersion is the shape of the json format; zero means DefaultJSONVersion
+	JSONVersion int
+	// Template renders the template format
+	Template *template.Template
+}
+
 // FormatCommentsV2 formats thread-based comments from GraphQL API
-func FormatCommentsV2(response *PRCommentsResponse, format string) (string, error) {
+func FormatCommentsV2(response *PRCommentsResponse, format string, opts FormatOptions) (string, error) {
 	switch format {
 	case "json":
-		return formatJSONV2(response)
+		return formatJSONV2(response, opts)
 	case "human":
-		return formatHumanV2(response)
+		return formatHumanV2(response, opts)
 	case "claude":
-		return formatClaudeV2(response)
+		return formatClaudeV2(response, opts)
+	case "checklist":
+		return formatChecklistV2(response, opts)
+	case "html":
+		return formatHTMLV2(response, opts)
+	case "csv", "tsv":
+		return formatTableV2(response, format, opts)
+	case "jsonl":
+		return formatJSONLV2(response)
+	case "template":
+		return formatTemplateV2(response, opts)
 	default:
 		return "", fmt.Errorf("unsupported format: %s", format)
 	}
 }
 
 // formatJSONV2 outputs thread-based response as JSON
-func formatJSONV2(response *PRCommentsResponse) (string, error) {
-	data, err := json.MarshalIndent(response, "", "  ")
+func formatJSONV2(response *PRCommentsResponse, opts FormatOptions) (string, error) {
+	// Threads keep their fetch order unless a sort was asked for
+	if opts.Sort != "" {
+		sorted := *response
+		sorted.ReviewThreads = SortThreads(response.ReviewThreads, opts.Sort, opts.ReviewerWeights)
+		response = &sorted
+	}
+
+	return formatJSONVersion(response, opts.JSONVersion)
+}
+
+// formatJSONVersion outputs a response as indented JSON in the shape of a JSON output version
+func formatJSONVersion(response *PRCommentsResponse, version int) (string, error) {
+	data, err := json.MarshalIndent(VersionedResponse(response, version), "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("marshaling JSON: %w", err)
 	}
@@ -243,7 +289,7 @@ func formatJSONV2(response *PRCommentsResponse) (string, error) {
 }
 
 // formatHumanV2 outputs human-readable thread format
-func formatHumanV2(response *PRCommentsResponse) (string, error) {
+func formatHumanV2(response *PRCommentsResponse, opts FormatOptions) (string, error) {
 	var output strings.Builder
 
 	output.WriteString(fmt.Sprintf("%s PR #%d Review Comments (%s/%s)\n",
@@ -264,6 +310,16 @@ func formatHumanV2(response *PRCommentsResponse) (string, error) {
 			iconGeneral, response.Summary.GeneralComments))
 	}
 
+	if len(response.Summary.Categories) > 0 {
+		output.WriteString(fmt.Sprintf("%s By category: %s\n",
+			iconStats, formatCategoryCounts(response.Summary.Categories)))
+	}
+
+	if len(response.Summary.FiltersApplied) > 0 {
+		output.WriteString(fmt.Sprintf("%s Filters: %s\n",
+			iconFilter, strings.Join(response.Summary.FiltersApplied, ", ")))
+	}
+
 	output.WriteString("═══════════════════════════════════════════════════════════════\n\n")
 
 	// Display general comments first (if any)
@@ -271,14 +327,14 @@ func formatHumanV2(response *PRCommentsResponse) (string, error) {
 		output.WriteString("## General PR Comments\n\n")
 		for i, comment := range response.GeneralComments {
 			output.WriteString(fmt.Sprintf("%s %d\n", iconComment, i+1))
-			output.WriteString(fmt.Sprintf("%s %s: %s\n", iconAuthor, comment.Author, comment.Body))
+			output.WriteString(fmt.Sprintf("%s %s: %s\n", iconAuthor, comment.Author, highlightMatches(comment.Body, opts.Highlight)))
 			output.WriteString(fmt.Sprintf("%s %s\n", iconLink, comment.HTMLURL))
 			output.WriteString("───────────────────────────────────────────────────────────────\n\n")
 		}
 	}
 
-	// Display review threads
-	sortedThreads := sortReviewThreads(response.ReviewThreads)
+	// Display review threads in the requested order, by file and line by default
+	sortedThreads := SortThreads(response.ReviewThreads, opts.Sort, opts.ReviewerWeights)
 	if len(sortedThreads) > 0 {
 		output.WriteString("## Review Threads\n\n")
 
@@ -291,6 +347,10 @@ func formatHumanV2(response *PRCommentsResponse) (string, error) {
 				statusText = "[RESOLVED]"
 			}
 
+			if thread.Category != "" {
+				statusText += fmt.Sprintf(" [%s]", strings.ToUpper(thread.Category))
+			}
+
 			output.WriteString(fmt.Sprintf("%s %s %d of %d %s\n",
 				statusIcon, iconThread, i+1, len(response.ReviewThreads), statusText))
 
@@ -313,7 +373,7 @@ func formatHumanV2(response *PRCommentsResponse) (string, error) {
 					indent = iconReply + " "
 				}
 				output.WriteString(fmt.Sprintf("%s%s %s: %s\n",
-					indent, iconAuthor, comment.Author, comment.Body))
+					indent, iconAuthor, comment.Author, highlightMatches(comment.Body, opts.Highlight)))
 
 				if j == 0 {
 					output.WriteString(fmt.Sprintf("  %s %s\n", iconLink, comment.HTMLURL))
@@ -332,7 +392,7 @@ func formatHumanV2(response *PRCommentsResponse) (string, error) {
 }
 
 // formatClaudeV2 outputs Claude-optimized thread format
-func formatClaudeV2(response *PRCommentsResponse) (string, error) {
+func formatClaudeV2(response *PRCommentsResponse, opts FormatOptions) (string, error) {
 	var output strings.Builder
 
 	output.WriteString(fmt.Sprintf("# PR #%d Review Comments Analysis\n\n", response.PRNumber))
@@ -351,6 +411,14 @@ func formatClaudeV2(response *PRCommentsResponse) (string, error) {
 		output.WriteString(fmt.Sprintf("- **General Comments:** %d\n", response.Summary.GeneralComments))
 	}
 
+	if len(response.Summary.Categories) > 0 {
+		output.WriteString(fmt.Sprintf("- **By Category:** %s\n", formatCategoryCounts(response.Summary.Categories)))
+	}
+
+	if len(response.Summary.FiltersApplied) > 0 {
+		output.WriteString(fmt.Sprintf("- **Filters Applied:** %s\n", strings.Join(response.Summary.FiltersApplied, ", ")))
+	}
+
 	output.WriteString(fmt.Sprintf("- **Files Affected:** %s\n\n", strings.Join(response.Summary.FilesAffected, ", ")))
 
 	// Show general comments first
@@ -372,75 +440,81 @@ func formatClaudeV2(response *PRCommentsResponse) (string, error) {
 		return output.String(), nil
 	}
 
-	output.WriteString("## Review Threads to Address\n\n")
-
-	// Group threads by file
-	fileThreads := make(map[string][]ReviewThread)
+	// Non-blocking feedback is listed separately from the action items
+	var actionable, nonBlocking []ReviewThread
 	for _, thread := range response.ReviewThreads {
-		fileThreads[thread.File] = append(fileThreads[thread.File], thread)
+		if !thread.IsResolved && !isBlockingThread(thread) {
+			nonBlocking = append(nonBlocking, thread)
+		} else {
+			actionable = append(actionable, thread)
+		}
 	}
 
-	files := make([]string, 0, len(fileThreads))
-	for file := range fileThreads {
-		files = append(files, file)
-	}
-	sort.Strings(files)
+	if len(actionable) > 0 && opts.Layout == LayoutRanked {
+		output.WriteString("## Ranked Task List\n\n")
+		output.WriteString("Work through these threads in order; the most important feedback comes first.\n\n")
 
-	for _, file := range files {
-		threads := sortReviewThreads(fileThreads[file])
-		output.WriteString(fmt.Sprintf("### 📁 `%s`\n\n", file))
-
-		for _, thread := range threads {
-			// Thread status header
-			if thread.IsResolved {
-				output.WriteString("#### [RESOLVED] ✓ ")
-			} else {
-				output.WriteString("#### [UNRESOLVED] ")
+		for i, thread := range SortThreads(actionable, opts.Sort, opts.ReviewerWeights) {
+			output.WriteString(fmt.Sprintf("### %d. 📁 `%s`\n\n", i+1, thread.File))
+			writeClaudeThread(&output, thread)
+		}
+	} else if len(actionable) > 0 {
+		output.WriteString("## Review Threads to Address\n\n")
+
+		// Group threads by file, keeping the files in the order of their first thread
+		sortedThreads := SortThreads(actionable, opts.Sort, opts.ReviewerWeights)
+		fileThreads := make(map[string][]ReviewThread)
+		var files []string
+		for _, thread := range sortedThreads {
+			if _, seen := fileThreads[thread.File]; !seen {
+				files = append(files, thread.File)
 			}
+			fileThreads[thread.File] = append(fileThreads[thread.File], thread)
+		}
+		if opts.Sort == "" || opts.Sort == SortFile {
+			sort.Strings(files)
+		}
 
-			// Line information
-			if thread.LineNew != nil {
-				output.WriteString(fmt.Sprintf("Line %d", *thread.LineNew))
-			} else {
-				output.WriteString("General file comment")
-			}
+		for _, file := range files {
+			output.WriteString(fmt.Sprintf("### 📁 `%s`\n\n", file))
 
-			if thread.IsOutdated {
-				output.WriteString(" ⚠️ **[OUTDATED]**")
+			for _, thread := range fileThreads[file] {
+				writeClaudeThread(&output, thread)
 			}
-			output.WriteString("\n\n")
-
-			writeDiffSnippet(&output, thread.DiffHunk)
+		}
+	}
 
-			// Thread conversation
-			output.WriteString("**Conversation:**\n\n")
-			for i, comment := range thread.Comments {
-				if comment.IsReply {
-					output.WriteString(fmt.Sprintf("↳ **%s** replied:\n", comment.Author))
-				} else {
-					output.WriteString(fmt.Sprintf("**%s** commented:\n", comment.Author))
-				}
-				output.WriteString(fmt.Sprintf("> %s\n\n", comment.Body))
+	if len(nonBlocking) > 0 {
+		output.WriteString("## Non-blocking Feedback\n\n")
+		output.WriteString("These comments don't block merging; address them at your discretion.\n\n")
 
-				// Only show link for first comment
-				if i == 0 {
-					output.WriteString(fmt.Sprintf("**Reference:** [View on GitHub](%s)\n\n", comment.HTMLURL))
-				}
+		for _, thread := range SortThreads(nonBlocking, opts.Sort, opts.ReviewerWeights) {
+			location := thread.File
+			if line := threadLineNumber(thread); line != math.MaxInt32 {
+				location = fmt.Sprintf("%s:%d", thread.File, line)
 			}
 
-			// Action item (only for unresolved threads)
-			if !thread.IsResolved {
-				output.WriteString("**Action Required:**\n")
-				lineInfo := "this section"
-				if thread.LineNew != nil {
-					lineInfo = fmt.Sprintf("line %d", *thread.LineNew)
+			label := thread.Category
+			summary := ""
+			author := ""
+			link := ""
+			if len(thread.Comments) > 0 {
+				summary = firstLine(thread.Comments[0].Body)
+				author = thread.Comments[0].Author
+				link = thread.Comments[0].HTMLURL
+			}
+			if thread.Conventional != nil {
+				label = thread.Conventional.Label
+				if len(thread.Conventional.Decorations) > 0 {
+					label += " (" + strings.Join(thread.Conventional.Decorations, ", ") + ")"
 				}
-				output.WriteString(fmt.Sprintf("- Address the feedback on %s\n", lineInfo))
-				output.WriteString("- Reply to the thread when changes are made\n\n")
+				summary = thread.Conventional.Subject
 			}
 
-			output.WriteString("---\n\n")
+			output.WriteString(fmt.Sprintf("- **%s** `%s` - %s (%s) [View on GitHub](%s)\n",
+				label, location, summary, author, link))
 		}
+		output.WriteString("\n")
 	}
 
 	// Next steps section
@@ -455,6 +529,86 @@ func formatClaudeV2(response *PRCommentsResponse) (string, error) {
 	return output.String(), nil
 }
 
+// writeClaudeThread renders a single review thread in Claude format
+func writeClaudeThread(builder *strings.Builder, thread ReviewThread) {
+	// Thread status header
+	if thread.IsResolved {
+		builder.WriteString("#### [RESOLVED] ✓ ")
+	} else {
+		builder.WriteString("#### [UNRESOLVED] ")
+	}
+
+	if thread.Category != "" {
+		builder.WriteString(fmt.Sprintf("[%s] ", strings.ToUpper(thread.Category)))
+	}
+
+	// Line information
+	if thread.LineNew != nil {
+		builder.WriteString(fmt.Sprintf("Line %d", *thread.LineNew))
+	} else {
+		builder.WriteString("General file comment")
+	}
+
+	if thread.IsOutdated {
+		builder.WriteString(" ⚠️ **[OUTDATED]**")
+	}
+	builder.WriteString("\n\n")
+
+	writeDiffSnippet(builder, thread.DiffHunk)
+
+	// Thread conversation
+	builder.WriteString("**Conversation:**\n\n")
+	for i, comment := range thread.Comments {
+		if comment.IsReply {
+			builder.WriteString(fmt.Sprintf("↳ **%s** replied:\n", comment.Author))
+		} else {
+			builder.WriteString(fmt.Sprintf("**%s** commented:\n", comment.Author))
+		}
+		builder.WriteString(fmt.Sprintf("> %s\n\n", comment.Body))
+
+		// Only show link for first comment
+		if i == 0 {
+			builder.WriteString(fmt.Sprintf("**Reference:** [View on GitHub](%s)\n\n", comment.HTMLURL))
+		}
+	}
+
+	// Action item (only for unresolved threads that ask for something)
+	if !thread.IsResolved && thread.Category != CategoryPraise {
+		builder.WriteString("**Action Required:**\n")
+		lineInfo := "this section"
+		if thread.LineNew != nil {
+			lineInfo = fmt.Sprintf("line %d", *thread.LineNew)
+		}
+		builder.WriteString(fmt.Sprintf("- %s on %s\n", categoryAction(thread.Category), lineInfo))
+		builder.WriteString("- Reply to the thread when changes are made\n\n")
+	}
+
+	builder.WriteString("---\n\n")
+}
+
+// firstLine returns the first non-empty line of a comment body
+func firstLine(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+// highlightMatches wraps every match of the pattern in match markers
+func highlightMatches(text string, pattern *regexp.Regexp) string {
+	if pattern == nil {
+		return text
+	}
+	return pattern.ReplaceAllStringFunc(text, func(match string) string {
+		if match == "" {
+			return match
+		}
+		return iconMatchStart + match + iconMatchEnd
+	})
+}
+
 // writeDiffSnippet renders a diff hunk with consistent formatting
 func writeDiffSnippet(builder *strings.Builder, diffHunk string) {
 	trimmed := strings.TrimSpace(diffHunk)
@@ -500,6 +654,13 @@ func GenerateThreadSummary(
 			summary.OutdatedThreads++
 		}
 
+		if thread.Category != "" {
+			if summary.Categories == nil {
+				summary.Categories = make(map[string]int)
+			}
+			summary.Categories[thread.Category]++
+		}
+
 		// Count all comments in thread
 		summary.TotalComments += len(thread.Comments)
 
@@ -563,6 +724,17 @@ func sortReviewThreads(threads []ReviewThread) []ReviewThread {
 	return sorted
 }
 
+// formatCategoryCounts renders per-category thread counts in category order
+func formatCategoryCounts(counts map[string]int) string {
+	parts := make([]string, 0, len(counts))
+	for _, category := range categoryOrder {
+		if counts[category] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", category, counts[category]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
 func sortParsedComments(comments []ParsedComment) []ParsedComment {
 	sorted := make([]ParsedComment, len(comments))
 	copy(sorted, comments)
@@ -612,3 +784,222 @@ func commentLineNumber(comment ParsedComment) int {
 	}
 	return math.MaxInt32
 }
+
+// ============================================================================
+// PR List Formatters for the multi-PR dashboard
+// ============================================================================
+
+// FormatPRList formats the output of the list command
+func FormatPRList(response *PRListResponse, format string) (string, error) {
+	switch format {
+	case "table":
+		return formatPRListTable(response)
+	case "json":
+		return formatPRListJSON(response)
+	case "claude":
+		return formatPRListClaude(response)
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatPRListJSON outputs the PR list as JSON
+func formatPRListJSON(response *PRListResponse) (string, error) {
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// formatPRListTable outputs an aligned terminal table, one PR per row
+func formatPRListTable(response *PRListResponse) (string, error) {
+	if len(response.PullRequests) == 0 {
+		return fmt.Sprintf("%s No open pull requests found\n", iconOK), nil
+	}
+
+	var output strings.Builder
+	writer := tabwriter.NewWriter(&output, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "SCORE\tPR\tUNRESOLVED\tRESOLVED\tOUTDATED\tDECISION\tLAST ACTIVITY\tTITLE")
+	for _, pr := range response.PullRequests {
+		title := pr.Title
+		if pr.IsDraft {
+			title = "[draft] " + title
+		}
+		fmt.Fprintf(writer, "%d\t%s/%s#%d\t%d\t%d\t%d\t%s\t%s\t%s\n",
+			pr.AttentionScore, pr.Owner, pr.Repo, pr.PRNumber,
+			pr.UnresolvedThreads, pr.ResolvedThreads, pr.OutdatedThreads,
+			formatReviewDecision(pr.ReviewDecision), pr.LastActivity, title)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return "", fmt.Errorf("writing table: %w", err)
+	}
+
+	return output.String(), nil
+}
+
+// formatPRListClaude outputs a Claude-optimized dashboard of PRs needing attention
+func formatPRListClaude(response *PRListResponse) (string, error) {
+	var output strings.Builder
+
+	output.WriteString("# Open Pull Requests Review Dashboard\n\n")
+	output.WriteString(fmt.Sprintf("**Query:** `%s`\n", response.Query))
+	output.WriteString(fmt.Sprintf("**Pull Requests:** %d\n\n", len(response.PullRequests)))
+
+	if len(response.PullRequests) == 0 {
+		output.WriteString("✅ **No open pull requests found**\n")
+		return output.String(), nil
+	}
+
+	output.WriteString("## Pull Requests by Attention Needed\n\n")
+	for i, pr := range response.PullRequests {
+		draft := ""
+		if pr.IsDraft {
+			draft = " (draft)"
+		}
+		output.WriteString(fmt.Sprintf("%d. **%s/%s#%d**%s - %s\n",
+			i+1, pr.Owner, pr.Repo, pr.PRNumber, draft, pr.Title))
+		output.WriteString(fmt.Sprintf("   - **Unresolved Threads:** %d (resolved: %d, outdated: %d)\n",
+			pr.UnresolvedThreads, pr.ResolvedThreads, pr.OutdatedThreads))
+		output.WriteString(fmt.Sprintf("   - **Review Decision:** %s\n", formatReviewDecision(pr.ReviewDecision)))
+		output.WriteString(fmt.Sprintf("   - **Last Activity:** %s\n", pr.LastActivity))
+		output.WriteString(fmt.Sprintf("   - **Reference:** [View on GitHub](%s)\n\n", pr.URL))
+	}
+
+	output.WriteString("## Next Steps\n\n")
+	output.WriteString("1. Start with the pull requests at the top of the list\n")
+	output.WriteString("2. Fetch each PR's unresolved threads with `pr-review-cli fetch --owner OWNER --repo REPO --pr NUMBER`\n")
+	output.WriteString("3. Address the feedback and notify reviewers\n")
+
+	return output.String(), nil
+}
+
+// formatReviewDecision converts a GraphQL review decision into display text
+func formatReviewDecision(decision string) string {
+	switch decision {
+	case "APPROVED":
+		return "approved"
+	case "CHANGES_REQUESTED":
+		return "changes requested"
+	case "REVIEW_REQUIRED":
+		return "review required"
+	default:
+		return "none"
+	}
+}
+
+// ============================================================================
+// Search Formatters for comments matching a pattern across PRs
+// ============================================================================
+
+// FormatSearchResults formats the output of the search command
+func FormatSearchResults(response *SearchResponse, format string, pattern *regexp.Regexp) (string, error) {
+	switch format {
+	case "json":
+		return formatSearchJSON(response)
+	case "human":
+		return formatSearchHuman(response, pattern)
+	case "claude":
+		return formatSearchClaude(response)
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// formatSearchJSON outputs search results as JSON
+func formatSearchJSON(response *SearchResponse) (string, error) {
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// formatSearchHuman outputs search results with matches highlighted
+func formatSearchHuman(response *SearchResponse, pattern *regexp.Regexp) (string, error) {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("%s %d matches for /%s/ in %d pull requests\n",
+		iconStats, len(response.Matches), response.Pattern, response.PullRequests))
+	output.WriteString("═══════════════════════════════════════════════════════════════\n\n")
+
+	if len(response.Matches) == 0 {
+		output.WriteString(fmt.Sprintf("%s No matching comments found\n", iconOK))
+		return output.String(), nil
+	}
+
+	for _, match := range response.Matches {
+		output.WriteString(fmt.Sprintf("%s %s/%s#%d %s\n",
+			iconPR, match.Owner, match.Repo, match.PRNumber, match.PRTitle))
+
+		if match.File != "" {
+			statusIcon := iconUnresolved
+			if match.IsResolved {
+				statusIcon = iconResolved
+			}
+			output.WriteString(fmt.Sprintf("%s %s %s", statusIcon, iconFile, match.File))
+			if match.Line != nil {
+				output.WriteString(fmt.Sprintf(":%d", *match.Line))
+			}
+			if match.InDiffHunk {
+				output.WriteString(" (match in diff)")
+			}
+			output.WriteString("\n")
+		} else {
+			output.WriteString(fmt.Sprintf("%s General comment\n", iconGeneral))
+		}
+
+		output.WriteString(fmt.Sprintf("%s %s: %s\n", iconAuthor, match.Author, highlightMatches(match.Body, pattern)))
+		output.WriteString(fmt.Sprintf("%s %s\n", iconLink, match.HTMLURL))
+		output.WriteString("───────────────────────────────────────────────────────────────\n\n")
+	}
+
+	return output.String(), nil
+}
+
+// formatSearchClaude outputs search results grouped by PR for Claude
+func formatSearchClaude(response *SearchResponse) (string, error) {
+	var output strings.Builder
+
+	output.WriteString("# Review Comment Search Results\n\n")
+	output.WriteString(fmt.Sprintf("**Query:** `%s`\n", response.Query))
+	output.WriteString(fmt.Sprintf("**Pattern:** `%s`\n", response.Pattern))
+	output.WriteString(fmt.Sprintf("**Matches:** %d in %d pull requests searched\n\n", len(response.Matches), response.PullRequests))
+
+	if len(response.Matches) == 0 {
+		output.WriteString("✅ **No matching comments found**\n")
+		return output.String(), nil
+	}
+
+	currentPR := ""
+	for _, match := range response.Matches {
+		pr := fmt.Sprintf("%s/%s#%d", match.Owner, match.Repo, match.PRNumber)
+		if pr != currentPR {
+			output.WriteString(fmt.Sprintf("## %s - %s\n\n", pr, match.PRTitle))
+			currentPR = pr
+		}
+
+		if match.File != "" {
+			location := match.File
+			if match.Line != nil {
+				location = fmt.Sprintf("%s:%d", match.File, *match.Line)
+			}
+			status := "UNRESOLVED"
+			if match.IsResolved {
+				status = "RESOLVED"
+			}
+			output.WriteString(fmt.Sprintf("### `%s` [%s]\n\n", location, status))
+		} else {
+			output.WriteString("### General PR comment\n\n")
+		}
+
+		output.WriteString(fmt.Sprintf("**%s** wrote:\n", match.Author))
+		output.WriteString(fmt.Sprintf("> %s\n\n", match.Body))
+		output.WriteString(fmt.Sprintf("**Reference:** [View on GitHub](%s)\n\n", match.HTMLURL))
+		output.WriteString("---\n\n")
+	}
+
+	return output.String(), nil
+}
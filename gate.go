@@ -0,0 +1,84 @@
+package main
+
+// Modes of --fail-on: what feedback left in the result fails a fetch
+const (
+	FailOnUnresolved = "unresolved" // unresolved review threads
+	FailOnBlocking   = "blocking"   // unresolved threads that block merging
+	FailOnAny        = "any"        // any thread or general comment
+)
+
+var validFailOnModes = []string{FailOnUnresolved, FailOnBlocking, FailOnAny}
+
+// isValidFailOn reports whether a --fail-on mode is supported
+func isValidFailOn(mode string) bool {
+	for _, valid := range validFailOnModes {
+		if mode == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// FeedbackGate fails a fetch whose filtered result still holds more feedback
+// than allowed, for gating merges in CI. It counts what the output shows, so
+// it agrees with the printed counts.
+type FeedbackGate struct {
+	Mode string // one of the FailOn modes; "" disables the gate
+	Max  int    // how much feedback is allowed before failing
+}
+
+// Enabled reports whether the gate checks anything
+func (g FeedbackGate) Enabled() bool {
+	return g.Mode != ""
+}
+
+// Count returns how much of the gated feedback a PR holds. Blocking threads
+// are those whose category is blocking, and count only while unresolved, as
+// in the Claude format's action items.
+func (g FeedbackGate) Count(response *PRCommentsResponse) int {
+	summary := response.Summary
+	switch g.Mode {
+	case FailOnUnresolved:
+		return summary.UnresolvedThreads
+	case FailOnBlocking:
+		count := 0
+		for _, thread := range response.ReviewThreads {
+			if !thread.IsResolved && thread.Category == CategoryBlocking {
+				count++
+			}
+		}
+		return count
+	case FailOnAny:
+		return summary.UnresolvedThreads + summary.ResolvedThreads + summary.GeneralComments
+	default:
+		return 0
+	}
+}
+
+// Check returns a KindUnresolved error if a PR has more feedback than allowed
+func (g FeedbackGate) Check(response *PRCommentsResponse) error {
+	if !g.Enabled() {
+		return nil
+	}
+
+	count := g.Count(response)
+	if count <= g.Max {
+		return nil
+	}
+
+	var noun string
+	switch g.Mode {
+	case FailOnUnresolved:
+		noun = pluralize(count, "unresolved thread", "unresolved threads")
+	case FailOnBlocking:
+		noun = pluralize(count, "blocking thread", "blocking threads")
+	default:
+		noun = pluralize(count, "thread or comment", "threads and comments")
+	}
+
+	ref := PRRef{Owner: response.Owner, Repo: response.Repo, Number: response.PRNumber}
+	if g.Max == 0 {
+		return newError(KindUnresolved, "%s has %d %s", ref, count, noun)
+	}
+	return newError(KindUnresolved, "%s has %d %s, more than the %d allowed", ref, count, noun, g.Max)
+}
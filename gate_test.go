@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestFeedbackGate(t *testing.T) {
+	response := &PRCommentsResponse{
+		Owner:    "octo-org",
+		Repo:     "widgets",
+		PRNumber: 1,
+		ReviewThreads: []ReviewThread{
+			// Only the unresolved thread categorised blocking counts for --fail-on blocking
+			{Category: CategoryComment},
+			{Category: CategoryNit},
+			{Category: CategoryBlocking, Conventional: &ConventionalComment{Label: "issue", Decorations: []string{"blocking"}, Blocking: true}},
+			{Category: CategorySuggestion, Conventional: &ConventionalComment{Label: "suggestion"}},
+			{Category: CategoryQuestion},
+			{Category: CategoryBlocking, IsResolved: true},
+		},
+		Summary: CommentsSummary{UnresolvedThreads: 5, ResolvedThreads: 1, GeneralComments: 2},
+	}
+
+	tests := []struct {
+		gate      FeedbackGate
+		wantCount int
+		wantErr   bool
+	}{
+		{gate: FeedbackGate{}, wantCount: 0},
+		{gate: FeedbackGate{Mode: FailOnUnresolved}, wantCount: 5, wantErr: true},
+		{gate: FeedbackGate{Mode: FailOnUnresolved, Max: 5}, wantCount: 5},
+		{gate: FeedbackGate{Mode: FailOnBlocking}, wantCount: 1, wantErr: true},
+		{gate: FeedbackGate{Mode: FailOnBlocking, Max: 1}, wantCount: 1},
+		{gate: FeedbackGate{Mode: FailOnAny, Max: 7}, wantCount: 8, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.gate.Count(response); got != tt.wantCount {
+			t.Errorf("%+v: Count = %d, want %d", tt.gate, got, tt.wantCount)
+		}
+		err := tt.gate.Check(response)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v: Check = %v, want error %v", tt.gate, err, tt.wantErr)
+		}
+		if err != nil && ExitCode(err) != ExitUnresolved {
+			t.Errorf("%+v: exit code %d, want %d", tt.gate, ExitCode(err), ExitUnresolved)
+		}
+	}
+}
@@ -1,47 +1,46 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"golang.org/x/oauth2"
 )
 
 // GitHubClient handles GitHub API interactions
 type GitHubClient struct {
-	token string
+	httpClient *http.Client
+	baseURL    string
 }
 
-// NewGitHubClient creates a new GitHub API client
-// If token is provided, it will be used; otherwise falls back to GITHUB_TOKEN env var
-func NewGitHubClient(token string) (*GitHubClient, error) {
-	if token == "" {
-		token = os.Getenv("GITHUB_TOKEN")
-		if token == "" {
-			return nil, fmt.Errorf("GitHub token is required. Provide via --token flag or GITHUB_TOKEN environment variable")
-		}
+// NewGitHubClientFromTokenSource creates a GitHub API client that authenticates
+// with tokens from src, e.g. a GitHub App installation. Requests are sent
+// with the HTTP client ctx holds under oauth2.HTTPClient, if any.
+func NewGitHubClientFromTokenSource(ctx context.Context, src oauth2.TokenSource, host string) *GitHubClient {
+	return &GitHubClient{
+		httpClient: oauth2.NewClient(ctx, src),
+		baseURL:    restAPIBaseURL(host),
 	}
-	return &GitHubClient{token: token}, nil
 }
 
 // FetchPRComments fetches all review comments for a PR
 func (c *GitHubClient) FetchPRComments(owner, repo string, prNumber int) ([]PRComment, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d/comments", owner, repo, prNumber)
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/comments", c.baseURL, owner, repo, prNumber)
 	
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 	
-	req.Header.Set("Authorization", "Bearer "+c.token)
 	req.Header.Set("Accept", "application/vnd.github+json")
 	
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("making request: %w", err)
 	}
@@ -49,7 +48,7 @@ func (c *GitHubClient) FetchPRComments(owner, repo string, prNumber int) ([]PRCo
 	
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, string(body))
+		return nil, restError(resp, body)
 	}
 	
 	var comments []PRComment
@@ -60,6 +59,11 @@ func (c *GitHubClient) FetchPRComments(owner, repo string, prNumber int) ([]PRCo
 	return comments, nil
 }
 
+// intPtr returns a pointer to a copy of n
+func intPtr(n int) *int {
+	return &n
+}
+
 // ParseDiffHunk parses a GitHub diff hunk string
 func ParseDiffHunk(diffHunk string) (*DiffHunkInfo, error) {
 	lines := strings.Split(diffHunk, "\n")
@@ -110,25 +114,25 @@ func ParseDiffHunk(diffHunk string) (*DiffHunkInfo, error) {
 		case strings.HasPrefix(line, "+"):
 			diffLine.Type = "addition"
 			diffLine.Content = line[1:] // Remove the + prefix
-			diffLine.NewLine = &newLine
+			diffLine.NewLine = intPtr(newLine)
 			newLine++
 		case strings.HasPrefix(line, "-"):
 			diffLine.Type = "deletion"
 			diffLine.Content = line[1:] // Remove the - prefix
-			diffLine.OldLine = &oldLine
+			diffLine.OldLine = intPtr(oldLine)
 			oldLine++
 		case strings.HasPrefix(line, " "):
 			diffLine.Type = "context"
 			diffLine.Content = line[1:] // Remove the space prefix
-			diffLine.OldLine = &oldLine
-			diffLine.NewLine = &newLine
+			diffLine.OldLine = intPtr(oldLine)
+			diffLine.NewLine = intPtr(newLine)
 			oldLine++
 			newLine++
 		default:
 			// Handle lines without prefix as context
 			diffLine.Type = "context"
-			diffLine.OldLine = &oldLine
-			diffLine.NewLine = &newLine
+			diffLine.OldLine = intPtr(oldLine)
+			diffLine.NewLine = intPtr(newLine)
 			oldLine++
 			newLine++
 		}
@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"fmt"
-	"os"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/shurcooL/githubv4"
 	"golang.org/x/oauth2"
@@ -13,25 +15,27 @@ import (
 // GitHubGraphQLClient handles GitHub GraphQL API interactions
 type GitHubGraphQLClient struct {
 	client *githubv4.Client
-}
 
-// NewGitHubGraphQLClient creates a new GitHub GraphQL API client
-func NewGitHubGraphQLClient(token string) (*GitHubGraphQLClient, error) {
-	if token == "" {
-		token = os.Getenv("GITHUB_TOKEN")
-		if token == "" {
-			return nil, fmt.Errorf("GitHub token is required")
-		}
-	}
+	viewerOnce  sync.Once
+	viewerLogin string
+	viewerErr   error
+}
 
-	src := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	httpClient := oauth2.NewClient(context.Background(), src)
+// NewGitHubGraphQLClientFromTokenSource creates a GitHub GraphQL API client
+// that authenticates with tokens from src, e.g. a GitHub App installation.
+// Requests are sent with the HTTP client ctx holds under oauth2.HTTPClient,
+// if any.
+func NewGitHubGraphQLClientFromTokenSource(ctx context.Context, src oauth2.TokenSource, host string) *GitHubGraphQLClient {
+	httpClient := oauth2.NewClient(ctx, src)
 
 	return &GitHubGraphQLClient{
-		client: githubv4.NewClient(httpClient),
-	}, nil
+		client: githubv4.NewEnterpriseClient(graphQLEndpoint(host), httpClient),
+	}
+}
+
+// query runs a GraphQL query, classifying any error by kind
+func (c *GitHubGraphQLClient) query(ctx context.Context, q interface{}, variables map[string]interface{}) error {
+	return classifyGraphQLError(c.client.Query(ctx, q, variables))
 }
 
 // FetchOptions configures what data to fetch
@@ -39,6 +43,50 @@ type FetchOptions struct {
 	IncludeResolved bool
 	IncludeOutdated bool
 	IncludeGeneral  bool
+
+	// RequireOpen fails with a KindPRClosed error if the PR is closed or merged
+	RequireOpen bool
+
+	// Author filters, matched case-insensitively against comment logins
+	Authors         []string // keep threads with a comment by one of these authors
+	ExcludeAuthors  []string // drop comments by these authors
+	ExcludeBots     bool     // drop comments by bot accounts
+	ThreadStartedBy []string // keep threads whose first comment is by one of these authors
+
+	// Path filters, gitignore-style patterns matched against the thread's file
+	Paths           []string // keep threads on files matching one of these patterns
+	ExcludePaths    []string // drop threads on files matching one of these patterns
+	CodeownersTeams []string // keep threads on files owned by one of these teams
+
+	// Time window, matched on thread creation or, with TimeField "activity",
+	// on the latest comment in the thread
+	Since       time.Time
+	Until       time.Time
+	TimeField   string
+	SinceCommit string // use this commit's timestamp as Since
+
+	// Turn-taking filter: keep unresolved threads where someone other than
+	// this user spoke last; with OnlyInvolved, only where the user is the PR
+	// author, took part in the thread or was @mentioned in it
+	NeedsResponseFrom string
+	OnlyInvolved      bool
+
+	// Text search: keep threads with a comment body (or, with GrepDiffHunks,
+	// a diff hunk) matching Grep
+	Grep          *regexp.Regexp
+	GrepDiffHunks bool
+
+	// Intent classification: Classifier defaults to the built-in rules, and
+	// Categories keeps only threads and comments of these categories
+	Classifier *Classifier
+	Categories []string
+
+	// Streaming hooks, called for each thread and general comment as soon as
+	// it has been fetched and passed the filters
+	OnThread         func(ReviewThread)
+	OnGeneralComment func(GeneralComment)
+
+	codeowners *Codeowners // loaded from the repository when CodeownersTeams is set
 }
 
 // FetchPRReviewThreads fetches PR review threads with filtering
@@ -52,6 +100,35 @@ func (c *GitHubGraphQLClient) FetchPRReviewThreads(
 	var allThreads []ReviewThread
 	var allGeneralComments []GeneralComment
 
+	// Resolve @me to the authenticated user's login
+	if opts.referencesViewer() {
+		viewer, err := c.ViewerLogin(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resolving @me: %w", err)
+		}
+		opts = opts.withViewer(viewer)
+	}
+
+	// Load CODEOWNERS from the PR's base branch for team-based path filtering
+	if len(opts.CodeownersTeams) > 0 && opts.codeowners == nil {
+		codeowners, err := c.FetchCodeowners(ctx, owner, repo, prNumber)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading CODEOWNERS: %w", err)
+		}
+		opts.codeowners = codeowners
+	}
+
+	// Only show feedback that came in after the given commit
+	if opts.SinceCommit != "" {
+		committedAt, err := c.FetchCommitTime(ctx, owner, repo, opts.SinceCommit)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resolving --since-commit: %w", err)
+		}
+		if committedAt.After(opts.Since) {
+			opts.Since = committedAt
+		}
+	}
+
 	// Fetch review threads with pagination
 	err := c.fetchReviewThreads(ctx, owner, repo, prNumber, opts, &allThreads)
 	if err != nil {
@@ -60,7 +137,7 @@ func (c *GitHubGraphQLClient) FetchPRReviewThreads(
 
 	// Fetch general comments if requested
 	if opts.IncludeGeneral {
-		err = c.fetchGeneralComments(ctx, owner, repo, prNumber, &allGeneralComments)
+		err = c.fetchGeneralComments(ctx, owner, repo, prNumber, opts, &allGeneralComments)
 		if err != nil {
 			return nil, nil, fmt.Errorf("fetching general comments: %w", err)
 		}
@@ -83,6 +160,10 @@ func (c *GitHubGraphQLClient) fetchReviewThreads(
 		var query struct {
 			Repository struct {
 				PullRequest struct {
+					State  githubv4.PullRequestState
+					Author struct {
+						Login githubv4.String
+					}
 					ReviewThreads struct {
 						PageInfo struct {
 							EndCursor   githubv4.String
@@ -106,7 +187,8 @@ func (c *GitHubGraphQLClient) fetchReviewThreads(
 									Body      githubv4.String
 									CreatedAt githubv4.DateTime
 									Author    struct {
-										Login githubv4.String
+										Login    githubv4.String
+										Typename githubv4.String `graphql:"__typename"`
 									}
 									DiffHunk githubv4.String
 									ReplyTo  *struct {
@@ -128,11 +210,15 @@ func (c *GitHubGraphQLClient) fetchReviewThreads(
 			"cursor":   cursor,
 		}
 
-		err := c.client.Query(ctx, &query, variables)
+		err := c.query(ctx, &query, variables)
 		if err != nil {
 			return fmt.Errorf("GraphQL query error for PR #%d in %s/%s: %w", prNumber, owner, repo, err)
 		}
 
+		if state := query.Repository.PullRequest.State; opts.RequireOpen && state != githubv4.PullRequestStateOpen {
+			return newError(KindPRClosed, "PR #%d in %s/%s is %s", prNumber, owner, repo, strings.ToLower(string(state)))
+		}
+
 		// Process and filter threads
 		for _, thread := range query.Repository.PullRequest.ReviewThreads.Nodes {
 			// Apply filtering logic
@@ -142,6 +228,9 @@ func (c *GitHubGraphQLClient) fetchReviewThreads(
 			if !opts.IncludeOutdated && thread.IsOutdated {
 				continue
 			}
+			if !opts.keepPath(string(thread.Path)) {
+				continue
+			}
 
 			reviewThread := ReviewThread{
 				ID:         string(thread.ID),
@@ -180,6 +269,7 @@ func (c *GitHubGraphQLClient) fetchReviewThreads(
 					CreatedAt: comment.CreatedAt.Format("2006-01-02 15:04:05"),
 					HTMLURL:   comment.URL.String(),
 					IsReply:   comment.ReplyTo != nil,
+					IsBot:     isBotAuthor(string(comment.Author.Login), string(comment.Author.Typename)),
 				}
 
 				reviewThread.Comments = append(reviewThread.Comments, threadComment)
@@ -202,7 +292,32 @@ func (c *GitHubGraphQLClient) fetchReviewThreads(
 				}
 			}
 
+			// Turn-taking looks at the whole conversation, before authors are filtered out
+			if !opts.needsResponse(reviewThread, string(query.Repository.PullRequest.Author.Login)) {
+				continue
+			}
+			if !opts.filterThreadAuthors(&reviewThread) {
+				continue
+			}
+			if !opts.keepThreadTime(reviewThread) {
+				continue
+			}
+			if !opts.keepThreadGrep(reviewThread) {
+				continue
+			}
+
+			if len(reviewThread.Comments) > 0 {
+				reviewThread.Conventional = ParseConventionalComment(reviewThread.Comments[0].Body)
+			}
+			reviewThread.Category = threadCategory(reviewThread, opts.classifier())
+			if !opts.keepCategory(reviewThread.Category) {
+				continue
+			}
+
 			*allThreads = append(*allThreads, reviewThread)
+			if opts.OnThread != nil {
+				opts.OnThread(reviewThread)
+			}
 		}
 
 		if !query.Repository.PullRequest.ReviewThreads.PageInfo.HasNextPage {
@@ -240,7 +355,8 @@ func (c *GitHubGraphQLClient) fetchAdditionalThreadComments(
 							Body      githubv4.String
 							CreatedAt githubv4.DateTime
 							Author    struct {
-								Login githubv4.String
+								Login    githubv4.String
+								Typename githubv4.String `graphql:"__typename"`
 							}
 							DiffHunk githubv4.String
 							ReplyTo  *struct {
@@ -258,7 +374,7 @@ func (c *GitHubGraphQLClient) fetchAdditionalThreadComments(
 			"cursor":   cursor,
 		}
 
-		if err := c.client.Query(ctx, &query, variables); err != nil {
+		if err := c.query(ctx, &query, variables); err != nil {
 			return fmt.Errorf("GraphQL thread comment query error: %w", err)
 		}
 
@@ -271,6 +387,7 @@ func (c *GitHubGraphQLClient) fetchAdditionalThreadComments(
 				CreatedAt: comment.CreatedAt.Format("2006-01-02 15:04:05"),
 				HTMLURL:   comment.URL.String(),
 				IsReply:   comment.ReplyTo != nil,
+				IsBot:     isBotAuthor(string(comment.Author.Login), string(comment.Author.Typename)),
 			}
 
 			reviewThread.Comments = append(reviewThread.Comments, threadComment)
@@ -294,14 +411,20 @@ func (c *GitHubGraphQLClient) fetchGeneralComments(
 	ctx context.Context,
 	owner, repo string,
 	prNumber int,
+	opts FetchOptions,
 	allComments *[]GeneralComment,
 ) error {
 	var cursor *githubv4.String
+	var comments []GeneralComment
+	var prAuthor string
 
 	for {
 		var query struct {
 			Repository struct {
 				PullRequest struct {
+					Author struct {
+						Login githubv4.String
+					}
 					Comments struct {
 						PageInfo struct {
 							EndCursor   githubv4.String
@@ -312,7 +435,8 @@ func (c *GitHubGraphQLClient) fetchGeneralComments(
 							Body      githubv4.String
 							CreatedAt githubv4.DateTime
 							Author    struct {
-								Login githubv4.String
+								Login    githubv4.String
+								Typename githubv4.String `graphql:"__typename"`
 							}
 							URL githubv4.URI
 						}
@@ -328,11 +452,13 @@ func (c *GitHubGraphQLClient) fetchGeneralComments(
 			"cursor":   cursor,
 		}
 
-		err := c.client.Query(ctx, &query, variables)
+		err := c.query(ctx, &query, variables)
 		if err != nil {
 			return fmt.Errorf("GraphQL query error for PR #%d general comments: %w", prNumber, err)
 		}
 
+		prAuthor = string(query.Repository.PullRequest.Author.Login)
+
 		// Process general comments
 		for _, comment := range query.Repository.PullRequest.Comments.Nodes {
 			generalComment := GeneralComment{
@@ -341,8 +467,9 @@ func (c *GitHubGraphQLClient) fetchGeneralComments(
 				Author:    string(comment.Author.Login),
 				CreatedAt: comment.CreatedAt.Format("2006-01-02 15:04:05"),
 				HTMLURL:   comment.URL.String(),
+				IsBot:     isBotAuthor(string(comment.Author.Login), string(comment.Author.Typename)),
 			}
-			*allComments = append(*allComments, generalComment)
+			comments = append(comments, generalComment)
 		}
 
 		if !query.Repository.PullRequest.Comments.PageInfo.HasNextPage {
@@ -351,5 +478,220 @@ func (c *GitHubGraphQLClient) fetchGeneralComments(
 		cursor = githubv4.NewString(query.Repository.PullRequest.Comments.PageInfo.EndCursor)
 	}
 
+	// Turn-taking looks at the whole conversation, before authors are filtered out
+	for _, comment := range opts.filterGeneralNeedsResponse(comments, prAuthor) {
+		if !opts.keepGeneralCommentAuthor(comment) || !opts.keepGeneralCommentTime(comment) || !opts.keepGeneralCommentGrep(comment) {
+			continue
+		}
+		comment.Category = opts.classifier().Classify(comment.Body)
+		if !opts.keepCategory(comment.Category) {
+			continue
+		}
+		*allComments = append(*allComments, comment)
+		if opts.OnGeneralComment != nil {
+			opts.OnGeneralComment(comment)
+		}
+	}
+
 	return nil
 }
+
+// ViewerLogin returns the login of the authenticated user, querying it once
+func (c *GitHubGraphQLClient) ViewerLogin(ctx context.Context) (string, error) {
+	c.viewerOnce.Do(func() {
+		var query struct {
+			Viewer struct {
+				Login githubv4.String
+			}
+		}
+
+		if err := c.query(ctx, &query, nil); err != nil {
+			c.viewerErr = fmt.Errorf("GraphQL viewer query error: %w", err)
+			return
+		}
+		c.viewerLogin = string(query.Viewer.Login)
+	})
+
+	return c.viewerLogin, c.viewerErr
+}
+
+// FetchCodeowners loads the CODEOWNERS file from a PR's base branch
+func (c *GitHubGraphQLClient) FetchCodeowners(
+	ctx context.Context,
+	owner, repo string,
+	prNumber int,
+) (*Codeowners, error) {
+	var baseQuery struct {
+		Repository struct {
+			PullRequest struct {
+				BaseRefOid githubv4.GitObjectID
+			} `graphql:"pullRequest(number: $prNumber)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"owner":    githubv4.String(owner),
+		"name":     githubv4.String(repo),
+		"prNumber": githubv4.Int(prNumber),
+	}
+
+	if err := c.query(ctx, &baseQuery, variables); err != nil {
+		return nil, fmt.Errorf("GraphQL query error for PR #%d base branch: %w", prNumber, err)
+	}
+	baseOid := string(baseQuery.Repository.PullRequest.BaseRefOid)
+
+	for _, path := range codeownersPaths {
+		var fileQuery struct {
+			Repository struct {
+				Object struct {
+					Blob struct {
+						Text githubv4.String
+					} `graphql:"... on Blob"`
+				} `graphql:"object(expression: $expression)"`
+			} `graphql:"repository(owner: $owner, name: $name)"`
+		}
+
+		fileVariables := map[string]interface{}{
+			"owner":      githubv4.String(owner),
+			"name":       githubv4.String(repo),
+			"expression": githubv4.String(baseOid + ":" + path),
+		}
+
+		if err := c.query(ctx, &fileQuery, fileVariables); err != nil {
+			return nil, fmt.Errorf("GraphQL query error for %s: %w", path, err)
+		}
+
+		if text := string(fileQuery.Repository.Object.Blob.Text); text != "" {
+			return ParseCodeowners(text), nil
+		}
+	}
+
+	return nil, fmt.Errorf("no CODEOWNERS file found in %s/%s (looked in %s)", owner, repo, strings.Join(codeownersPaths, ", "))
+}
+
+// FetchCommitTime returns the commit timestamp of a commit (full or abbreviated SHA)
+func (c *GitHubGraphQLClient) FetchCommitTime(
+	ctx context.Context,
+	owner, repo string,
+	sha string,
+) (time.Time, error) {
+	var query struct {
+		Repository struct {
+			Object struct {
+				Commit struct {
+					CommittedDate githubv4.DateTime
+				} `graphql:"... on Commit"`
+			} `graphql:"object(expression: $expression)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	variables := map[string]interface{}{
+		"owner":      githubv4.String(owner),
+		"name":       githubv4.String(repo),
+		"expression": githubv4.String(sha),
+	}
+
+	if err := c.query(ctx, &query, variables); err != nil {
+		return time.Time{}, fmt.Errorf("GraphQL query error for commit %s: %w", sha, err)
+	}
+
+	committedAt := query.Repository.Object.Commit.CommittedDate.Time
+	if committedAt.IsZero() {
+		return time.Time{}, fmt.Errorf("commit %s not found in %s/%s", sha, owner, repo)
+	}
+
+	return committedAt, nil
+}
+
+// PullRequestSearchResult holds the metadata of a pull request returned by search
+type PullRequestSearchResult struct {
+	Owner          string
+	Repo           string
+	Number         int
+	Title          string
+	URL            string
+	Author         string
+	IsDraft        bool
+	ReviewDecision string
+	UpdatedAt      time.Time
+}
+
+// SearchPullRequests runs a GitHub issue search and returns the matching pull requests
+func (c *GitHubGraphQLClient) SearchPullRequests(
+	ctx context.Context,
+	searchQuery string,
+	limit int,
+) ([]PullRequestSearchResult, error) {
+	var results []PullRequestSearchResult
+	var cursor *githubv4.String
+
+	for {
+		var query struct {
+			Search struct {
+				PageInfo struct {
+					EndCursor   githubv4.String
+					HasNextPage bool
+				}
+				Nodes []struct {
+					PullRequest struct {
+						Number         githubv4.Int
+						Title          githubv4.String
+						URL            githubv4.URI
+						IsDraft        bool
+						ReviewDecision githubv4.String
+						UpdatedAt      githubv4.DateTime
+						Author         struct {
+							Login githubv4.String
+						}
+						Repository struct {
+							Name  githubv4.String
+							Owner struct {
+								Login githubv4.String
+							}
+						}
+					} `graphql:"... on PullRequest"`
+				}
+			} `graphql:"search(query: $query, type: ISSUE, first: 50, after: $cursor)"`
+		}
+
+		variables := map[string]interface{}{
+			"query":  githubv4.String(searchQuery),
+			"cursor": cursor,
+		}
+
+		if err := c.query(ctx, &query, variables); err != nil {
+			return nil, fmt.Errorf("GraphQL search error for %q: %w", searchQuery, err)
+		}
+
+		for _, node := range query.Search.Nodes {
+			pr := node.PullRequest
+			if pr.Number == 0 {
+				// Not a pull request (search type ISSUE also matches issues)
+				continue
+			}
+
+			results = append(results, PullRequestSearchResult{
+				Owner:          string(pr.Repository.Owner.Login),
+				Repo:           string(pr.Repository.Name),
+				Number:         int(pr.Number),
+				Title:          string(pr.Title),
+				URL:            pr.URL.String(),
+				Author:         string(pr.Author.Login),
+				IsDraft:        pr.IsDraft,
+				ReviewDecision: string(pr.ReviewDecision),
+				UpdatedAt:      pr.UpdatedAt.Time,
+			})
+
+			if limit > 0 && len(results) >= limit {
+				return results, nil
+			}
+		}
+
+		if !query.Search.PageInfo.HasNextPage {
+			break
+		}
+		cursor = githubv4.NewString(query.Search.PageInfo.EndCursor)
+	}
+
+	return results, nil
+}
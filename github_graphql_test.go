@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+
+	"pr-review-cli/fakegithub"
+)
+
+// fakeSeed holds octo-org/widgets with an open PR #1, whose first review
+// thread has three comments, and a merged PR #2
+func fakeSeed() *fakegithub.Data {
+	line := func(n int) *int { return &n }
+	return &fakegithub.Data{
+		Viewer: "octocat",
+		Repositories: []*fakegithub.Repository{{
+			Owner: "octo-org",
+			Name:  "widgets",
+			PullRequests: []*fakegithub.PullRequest{
+				{
+					Number: 1,
+					Title:  "Add caching",
+					Author: "hubot",
+					Threads: []*fakegithub.Thread{
+						{Path: "cache.go", Line: line(12), Comments: []*fakegithub.Comment{
+							{Author: "octocat", Body: "issue (blocking): this map needs a mutex", DiffHunk: "@@ -10,2 +10,3 @@\n entries map[string]Widget\n+hits int"},
+							{Author: "hubot", Body: "Will do."},
+							{Author: "octocat", Body: "Thanks."},
+						}},
+						{Path: "cache.go", Line: line(30), Resolved: true, Comments: []*fakegithub.Comment{{Author: "octocat", Body: "nit: naming"}}},
+						{Path: "docs/cache.md", Line: line(3), Outdated: true, Comments: []*fakegithub.Comment{{Author: "lint-bot", Bot: true, Body: "Typo."}}},
+					},
+					Comments: []*fakegithub.Comment{
+						{Author: "octocat", Body: "Please add a benchmark."},
+						{Author: "hubot", Body: "Added."},
+					},
+				},
+				{Number: 2, Title: "Old change", Author: "hubot", State: "MERGED"},
+			},
+		}},
+	}
+}
+
+// newFakeGitHub starts a fake GitHub over fakeSeed and returns it with the
+// URL to pass as the host
+func newFakeGitHub(t *testing.T, configure func(*fakegithub.Server)) (*fakegithub.Server, string) {
+	t.Helper()
+	server, err := fakegithub.New(fakeSeed())
+	if err != nil {
+		t.Fatalf("fakegithub.New: %v", err)
+	}
+	if configure != nil {
+		configure(server)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	return server, httpServer.URL
+}
+
+// tokenSource returns a static token source for tests
+func tokenSource(token string) oauth2.TokenSource {
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+}
+
+func TestFetchPRReviewThreadsPaginates(t *testing.T) {
+	_, host := newFakeGitHub(t, func(s *fakegithub.Server) { s.PageSize = 1 })
+	client := NewGitHubGraphQLClientFromTokenSource(context.Background(), tokenSource("test"), host)
+
+	opts := FetchOptions{IncludeResolved: true, IncludeOutdated: true, IncludeGeneral: true}
+	response, err := fetchGraphQLResponse(context.Background(), client, PRRef{Owner: "octo-org", Repo: "widgets", Number: 1}, opts)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	if len(response.ReviewThreads) != 3 {
+		t.Fatalf("got %d threads, want 3", len(response.ReviewThreads))
+	}
+	first := response.ReviewThreads[0]
+	if len(first.Comments) != 3 {
+		t.Fatalf("first thread has %d comments, want 3", len(first.Comments))
+	}
+	if first.Comments[0].IsReply || !first.Comments[1].IsReply || first.Comments[2].Body != "Thanks." {
+		t.Errorf("first thread's comments = %+v", first.Comments)
+	}
+	if first.Category != CategoryBlocking || first.Conventional == nil || !first.Conventional.Blocking {
+		t.Errorf("first thread: category %q, conventional %+v; want a blocking conventional comment", first.Category, first.Conventional)
+	}
+	if len(response.GeneralComments) != 2 {
+		t.Errorf("got %d general comments, want 2", len(response.GeneralComments))
+	}
+}
+
+func TestFetchPRReviewThreadsFilters(t *testing.T) {
+	_, host := newFakeGitHub(t, func(s *fakegithub.Server) { s.PageSize = 2 })
+	client := NewGitHubGraphQLClientFromTokenSource(context.Background(), tokenSource("test"), host)
+	ref := PRRef{Owner: "octo-org", Repo: "widgets", Number: 1}
+
+	tests := []struct {
+		name      string
+		opts      FetchOptions
+		wantFiles []string
+	}{
+		{name: "defaults drop resolved and outdated", opts: FetchOptions{}, wantFiles: []string{"cache.go"}},
+		{name: "include resolved", opts: FetchOptions{IncludeResolved: true}, wantFiles: []string{"cache.go", "cache.go"}},
+		{name: "exclude bots", opts: FetchOptions{IncludeResolved: true, IncludeOutdated: true, ExcludeBots: true}, wantFiles: []string{"cache.go", "cache.go"}},
+		{name: "path", opts: FetchOptions{IncludeOutdated: true, Paths: []string{"docs/"}}, wantFiles: []string{"docs/cache.md"}},
+		{name: "started by @me", opts: FetchOptions{IncludeResolved: true, IncludeOutdated: true, ThreadStartedBy: []string{"@me"}}, wantFiles: []string{"cache.go", "cache.go"}},
+		{name: "category", opts: FetchOptions{IncludeResolved: true, Categories: []string{CategoryNit}}, wantFiles: []string{"cache.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := fetchGraphQLResponse(context.Background(), client, ref, tt.opts)
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			var files []string
+			for _, thread := range response.ReviewThreads {
+				files = append(files, thread.File)
+			}
+			if len(files) != len(tt.wantFiles) {
+				t.Fatalf("files = %v, want %v", files, tt.wantFiles)
+			}
+			for i := range files {
+				if files[i] != tt.wantFiles[i] {
+					t.Errorf("files = %v, want %v", files, tt.wantFiles)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchErrorKinds(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+
+	tests := []struct {
+		name      string
+		configure func(*fakegithub.Server)
+		host      string
+		token     string
+		ref       PRRef
+		opts      FetchOptions
+		wantKind  ErrorKind
+		wantCode  int
+	}{
+		{
+			name:      "bad credentials",
+			configure: func(s *fakegithub.Server) { s.Token = "secret" },
+			token:     "wrong",
+			ref:       PRRef{Owner: "octo-org", Repo: "widgets", Number: 1},
+			wantKind:  KindAuth,
+			wantCode:  ExitAuth,
+		},
+		{
+			name:     "unknown repository",
+			ref:      PRRef{Owner: "octo-org", Repo: "gadgets", Number: 1},
+			wantKind: KindNotFound,
+			wantCode: ExitNotFound,
+		},
+		{
+			name:     "unknown pull request",
+			ref:      PRRef{Owner: "octo-org", Repo: "widgets", Number: 99},
+			wantKind: KindNotFound,
+			wantCode: ExitNotFound,
+		},
+		{
+			name:     "merged pull request with --require-open",
+			ref:      PRRef{Owner: "octo-org", Repo: "widgets", Number: 2},
+			opts:     FetchOptions{RequireOpen: true},
+			wantKind: KindPRClosed,
+			wantCode: ExitPRClosed,
+		},
+		{
+			name: "rate limit used up while paginating",
+			configure: func(s *fakegithub.Server) {
+				s.PageSize = 1
+				s.RateLimit = 1
+			},
+			ref:      PRRef{Owner: "octo-org", Repo: "widgets", Number: 1},
+			opts:     FetchOptions{IncludeResolved: true, IncludeOutdated: true},
+			wantKind: KindRateLimited,
+			wantCode: ExitRateLimited,
+		},
+		{
+			name:     "unreachable host",
+			host:     closedURL,
+			ref:      PRRef{Owner: "octo-org", Repo: "widgets", Number: 1},
+			wantKind: KindNetwork,
+			wantCode: ExitNetwork,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, host := newFakeGitHub(t, tt.configure)
+			if tt.host != "" {
+				host = tt.host
+			}
+			token := tt.token
+			if token == "" {
+				token = "test"
+			}
+			client := NewGitHubGraphQLClientFromTokenSource(context.Background(), tokenSource(token), host)
+
+			_, err := fetchGraphQLResponse(context.Background(), client, tt.ref, tt.opts)
+			if err == nil {
+				t.Fatal("fetch succeeded, want an error")
+			}
+			if kind := ErrorKindOf(err); kind != tt.wantKind {
+				t.Errorf("kind = %q, want %q (error: %v)", kind, tt.wantKind, err)
+			}
+			if code := ExitCode(err); code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestFetchBatchExitCodes(t *testing.T) {
+	_, host := newFakeGitHub(t, nil)
+	client := NewGitHubGraphQLClientFromTokenSource(context.Background(), tokenSource("test"), host)
+
+	tests := []struct {
+		name     string
+		numbers  []int
+		wantCode int
+	}{
+		{name: "all fetched", numbers: []int{1}, wantCode: ExitOK},
+		{name: "some failed", numbers: []int{1, 98}, wantCode: ExitPartial},
+		{name: "all failed the same way", numbers: []int{98, 99}, wantCode: ExitNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := make([]PRRef, len(tt.numbers))
+			for i, number := range tt.numbers {
+				refs[i] = PRRef{Owner: "octo-org", Repo: "widgets", Number: number}
+			}
+
+			results := FetchBatch(context.Background(), refs, 2, func(ctx context.Context, ref PRRef) (*PRCommentsResponse, error) {
+				return fetchGraphQLResponse(ctx, client, ref, FetchOptions{})
+			}, nil)
+
+			var failures []error
+			for _, result := range results {
+				if result.Err != nil {
+					failures = append(failures, result.Err)
+				}
+			}
+			if code := ExitCode(batchError(failures, len(refs))); code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestFeedbackGateOnFetchedPR(t *testing.T) {
+	_, host := newFakeGitHub(t, nil)
+	client := NewGitHubGraphQLClientFromTokenSource(context.Background(), tokenSource("test"), host)
+
+	response, err := fetchGraphQLResponse(context.Background(), client, PRRef{Owner: "octo-org", Repo: "widgets", Number: 1}, FetchOptions{IncludeResolved: true})
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	err = FeedbackGate{Mode: FailOnBlocking}.Check(response)
+	if ErrorKindOf(err) != KindUnresolved || ExitCode(err) != ExitUnresolved {
+		t.Errorf("gate error = %v (exit code %d), want kind unresolved and exit code %d", err, ExitCode(err), ExitUnresolved)
+	}
+	if err := (FeedbackGate{Mode: FailOnBlocking, Max: 1}).Check(response); err != nil {
+		t.Errorf("gate with --max-unresolved 1: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"pr-review-cli/fakegithub"
+)
+
+func TestParseDiffHunk(t *testing.T) {
+	tests := []struct {
+		name                 string
+		hunk                 string
+		wantErr              bool
+		wantOldStart         int
+		wantNewStart         int
+		wantNewCount         int
+		wantTypes            []string
+		wantLastNew, wantOld int
+	}{
+		{
+			name:         "addition after context",
+			hunk:         "@@ -10,2 +10,3 @@ func main() {\n a := 1\n+b := 2\n }",
+			wantOldStart: 10, wantNewStart: 10, wantNewCount: 3,
+			wantTypes:   []string{"context", "addition", "context"},
+			wantLastNew: 12, wantOld: 11,
+		},
+		{
+			name:         "deletion",
+			hunk:         "@@ -5,2 +5 @@\n-gone\n kept",
+			wantOldStart: 5, wantNewStart: 5, wantNewCount: 1,
+			wantTypes:   []string{"deletion", "context"},
+			wantLastNew: 5, wantOld: 6,
+		},
+		{
+			name:    "no header",
+			hunk:    "+just a line",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseDiffHunk(tt.hunk)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDiffHunk: %v", err)
+			}
+			if info.OldStart != tt.wantOldStart || info.NewStart != tt.wantNewStart || info.NewCount != tt.wantNewCount {
+				t.Errorf("header = -%d +%d,%d", info.OldStart, info.NewStart, info.NewCount)
+			}
+			if len(info.Lines) != len(tt.wantTypes) {
+				t.Fatalf("got %d lines, want %d", len(info.Lines), len(tt.wantTypes))
+			}
+			for i, line := range info.Lines {
+				if line.Type != tt.wantTypes[i] {
+					t.Errorf("line %d type = %q, want %q", i, line.Type, tt.wantTypes[i])
+				}
+			}
+			last := info.Lines[len(info.Lines)-1]
+			if last.NewLine == nil || *last.NewLine != tt.wantLastNew || last.OldLine == nil || *last.OldLine != tt.wantOld {
+				t.Errorf("last line numbers = %v/%v, want old %d new %d", last.OldLine, last.NewLine, tt.wantOld, tt.wantLastNew)
+			}
+		})
+	}
+}
+
+// pathRecorder records the paths of the requests it passes on
+type pathRecorder struct {
+	next  http.Handler
+	mu    sync.Mutex
+	paths []string
+}
+
+func (p *pathRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.paths = append(p.paths, r.URL.Path)
+	p.mu.Unlock()
+	p.next.ServeHTTP(w, r)
+}
+
+func TestFetchPRCommentsOverEnterpriseREST(t *testing.T) {
+	server, err := fakegithub.New(fakeSeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := &pathRecorder{next: server}
+	httpServer := httptest.NewServer(recorder)
+	defer httpServer.Close()
+
+	client := NewGitHubClientFromTokenSource(context.Background(), tokenSource("test"), httpServer.URL)
+	comments, err := client.FetchPRComments("octo-org", "widgets", 1)
+	if err != nil {
+		t.Fatalf("FetchPRComments: %v", err)
+	}
+	if len(comments) != 5 {
+		t.Errorf("got %d review comments, want 5", len(comments))
+	}
+	if len(recorder.paths) != 1 || recorder.paths[0] != "/api/v3/repos/octo-org/widgets/pulls/1/comments" {
+		t.Errorf("requested %v, want the comments under /api/v3", recorder.paths)
+	}
+
+	parsed, err := ParseComments(comments)
+	if err != nil {
+		t.Fatalf("ParseComments: %v", err)
+	}
+	if len(parsed) != 5 || parsed[0].File != "cache.go" {
+		t.Errorf("parsed comments = %+v", parsed)
+	}
+}
+
+func TestFetchPRCommentsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*fakegithub.Server)
+		number    int
+		wantKind  ErrorKind
+		wantReset bool
+	}{
+		{name: "bad credentials", configure: func(s *fakegithub.Server) { s.Token = "secret" }, number: 1, wantKind: KindAuth},
+		{name: "unknown pull request", number: 99, wantKind: KindNotFound},
+		{name: "rate limited", configure: func(s *fakegithub.Server) { s.RateLimit = 1 }, number: 1, wantKind: KindRateLimited, wantReset: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, host := newFakeGitHub(t, tt.configure)
+			client := NewGitHubClientFromTokenSource(context.Background(), tokenSource("test"), host)
+
+			// The first request uses up a rate limit of 1
+			_, err := client.FetchPRComments("octo-org", "widgets", tt.number)
+			if tt.wantKind == KindRateLimited {
+				_, err = client.FetchPRComments("octo-org", "widgets", tt.number)
+			}
+
+			var cliErr *CLIError
+			if !errors.As(err, &cliErr) {
+				t.Fatalf("err = %v, want a CLIError", err)
+			}
+			if cliErr.Kind != tt.wantKind {
+				t.Errorf("kind = %q, want %q", cliErr.Kind, tt.wantKind)
+			}
+			if tt.wantReset && cliErr.ResetAt.IsZero() {
+				t.Error("no reset time")
+			}
+		})
+	}
+}
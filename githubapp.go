@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// appJWTLifetime is how long an app JWT is valid; GitHub allows at most 10 minutes
+	appJWTLifetime = 9 * time.Minute
+	// appJWTClockSkew backdates the JWT to allow for clock drift
+	appJWTClockSkew = 60 * time.Second
+	// installationTokenRefreshEarly renews installation tokens this long before they expire
+	installationTokenRefreshEarly = 5 * time.Minute
+)
+
+// AppCredentials identify a GitHub App installation
+type AppCredentials struct {
+	AppID          int64
+	InstallationID int64
+	PrivateKey     *rsa.PrivateKey
+}
+
+// installationTokenSource exchanges app JWTs for installation access tokens
+type installationTokenSource struct {
+	ctx        context.Context
+	app        AppCredentials
+	apiBaseURL string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewAppTokenSource returns a token source for a GitHub App installation.
+// Installation tokens are cached and refreshed shortly before they expire.
+// apiBaseURL is the REST API base URL, e.g. https://api.github.com. Tokens
+// are requested with the HTTP client ctx holds under oauth2.HTTPClient, if
+// any, like the API requests themselves.
+func NewAppTokenSource(ctx context.Context, app AppCredentials, apiBaseURL string) oauth2.TokenSource {
+	httpClient := http.DefaultClient
+	if client, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok && client != nil {
+		httpClient = client
+	}
+
+	source := &installationTokenSource{
+		ctx:        ctx,
+		app:        app,
+		apiBaseURL: strings.TrimSuffix(apiBaseURL, "/"),
+		httpClient: httpClient,
+		now:        time.Now,
+	}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, source, installationTokenRefreshEarly)
+}
+
+// Token mints a JWT and exchanges it for a new installation access token
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := signAppJWT(s.app, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", s.apiBaseURL, s.app.InstallationID)
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating installation token request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		err := restError(resp, body)
+		err.Message = fmt.Sprintf("GitHub App installation token error %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return nil, err
+	}
+
+	var payload struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decoding installation token: %w", err)
+	}
+	if payload.Token == "" {
+		return nil, fmt.Errorf("installation token response has no token")
+	}
+
+	return &oauth2.Token{
+		AccessToken: payload.Token,
+		TokenType:   "Bearer",
+		Expiry:      payload.ExpiresAt,
+	}, nil
+}
+
+// signAppJWT creates the RS256 JSON Web Token that authenticates as the app
+func signAppJWT(app AppCredentials, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("encoding JWT header: %w", err)
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-appJWTClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": fmt.Sprint(app.AppID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("encoding JWT claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, app.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing JWT: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// LoadAppPrivateKey reads a GitHub App private key, given as a path to a PEM
+// file or as the PEM text itself
+func LoadAppPrivateKey(value string) (*rsa.PrivateKey, error) {
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		var err error
+		if data, err = os.ReadFile(expandHome(value)); err != nil {
+			return nil, fmt.Errorf("reading app private key: %w", err)
+		}
+	}
+	return parseAppPrivateKey(data)
+}
+
+// parseAppPrivateKey parses a PKCS#1 or PKCS#8 PEM-encoded RSA private key
+func parseAppPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("app private key is not PEM-encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing app private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("app private key is not an RSA key")
+	}
+	return key, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"sync"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// countingTransport counts the requests sent through it
+type countingTransport struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.paths = append(t.paths, req.URL.Path)
+	t.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestAppTokenSourceUsesContextClient(t *testing.T) {
+	_, host := newFakeGitHub(t, nil)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := &countingTransport{}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: transport})
+	app := AppCredentials{AppID: 1, InstallationID: 42, PrivateKey: key}
+	src := NewAppTokenSource(ctx, app, restAPIBaseURL(host))
+
+	for i := 0; i < 2; i++ {
+		token, err := src.Token()
+		if err != nil {
+			t.Fatalf("Token: %v", err)
+		}
+		if token.AccessToken != "ghs_fakeinstallationtoken" {
+			t.Errorf("token = %q", token.AccessToken)
+		}
+	}
+
+	// The second token comes from the cache
+	if len(transport.paths) != 1 || transport.paths[0] != "/api/v3/app/installations/42/access_tokens" {
+		t.Errorf("requests through the context's client: %v, want one token request under /api/v3", transport.paths)
+	}
+}
+
+func TestAppCredentialsRequiresAllOptions(t *testing.T) {
+	appID, installationID := int64(1), int64(0)
+	key := ""
+	auth := AuthFlags{AppID: &appID, AppPrivateKey: &key, InstallationID: &installationID}
+
+	_, err := auth.AppCredentials()
+	if ErrorKindOf(err) != KindUsage || ExitCode(err) != ExitUsage {
+		t.Errorf("err = %v (exit code %d), want a usage error", err, ExitCode(err))
+	}
+}
@@ -7,6 +7,7 @@ toolchain go1.24.10
 require (
 	github.com/shurcooL/githubv4 v0.0.0-20240727222349-48295856cce7
 	golang.org/x/oauth2 v0.33.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/shurcooL/graphql v0.0.0-20230722043721-ed46e5a46466 // indirect
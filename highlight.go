@@ -0,0 +1,206 @@
+package main
+
+import (
+	"html"
+	"path/filepath"
+	"strings"
+)
+
+// syntax describes enough of a language family to highlight its keywords,
+// strings, comments and numbers. Code is highlighted a line at a time, since
+// diff hunks rarely show where a multi-line comment or string began.
+type syntax struct {
+	lineComments    []string
+	blockComments   bool // /* ... */
+	quotes          string
+	keywords        map[string]bool
+	caseInsensitive bool
+}
+
+// wordSet builds a keyword set from a space-separated list
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+var (
+	cLikeSyntax = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: true,
+		quotes:        "\"'`",
+		keywords: wordSet(`if else for while do switch case default break continue return goto
+			func function var let const type struct interface class enum package import export from
+			new delete this super nil null undefined true false void public private protected static
+			final abstract extends implements try catch finally throw throws async await yield
+			go defer chan map range select fn impl pub use mut match mod crate self trait where
+			in of typeof instanceof sizeof val fun object when override`),
+	}
+	hashSyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       "\"'`",
+		keywords: wordSet(`if elif else for while in not and or is return def class lambda import from as
+			with try except finally raise pass break continue yield global nonlocal assert del async await
+			None True False self nil true false end module require unless begin rescue ensure do then
+			fi done esac case function local export echo`),
+	}
+	dashSyntax = &syntax{
+		lineComments:    []string{"--"},
+		quotes:          "\"'",
+		caseInsensitive: true,
+		keywords: wordSet(`select from where insert update delete into values set join left right inner outer
+			on group by order having limit offset create table alter drop index view and or not null is
+			as distinct union all case when then else end begin commit rollback primary key references
+			local function return if elseif for while do in nil true false let where module import`),
+	}
+	cssSyntax = &syntax{
+		blockComments: true,
+		quotes:        "\"'",
+		keywords:      wordSet(`important inherit initial none auto`),
+	}
+)
+
+// syntaxByExtension picks the highlighting for a file by its extension
+var syntaxByExtension = map[string]*syntax{
+	".go": cLikeSyntax, ".js": cLikeSyntax, ".jsx": cLikeSyntax, ".mjs": cLikeSyntax, ".cjs": cLikeSyntax,
+	".ts": cLikeSyntax, ".tsx": cLikeSyntax, ".java": cLikeSyntax, ".kt": cLikeSyntax, ".kts": cLikeSyntax,
+	".scala": cLikeSyntax, ".c": cLikeSyntax, ".h": cLikeSyntax, ".cc": cLikeSyntax, ".cpp": cLikeSyntax,
+	".hpp": cLikeSyntax, ".cs": cLikeSyntax, ".rs": cLikeSyntax, ".swift": cLikeSyntax, ".php": cLikeSyntax,
+	".dart": cLikeSyntax, ".proto": cLikeSyntax,
+	".py": hashSyntax, ".rb": hashSyntax, ".sh": hashSyntax, ".bash": hashSyntax, ".zsh": hashSyntax,
+	".yaml": hashSyntax, ".yml": hashSyntax, ".toml": hashSyntax, ".pl": hashSyntax, ".r": hashSyntax,
+	".tf": hashSyntax, ".mk": hashSyntax,
+	".sql": dashSyntax, ".lua": dashSyntax, ".hs": dashSyntax,
+	".css": cssSyntax, ".scss": cssSyntax, ".less": cssSyntax,
+}
+
+// syntaxByName picks the highlighting for the language of a fenced code block
+var syntaxByName = map[string]*syntax{
+	"go": cLikeSyntax, "golang": cLikeSyntax, "js": cLikeSyntax, "javascript": cLikeSyntax,
+	"jsx": cLikeSyntax, "ts": cLikeSyntax, "typescript": cLikeSyntax, "tsx": cLikeSyntax,
+	"java": cLikeSyntax, "kotlin": cLikeSyntax, "scala": cLikeSyntax, "c": cLikeSyntax,
+	"cpp": cLikeSyntax, "c++": cLikeSyntax, "cs": cLikeSyntax, "csharp": cLikeSyntax,
+	"rust": cLikeSyntax, "rs": cLikeSyntax, "swift": cLikeSyntax, "php": cLikeSyntax,
+	"dart": cLikeSyntax, "proto": cLikeSyntax,
+	"python": hashSyntax, "py": hashSyntax, "ruby": hashSyntax, "rb": hashSyntax,
+	"sh": hashSyntax, "bash": hashSyntax, "shell": hashSyntax, "zsh": hashSyntax,
+	"yaml": hashSyntax, "yml": hashSyntax, "toml": hashSyntax, "perl": hashSyntax,
+	"r": hashSyntax, "hcl": hashSyntax, "terraform": hashSyntax, "dockerfile": hashSyntax, "makefile": hashSyntax,
+	"sql": dashSyntax, "lua": dashSyntax, "haskell": dashSyntax,
+	"css": cssSyntax, "scss": cssSyntax, "less": cssSyntax,
+}
+
+// syntaxForFile returns the highlighting for a file, or nil if its language
+// isn't known
+func syntaxForFile(path string) *syntax {
+	base := strings.ToLower(filepath.Base(path))
+	switch {
+	case base == "dockerfile" || strings.HasPrefix(base, "dockerfile.") || base == "makefile":
+		return hashSyntax
+	}
+	return syntaxByExtension[strings.ToLower(filepath.Ext(base))]
+}
+
+// syntaxForLanguage returns the highlighting for a fenced code block's
+// language, or nil if it isn't known
+func syntaxForLanguage(language string) *syntax {
+	return syntaxByName[strings.ToLower(language)]
+}
+
+// highlightCode escapes a line of code for HTML, wrapping keywords, strings,
+// comments and numbers in spans styled by the report's CSS. Without a syntax
+// the line is only escaped.
+func highlightCode(line string, lang *syntax) string {
+	if lang == nil {
+		return html.EscapeString(line)
+	}
+
+	var output strings.Builder
+	span := func(class, text string) {
+		output.WriteString(`<span class="` + class + `">` + html.EscapeString(text) + `</span>`)
+	}
+
+	for i := 0; i < len(line); {
+		rest := line[i:]
+
+		if lang.blockComments && strings.HasPrefix(rest, "/*") {
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				span("syn-comment", rest)
+				break
+			}
+			span("syn-comment", rest[:end+4])
+			i += end + 4
+			continue
+		}
+
+		isComment := false
+		for _, marker := range lang.lineComments {
+			if strings.HasPrefix(rest, marker) {
+				isComment = true
+			}
+		}
+		if isComment {
+			span("syn-comment", rest)
+			break
+		}
+
+		c := line[i]
+		switch {
+		case strings.IndexByte(lang.quotes, c) >= 0:
+			end := i + 1
+			for end < len(line) && line[end] != c {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				end = len(line) - 1
+			}
+			span("syn-string", line[i:end+1])
+			i = end + 1
+		case isDigit(c) && (i == 0 || !isWordByte(line[i-1])):
+			end := i
+			for end < len(line) && (isWordByte(line[end]) || line[end] == '.') {
+				end++
+			}
+			span("syn-number", line[i:end])
+			i = end
+		case isWordByte(c):
+			end := i
+			for end < len(line) && isWordByte(line[end]) {
+				end++
+			}
+			word := line[i:end]
+			key := word
+			if lang.caseInsensitive {
+				key = strings.ToLower(word)
+			}
+			if lang.keywords[key] {
+				span("syn-keyword", word)
+			} else {
+				output.WriteString(html.EscapeString(word))
+			}
+			i = end
+		default:
+			output.WriteString(html.EscapeString(line[i : i+1]))
+			i++
+		}
+	}
+
+	return output.String()
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isWordByte reports whether c can be part of an identifier. Bytes of
+// multi-byte UTF-8 characters count, so they are never split.
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 0x80 || isDigit(c) || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlightCode(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		lang *syntax
+		want string
+	}{
+		{
+			name: "unknown language is only escaped",
+			line: `if a < b { return "x" }`,
+			want: `if a &lt; b { return &#34;x&#34; }`,
+		},
+		{
+			name: "keywords and identifiers",
+			line: "func returnValue() {",
+			lang: cLikeSyntax,
+			want: `<span class="syn-keyword">func</span> returnValue() {`,
+		},
+		{
+			name: "strings with escaped quotes",
+			line: `s := "a \"b\" <c>"`,
+			lang: cLikeSyntax,
+			want: `s := <span class="syn-string">&#34;a \&#34;b\&#34; &lt;c&gt;&#34;</span>`,
+		},
+		{
+			name: "unterminated string runs to the end of the line",
+			line: `x = 'abc`,
+			lang: hashSyntax,
+			want: `x = <span class="syn-string">&#39;abc</span>`,
+		},
+		{
+			name: "numbers but not digits inside words",
+			line: "x2 := 0x1F + 3.5",
+			lang: cLikeSyntax,
+			want: `x2 := <span class="syn-number">0x1F</span> + <span class="syn-number">3.5</span>`,
+		},
+		{
+			name: "line comment",
+			line: "x := 1 // <done>",
+			lang: cLikeSyntax,
+			want: `x := <span class="syn-number">1</span> <span class="syn-comment">// &lt;done&gt;</span>`,
+		},
+		{
+			name: "block comment",
+			line: "/* a */ b",
+			lang: cLikeSyntax,
+			want: `<span class="syn-comment">/* a */</span> b`,
+		},
+		{
+			name: "case-insensitive keywords",
+			line: "Select id -- all",
+			lang: dashSyntax,
+			want: `<span class="syn-keyword">Select</span> id <span class="syn-comment">-- all</span>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightCode(tt.line, tt.lang); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyntaxForFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want *syntax
+	}{
+		{"cmd/main.go", cLikeSyntax},
+		{"scripts/Deploy.SH", hashSyntax},
+		{"build/Dockerfile.dev", hashSyntax},
+		{"Makefile", hashSyntax},
+		{"db/schema.sql", dashSyntax},
+		{"README.md", nil},
+	}
+
+	for _, tt := range tests {
+		if got := syntaxForFile(tt.path); got != tt.want {
+			t.Errorf("syntaxForFile(%q) picked the wrong syntax", tt.path)
+		}
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "escaped text",
+			body: `<script>alert("x")</script>`,
+			want: "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p>\n",
+		},
+		{
+			name: "inline markup",
+			body: "Use **`ctx`** and see [the docs](https://go.dev/doc), not [this](javascript:alert(1))",
+			want: "<p>Use **<code>ctx</code>** and see <a href=\"https://go.dev/doc\">the docs</a>, not [this](javascript:alert(1))</p>\n",
+		},
+		{
+			name: "highlighted fenced code",
+			body: "Try:\n```go\nreturn nil // done\n```",
+			want: "<p>Try:</p>\n<pre><code><span class=\"syn-keyword\">return</span> <span class=\"syn-keyword\">nil</span> <span class=\"syn-comment\">// done</span></code></pre>\n",
+		},
+		{
+			name: "fence of an unknown language",
+			body: "```\na < b\n```",
+			want: "<pre><code>a &lt; b</code></pre>\n",
+		},
+		{
+			name: "list and quote",
+			body: "> why?\n- one\n- two",
+			want: "<blockquote>why?</blockquote>\n<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderMarkdown(tt.body); got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteHTMLDiff(t *testing.T) {
+	line := 11
+	thread := ReviewThread{
+		File:     "cache.go",
+		LineNew:  &line,
+		DiffHunk: "@@ -10,2 +10,3 @@ func get() {\n \treturn \"<v>\"\n+\tif ok {\n-\tx := 1",
+	}
+
+	var builder strings.Builder
+	writeHTMLDiff(&builder, thread)
+	want := `<table class="diff">
+<tr class="hunk"><td class="num"></td><td class="num"></td><td>@@ -10,2 +10,3 @@ func get() {</td></tr>
+<tr><td class="num">10</td><td class="num">10</td><td> 	<span class="syn-keyword">return</span> <span class="syn-string">&#34;&lt;v&gt;&#34;</span></td></tr>
+<tr class="add commented"><td class="num"></td><td class="num">11</td><td>+	<span class="syn-keyword">if</span> ok {</td></tr>
+<tr class="del"><td class="num">11</td><td class="num"></td><td>-	x := <span class="syn-number">1</span></td></tr>
+</table>
+`
+	if got := builder.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultHost is the GitHub host used when --host isn't given
+const defaultHost = "github.com"
+
+// hostBaseURL returns the web base URL of a host. The host may be a bare
+// name like github.example.com or a URL such as http://localhost:8080.
+func hostBaseURL(host string) string {
+	host = strings.TrimSuffix(host, "/")
+	if host == "" {
+		host = defaultHost
+	}
+	if strings.Contains(host, "://") {
+		return host
+	}
+	return "https://" + host
+}
+
+// isDefaultHost reports whether a host is github.com
+func isDefaultHost(host string) bool {
+	return host == "" || strings.EqualFold(strings.TrimSuffix(host, "/"), defaultHost)
+}
+
+// restAPIBaseURL returns the REST API base URL of a host; GitHub Enterprise
+// Server serves it under /api/v3
+func restAPIBaseURL(host string) string {
+	if isDefaultHost(host) {
+		return "https://api.github.com"
+	}
+	return hostBaseURL(host) + "/api/v3"
+}
+
+// graphQLEndpoint returns the GraphQL API URL of a host
+func graphQLEndpoint(host string) string {
+	if isDefaultHost(host) {
+		return "https://api.github.com/graphql"
+	}
+	return hostBaseURL(host) + "/api/graphql"
+}
+
+// hostName returns a host without its scheme, e.g. for matching credentials
+func hostName(host string) string {
+	if host == "" {
+		return defaultHost
+	}
+	if _, rest, found := strings.Cut(host, "://"); found {
+		host = rest
+	}
+	return strings.TrimSuffix(host, "/")
+}
+
+// validateHost checks a --host value
+func validateHost(host string) error {
+	if strings.TrimSpace(host) == "" {
+		return fmt.Errorf("host must not be empty")
+	}
+	if strings.Contains(hostName(host), "/") {
+		return fmt.Errorf("invalid host %q (expected a host name like github.example.com)", host)
+	}
+	return nil
+}
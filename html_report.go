@@ -0,0 +1,424 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// htmlReportCSS styles the self-contained HTML report; no external assets are loaded
+const htmlReportCSS = `
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; max-width: 1100px; margin: 0 auto; padding: 24px; line-height: 1.5; }
+header { border-bottom: 1px solid #d0d7de; margin-bottom: 16px; }
+h1 { font-size: 1.6em; margin: 0 0 4px; }
+.repo { color: #59636e; margin: 0 0 12px; }
+.stats { display: flex; flex-wrap: wrap; gap: 12px; margin: 16px 0; padding: 0; list-style: none; }
+.stats li { border: 1px solid #d0d7de; border-radius: 6px; padding: 8px 14px; background: #f6f8fa; }
+.stats .value { display: block; font-size: 1.4em; font-weight: 600; }
+.meta { color: #59636e; font-size: 0.9em; }
+details.file { border: 1px solid #d0d7de; border-radius: 6px; margin: 12px 0; }
+details.file > summary { cursor: pointer; padding: 8px 12px; background: #f6f8fa; font-family: ui-monospace, SFMono-Regular, Menlo, monospace; }
+.thread { border-top: 1px solid #d0d7de; padding: 12px; }
+.thread-header { margin-bottom: 8px; font-weight: 600; }
+.badge { display: inline-block; border-radius: 2em; padding: 0 8px; font-size: 0.8em; font-weight: 600; margin-right: 4px; border: 1px solid transparent; }
+.badge.unresolved { background: #fff8c5; border-color: #d4a72c; color: #7d4e00; }
+.badge.resolved { background: #dafbe1; border-color: #4ac26b; color: #116329; }
+.badge.outdated { background: #eaeef2; border-color: #afb8c1; color: #424a53; }
+.badge.category { background: #ddf4ff; border-color: #54aeff; color: #0550ae; }
+.badge.category.blocking { background: #ffebe9; border-color: #ff8182; color: #a40e26; }
+table.diff { border-collapse: collapse; width: 100%; font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 12px; margin-bottom: 12px; border: 1px solid #d0d7de; }
+table.diff td { padding: 0 8px; white-space: pre-wrap; word-break: break-all; vertical-align: top; }
+table.diff td.num { color: #59636e; text-align: right; width: 1%; user-select: none; }
+tr.hunk td { background: #ddf4ff; color: #59636e; }
+tr.add td { background: #e6ffec; }
+tr.del td { background: #ffebe9; }
+tr.commented td { background: #fff8c5; font-weight: 600; }
+tr.commented td.num { box-shadow: inset 3px 0 0 #d4a72c; }
+.syn-keyword { color: #cf222e; }
+.syn-string { color: #0a3069; }
+.syn-comment { color: #6e7781; font-style: italic; }
+.syn-number { color: #0550ae; }
+.comment { margin: 8px 0; padding: 8px 12px; border-left: 3px solid #d0d7de; }
+.comment.reply { margin-left: 24px; }
+.comment .author { font-weight: 600; }
+.comment pre { background: #f6f8fa; padding: 8px; border-radius: 6px; overflow-x: auto; }
+.comment code { background: #eff1f3; padding: 0 4px; border-radius: 4px; font-size: 0.9em; }
+.comment pre code { background: none; padding: 0; }
+.comment blockquote { margin: 0; padding-left: 12px; border-left: 3px solid #d0d7de; color: #59636e; }
+.empty { color: #116329; font-weight: 600; }
+`
+
+// diffHunkHeaderRegex extracts the starting line numbers from a diff hunk header
+var diffHunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// Inline markdown patterns, applied to already-escaped text
+var (
+	markdownCodeRegex   = regexp.MustCompile("`([^`]+)`")
+	markdownLinkRegex   = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^\s)]+)\)`)
+	markdownBoldRegex   = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	markdownItalicRegex = regexp.MustCompile(`(^|[^*\w])[*_]([^*_]+)[*_]([^*\w]|$)`)
+)
+
+// formatHTMLV2 outputs a self-contained HTML report of the review threads
+func formatHTMLV2(response *PRCommentsResponse, opts FormatOptions) (string, error) {
+	var output strings.Builder
+
+	title := fmt.Sprintf("PR #%d Review Comments", response.PRNumber)
+	repo := fmt.Sprintf("%s/%s", response.Owner, response.Repo)
+
+	output.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	output.WriteString(fmt.Sprintf("<title>%s (%s)</title>\n", html.EscapeString(title), html.EscapeString(repo)))
+	output.WriteString("<style>" + htmlReportCSS + "</style>\n</head>\n<body>\n")
+
+	output.WriteString("<header>\n")
+	output.WriteString(fmt.Sprintf("<h1>%s</h1>\n", html.EscapeString(title)))
+	output.WriteString(fmt.Sprintf("<p class=\"repo\">%s</p>\n", html.EscapeString(repo)))
+	output.WriteString("</header>\n")
+
+	writeHTMLSummary(&output, response.Summary)
+
+	if len(response.GeneralComments) > 0 {
+		output.WriteString("<h2>General PR Discussion</h2>\n")
+		for _, comment := range response.GeneralComments {
+			writeHTMLComment(&output, comment.Author, comment.CreatedAt, comment.HTMLURL, comment.Body, false)
+		}
+	}
+
+	if len(response.ReviewThreads) == 0 {
+		if len(response.GeneralComments) == 0 {
+			output.WriteString("<p class=\"empty\">No review comments to address</p>\n")
+		}
+		output.WriteString("</body>\n</html>\n")
+		return output.String(), nil
+	}
+
+	output.WriteString("<h2>Review Threads</h2>\n")
+
+	// Group threads by file, keeping the files in the order of their first thread
+	fileThreads := make(map[string][]ReviewThread)
+	var files []string
+	for _, thread := range SortThreads(response.ReviewThreads, opts.Sort, opts.ReviewerWeights) {
+		if _, seen := fileThreads[thread.File]; !seen {
+			files = append(files, thread.File)
+		}
+		fileThreads[thread.File] = append(fileThreads[thread.File], thread)
+	}
+	if opts.Sort == "" || opts.Sort == SortFile {
+		sort.Strings(files)
+	}
+
+	for _, file := range files {
+		threads := fileThreads[file]
+		output.WriteString("<details class=\"file\" open>\n")
+		output.WriteString(fmt.Sprintf("<summary>%s <span class=\"meta\">(%d %s)</span></summary>\n",
+			html.EscapeString(file), len(threads), pluralize(len(threads), "thread", "threads")))
+
+		for _, thread := range threads {
+			writeHTMLThread(&output, thread)
+		}
+		output.WriteString("</details>\n")
+	}
+
+	output.WriteString("</body>\n</html>\n")
+	return output.String(), nil
+}
+
+// writeHTMLSummary renders the summary statistics
+func writeHTMLSummary(builder *strings.Builder, summary CommentsSummary) {
+	builder.WriteString("<ul class=\"stats\">\n")
+
+	stat := func(label string, value int) {
+		builder.WriteString(fmt.Sprintf("<li><span class=\"value\">%d</span>%s</li>\n", value, label))
+	}
+	stat("Unresolved threads", summary.UnresolvedThreads)
+	stat("Resolved threads", summary.ResolvedThreads)
+	if summary.OutdatedThreads > 0 {
+		stat("Outdated threads", summary.OutdatedThreads)
+	}
+	if summary.GeneralComments > 0 {
+		stat("General comments", summary.GeneralComments)
+	}
+	stat("Comments", summary.TotalComments)
+	stat("Files", len(summary.FilesAffected))
+	builder.WriteString("</ul>\n")
+
+	if len(summary.Authors) > 0 {
+		builder.WriteString(fmt.Sprintf("<p class=\"meta\">Reviewers: %s</p>\n", html.EscapeString(strings.Join(summary.Authors, ", "))))
+	}
+	if len(summary.Categories) > 0 {
+		builder.WriteString(fmt.Sprintf("<p class=\"meta\">By category: %s</p>\n", html.EscapeString(formatCategoryCounts(summary.Categories))))
+	}
+	if len(summary.FiltersApplied) > 0 {
+		builder.WriteString(fmt.Sprintf("<p class=\"meta\">Filters applied: %s</p>\n", html.EscapeString(strings.Join(summary.FiltersApplied, ", "))))
+	}
+}
+
+// writeHTMLThread renders a single review thread with its badges, diff and conversation
+func writeHTMLThread(builder *strings.Builder, thread ReviewThread) {
+	builder.WriteString(fmt.Sprintf("<div class=\"thread\" id=\"%s\">\n", html.EscapeString(thread.ID)))
+	builder.WriteString("<div class=\"thread-header\">")
+
+	if thread.IsResolved {
+		builder.WriteString("<span class=\"badge resolved\">Resolved</span>")
+	} else {
+		builder.WriteString("<span class=\"badge unresolved\">Unresolved</span>")
+	}
+	if thread.IsOutdated {
+		builder.WriteString("<span class=\"badge outdated\">Outdated</span>")
+	}
+	if thread.Category != "" {
+		builder.WriteString(fmt.Sprintf("<span class=\"badge category %s\">%s</span>",
+			html.EscapeString(thread.Category), html.EscapeString(thread.Category)))
+	}
+
+	if thread.LineNew != nil {
+		builder.WriteString(fmt.Sprintf(" Line %d", *thread.LineNew))
+	} else if thread.LineOld != nil {
+		builder.WriteString(fmt.Sprintf(" Line %d (original)", *thread.LineOld))
+	} else {
+		builder.WriteString(" General file comment")
+	}
+	builder.WriteString("</div>\n")
+
+	writeHTMLDiff(builder, thread)
+
+	for _, comment := range thread.Comments {
+		writeHTMLComment(builder, comment.Author, comment.CreatedAt, comment.HTMLURL, comment.Body, comment.IsReply)
+	}
+
+	builder.WriteString("</div>\n")
+}
+
+// writeHTMLComment renders a comment with its markdown body
+func writeHTMLComment(builder *strings.Builder, author, createdAt, url, body string, isReply bool) {
+	class := "comment"
+	if isReply {
+		class += " reply"
+	}
+
+	builder.WriteString(fmt.Sprintf("<div class=\"%s\">\n", class))
+	builder.WriteString(fmt.Sprintf("<div><span class=\"author\">%s</span> <span class=\"meta\">%s", html.EscapeString(author), html.EscapeString(createdAt)))
+	if url != "" {
+		builder.WriteString(fmt.Sprintf(" · <a href=\"%s\">View on GitHub</a>", html.EscapeString(url)))
+	}
+	builder.WriteString("</span></div>\n")
+	builder.WriteString(renderMarkdown(body))
+	builder.WriteString("</div>\n")
+}
+
+// writeHTMLDiff renders a thread's diff hunk as a syntax-highlighted table,
+// emphasising the commented lines
+func writeHTMLDiff(builder *strings.Builder, thread ReviewThread) {
+	trimmed := strings.TrimRight(thread.DiffHunk, "\n")
+	if strings.TrimSpace(trimmed) == "" {
+		return
+	}
+	lines := strings.Split(trimmed, "\n")
+
+	// The commented range is on the new side unless the comment is on a removed line
+	useOld := thread.LineNew == nil && thread.LineOld != nil
+	start, end := 0, 0
+	switch {
+	case useOld:
+		end = *thread.LineOld
+		start = end
+		if thread.StartLineOld != nil {
+			start = *thread.StartLineOld
+		}
+	case thread.LineNew != nil:
+		end = *thread.LineNew
+		start = end
+		if thread.StartLineNew != nil {
+			start = *thread.StartLineNew
+		}
+	}
+
+	lang := syntaxForFile(thread.File)
+	builder.WriteString("<table class=\"diff\">\n")
+
+	oldLine, newLine := 0, 0
+	for i, line := range lines {
+		class := ""
+		oldNum, newNum := "", ""
+
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			class = "hunk"
+			if matches := diffHunkHeaderRegex.FindStringSubmatch(line); matches != nil {
+				oldLine, _ = strconv.Atoi(matches[1])
+				newLine, _ = strconv.Atoi(matches[2])
+			}
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+			newNum = strconv.Itoa(newLine)
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			class = "del"
+			oldNum = strconv.Itoa(oldLine)
+			oldLine++
+		default:
+			oldNum = strconv.Itoa(oldLine)
+			newNum = strconv.Itoa(newLine)
+			oldLine++
+			newLine++
+		}
+
+		if class != "hunk" && isCommentedDiffLine(oldNum, newNum, useOld, start, end, i == len(lines)-1) {
+			class = strings.TrimSpace(class + " commented")
+		}
+
+		if class != "" {
+			builder.WriteString(fmt.Sprintf("<tr class=\"%s\">", class))
+		} else {
+			builder.WriteString("<tr>")
+		}
+		code := html.EscapeString(line)
+		if class != "hunk" && line != "" {
+			code = html.EscapeString(line[:1]) + highlightCode(line[1:], lang)
+		}
+		builder.WriteString(fmt.Sprintf("<td class=\"num\">%s</td><td class=\"num\">%s</td><td>%s</td></tr>\n",
+			oldNum, newNum, code))
+	}
+
+	builder.WriteString("</table>\n")
+}
+
+// isCommentedDiffLine reports whether a diff line falls in the commented range.
+// Without line information, GitHub's convention of ending the hunk at the
+// commented line is used.
+func isCommentedDiffLine(oldNum, newNum string, useOld bool, start, end int, last bool) bool {
+	if end == 0 {
+		return last
+	}
+
+	num := newNum
+	if useOld {
+		num = oldNum
+	}
+	if num == "" {
+		return false
+	}
+
+	line, _ := strconv.Atoi(num)
+	return line >= start && line <= end
+}
+
+// renderMarkdown converts the subset of GitHub markdown common in review
+// comments (fenced code, headings, lists, quotes, inline code, links,
+// emphasis) to HTML. Everything else is escaped and kept as text.
+func renderMarkdown(body string) string {
+	var output strings.Builder
+
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	var paragraph []string
+	inList := false
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			output.WriteString("<p>" + strings.Join(paragraph, "<br>\n") + "</p>\n")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if inList {
+			output.WriteString("</ul>\n")
+			inList = false
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flushParagraph()
+			closeList()
+
+			language := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+
+			lang := syntaxForLanguage(language)
+			highlighted := make([]string, len(code))
+			for j, codeLine := range code {
+				highlighted[j] = highlightCode(codeLine, lang)
+			}
+			output.WriteString("<pre><code>")
+			output.WriteString(strings.Join(highlighted, "\n"))
+			output.WriteString("</code></pre>\n")
+		case trimmed == "":
+			flushParagraph()
+			closeList()
+		case strings.HasPrefix(trimmed, "#"):
+			flushParagraph()
+			closeList()
+
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			if level > 6 {
+				level = 6
+			}
+			// Comment headings sit below the report's own h1-h3
+			tag := level + 3
+			if tag > 6 {
+				tag = 6
+			}
+			output.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", tag, renderInlineMarkdown(strings.TrimSpace(strings.TrimLeft(trimmed, "#"))), tag))
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			flushParagraph()
+			if !inList {
+				output.WriteString("<ul>\n")
+				inList = true
+			}
+			output.WriteString("<li>" + renderInlineMarkdown(trimmed[2:]) + "</li>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			closeList()
+			output.WriteString("<blockquote>" + renderInlineMarkdown(strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))) + "</blockquote>\n")
+		default:
+			closeList()
+			paragraph = append(paragraph, renderInlineMarkdown(trimmed))
+		}
+	}
+
+	flushParagraph()
+	closeList()
+
+	return output.String()
+}
+
+// renderInlineMarkdown escapes a line and renders inline code, links and emphasis
+func renderInlineMarkdown(text string) string {
+	// Split out code spans first so their contents aren't formatted
+	var output strings.Builder
+	last := 0
+	for _, loc := range markdownCodeRegex.FindAllStringIndex(text, -1) {
+		output.WriteString(renderInlineText(text[last:loc[0]]))
+		output.WriteString("<code>" + html.EscapeString(text[loc[0]+1:loc[1]-1]) + "</code>")
+		last = loc[1]
+	}
+	output.WriteString(renderInlineText(text[last:]))
+	return output.String()
+}
+
+// renderInlineText escapes text outside code spans and renders links and emphasis
+func renderInlineText(text string) string {
+	escaped := html.EscapeString(text)
+	escaped = markdownLinkRegex.ReplaceAllString(escaped, `<a href="$2">$1</a>`)
+	escaped = markdownBoldRegex.ReplaceAllString(escaped, "<strong>$1</strong>")
+	escaped = markdownItalicRegex.ReplaceAllString(escaped, "$1<em>$2</em>$3")
+	return escaped
+}
+
+// pluralize picks the singular or plural form of a word for a count
+func pluralize(count int, singular, plural string) string {
+	if count == 1 {
+		return singular
+	}
+	return plural
+}
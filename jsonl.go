@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// JSON Lines event types
+const (
+	EventThread         = "thread"
+	EventGeneralComment = "general_comment"
+	EventSummary        = "summary"
+	EventError          = "error"
+)
+
+// JSONLWriter streams JSON Lines events as threads and comments are fetched.
+// It is safe for concurrent use by several PR fetches.
+type JSONLWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+	err error
+}
+
+// NewJSONLWriter creates a JSON Lines event writer
+func NewJSONLWriter(out io.Writer) *JSONLWriter {
+	return &JSONLWriter{out: out}
+}
+
+// Observe returns fetch options that stream the PR's threads and general
+// comments as they pass the filters
+func (w *JSONLWriter) Observe(ref PRRef, opts FetchOptions) FetchOptions {
+	opts.OnThread = func(thread ReviewThread) {
+		w.write(newJSONLEvent(EventThread, ref, func(event *JSONLEvent) { event.Thread = &thread }))
+	}
+	opts.OnGeneralComment = func(comment GeneralComment) {
+		w.write(newJSONLEvent(EventGeneralComment, ref, func(event *JSONLEvent) { event.Comment = &comment }))
+	}
+	return opts
+}
+
+// Finish writes the PR's final summary record, or an error record if the fetch failed
+func (w *JSONLWriter) Finish(ref PRRef, response *PRCommentsResponse, err error) {
+	if err != nil {
+		w.write(newJSONLEvent(EventError, ref, func(event *JSONLEvent) { event.Error = err.Error() }))
+		return
+	}
+	w.write(newJSONLEvent(EventSummary, ref, func(event *JSONLEvent) { event.Summary = &response.Summary }))
+}
+
+// Err returns the first error writing an event, if any
+func (w *JSONLWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// write encodes an event as a single line
+func (w *JSONLWriter) write(event JSONLEvent) {
+	data, err := json.Marshal(event)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return
+	}
+	if err != nil {
+		w.err = fmt.Errorf("marshaling JSON: %w", err)
+		return
+	}
+	if _, err := w.out.Write(append(data, '\n')); err != nil {
+		w.err = fmt.Errorf("writing output: %w", err)
+	}
+}
+
+// newJSONLEvent creates an event for a PR and lets the caller fill in its payload
+func newJSONLEvent(eventType string, ref PRRef, fill func(*JSONLEvent)) JSONLEvent {
+	event := JSONLEvent{
+		Type:     eventType,
+		Owner:    ref.Owner,
+		Repo:     ref.Repo,
+		PRNumber: ref.Number,
+	}
+	fill(&event)
+	return event
+}
+
+// formatJSONLV2 outputs an already fetched response as JSON Lines events,
+// as --format jsonl does when writing to files
+func formatJSONLV2(response *PRCommentsResponse) (string, error) {
+	var output strings.Builder
+
+	writer := NewJSONLWriter(&output)
+	ref := PRRef{Owner: response.Owner, Repo: response.Repo, Number: response.PRNumber}
+	opts := writer.Observe(ref, FetchOptions{})
+
+	for _, thread := range response.ReviewThreads {
+		opts.OnThread(thread)
+	}
+	for _, comment := range response.GeneralComments {
+		opts.OnGeneralComment(comment)
+	}
+	writer.Finish(ref, response, nil)
+
+	if err := writer.Err(); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"pr-review-cli/fakegithub"
+)
+
+// jsonlRecord is the shape of a JSON Lines record as consumers decode it
+type jsonlRecord struct {
+	Type     string                     `json:"type"`
+	Owner    string                     `json:"owner"`
+	Repo     string                     `json:"repo"`
+	PRNumber int                        `json:"pr_number"`
+	Thread   map[string]json.RawMessage `json:"thread"`
+	Comment  map[string]json.RawMessage `json:"comment"`
+	Summary  map[string]json.RawMessage `json:"summary"`
+	Error    string                     `json:"error"`
+}
+
+// readJSONL decodes one record per line, failing on any line that isn't a JSON object
+func readJSONL(t *testing.T, output string) []jsonlRecord {
+	t.Helper()
+	var records []jsonlRecord
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		var record jsonlRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestJSONLStreamsPagesInOrder(t *testing.T) {
+	_, host := newFakeGitHub(t, func(s *fakegithub.Server) { s.PageSize = 1 })
+	client := NewGitHubGraphQLClientFromTokenSource(context.Background(), tokenSource("test"), host)
+
+	var output strings.Builder
+	writer := NewJSONLWriter(&output)
+	ref := PRRef{Owner: "octo-org", Repo: "widgets", Number: 1}
+	opts := FetchOptions{IncludeResolved: true, IncludeOutdated: true, IncludeGeneral: true}
+
+	response, err := fetchGraphQLResponse(context.Background(), client, ref, writer.Observe(ref, opts))
+	writer.Finish(ref, response, err)
+	if err != nil || writer.Err() != nil {
+		t.Fatalf("fetch: %v, write: %v", err, writer.Err())
+	}
+
+	records := readJSONL(t, output.String())
+	var types []string
+	for _, record := range records {
+		types = append(types, record.Type)
+		if record.Owner != "octo-org" || record.Repo != "widgets" || record.PRNumber != 1 {
+			t.Errorf("%s record for %s/%s#%d", record.Type, record.Owner, record.Repo, record.PRNumber)
+		}
+	}
+	want := "thread,thread,thread,general_comment,general_comment,summary"
+	if got := strings.Join(types, ","); got != want {
+		t.Fatalf("records = %s, want %s", got, want)
+	}
+
+	// Each record carries only its own payload
+	for _, record := range records {
+		payloads := 0
+		for _, present := range []bool{record.Thread != nil, record.Comment != nil, record.Summary != nil, record.Error != ""} {
+			if present {
+				payloads++
+			}
+		}
+		if payloads != 1 {
+			t.Errorf("%s record has %d payloads", record.Type, payloads)
+		}
+	}
+	if string(records[0].Thread["file"]) != `"cache.go"` || records[0].Thread["comments"] == nil {
+		t.Errorf("first thread = %v", records[0].Thread)
+	}
+	if string(records[5].Summary["unresolved_threads"]) != "2" {
+		t.Errorf("summary = %v", records[5].Summary)
+	}
+
+	// Formatting the fetched response gives the same records
+	formatted, err := formatJSONLV2(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formatted != output.String() {
+		t.Errorf("formatJSONLV2:\n%s\nstreamed:\n%s", formatted, output.String())
+	}
+}
+
+func TestJSONLBatchRecords(t *testing.T) {
+	_, host := newFakeGitHub(t, nil)
+	client := NewGitHubGraphQLClientFromTokenSource(context.Background(), tokenSource("test"), host)
+
+	var output strings.Builder
+	writer := NewJSONLWriter(&output)
+	refs := []PRRef{
+		{Owner: "octo-org", Repo: "widgets", Number: 1},
+		{Owner: "octo-org", Repo: "widgets", Number: 99},
+	}
+	FetchBatch(context.Background(), refs, 2, func(ctx context.Context, ref PRRef) (*PRCommentsResponse, error) {
+		response, err := fetchGraphQLResponse(ctx, client, ref, writer.Observe(ref, FetchOptions{}))
+		writer.Finish(ref, response, err)
+		return response, err
+	}, nil)
+
+	// Records of concurrent fetches interleave, but each PR's records end with its summary or error
+	byPR := make(map[int][]string)
+	for _, record := range readJSONL(t, output.String()) {
+		byPR[record.PRNumber] = append(byPR[record.PRNumber], record.Type)
+		if record.Type == EventError && !strings.Contains(record.Error, "99") {
+			t.Errorf("error record = %q", record.Error)
+		}
+	}
+	var got []string
+	for number, types := range byPR {
+		got = append(got, fmt.Sprintf("%d:%s", number, strings.Join(types, ":")))
+	}
+	sort.Strings(got)
+	if want := "1:thread:summary,99:error"; strings.Join(got, ",") != want {
+		t.Errorf("records = %s, want %s", strings.Join(got, ","), want)
+	}
+}
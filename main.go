@@ -2,9 +2,21 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"log"
+	"net"
+	"net/http"
 	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"pr-review-cli/fakegithub"
 )
 
 func main() {
@@ -18,6 +30,18 @@ func main() {
 	switch command {
 	case "fetch":
 		handleFetch(os.Args[2:])
+	case "list":
+		handleList(os.Args[2:])
+	case "search":
+		handleSearch(os.Args[2:])
+	case "schema":
+		handleSchema(os.Args[2:])
+	case "auth":
+		handleAuth(os.Args[2:])
+	case "config":
+		handleConfig(os.Args[2:])
+	case "dev":
+		handleDev(os.Args[2:])
 	case "help", "--help", "-h":
 		printUsage()
 	default:
@@ -33,9 +57,24 @@ func handleFetch(args []string) {
 	// Basic flags
 	owner := fetchCmd.String("owner", "", "GitHub repository owner")
 	repo := fetchCmd.String("repo", "", "GitHub repository name")
-	prNumber := fetchCmd.Int("pr", 0, "Pull request number")
-	format := fetchCmd.String("format", "claude", "Output format: json, human, claude")
-	token := fetchCmd.String("token", "", "GitHub personal access token (optional if GITHUB_TOKEN env var is set)")
+	var prRefs stringListFlag
+	fetchCmd.Var(&prRefs, "pr", "Pull request number, OWNER/REPO#NUMBER or PR URL (repeatable, comma-separated)")
+	format := fetchCmd.String("format", "claude", "Output format: json, jsonl, human, claude, checklist, html, csv, tsv, template")
+	auth := RegisterAuthFlags(fetchCmd)
+	traffic := RegisterTrafficFlags(fetchCmd)
+	fetchCmd.String("profile", "", "Apply this named preset from ~/.config/pr-review-cli/profiles.yaml")
+
+	// Batch flags
+	prFile := fetchCmd.String("pr-file", "", "Read PR references from a file, one per line (- for stdin)")
+	concurrency := fetchCmd.Int("concurrency", 4, "Number of PRs to fetch in parallel when fetching several")
+	outputDir := fetchCmd.String("output-dir", "", "Write one output file per PR into this directory")
+	stream := fetchCmd.Bool("stream", false, "With --format json and several PRs, print one JSON line per PR as it completes instead of an array")
+	jsonVersion := fetchCmd.Int("json-version", DefaultJSONVersion, "Shape of the json format to write, to pin it across releases (1 or 2; see the schema command)")
+	outTodo := fetchCmd.String("out-todo", "", "Also write the threads as a task list into this file, e.g. REVIEW_TODO.md, keeping boxes already ticked there")
+	errorFormat := fetchCmd.String("error-format", ErrorFormatText, "How errors are written to stderr: text, or json for one machine-readable object per error")
+	requireOpen := fetchCmd.Bool("require-open", false, "Fail with exit code 5 if a PR is closed or merged")
+	failOn := fetchCmd.String("fail-on", "", "Exit with code 9 if the result still has feedback of this kind: unresolved, blocking or any (threads and general comments)")
+	maxUnresolved := fetchCmd.Int("max-unresolved", -1, "With --fail-on, how much of that feedback is allowed per PR before failing (implies --fail-on unresolved; default 0)")
 
 	// GraphQL flags
 	useGraphQL := fetchCmd.Bool("graphql", true, "Use GraphQL API (default: true, set to false for legacy REST)")
@@ -43,13 +82,47 @@ func handleFetch(args []string) {
 	includeOutdated := fetchCmd.Bool("include-outdated", false, "Include outdated review threads (default: exclude outdated)")
 	includeGeneral := fetchCmd.Bool("include-general", false, "Include general PR comments (default: only review threads)")
 
+	// Filter flags
+	var authors, excludeAuthors, threadStartedBy stringListFlag
+	fetchCmd.Var(&authors, "author", "Only threads with a comment by this user (repeatable, comma-separated)")
+	fetchCmd.Var(&excludeAuthors, "exclude-author", "Drop comments by this user, and threads they started (repeatable, comma-separated)")
+	excludeBots := fetchCmd.Bool("exclude-bots", false, "Drop comments by bot accounts, and threads they started")
+	fetchCmd.Var(&threadStartedBy, "thread-started-by", "Only threads whose first comment is by this user (repeatable, comma-separated)")
+	var paths, excludePaths, codeownersTeams stringListFlag
+	fetchCmd.Var(&paths, "path", "Only threads on files matching this glob, ** matches across directories (repeatable, comma-separated)")
+	fetchCmd.Var(&excludePaths, "exclude-path", "Drop threads on files matching this glob (repeatable, comma-separated)")
+	needsResponseFrom := fetchCmd.String("needs-response-from", "", "Only unresolved threads where someone other than this user (@me for yourself) spoke last")
+	onlyInvolved := fetchCmd.Bool("only-involved", false, "With --needs-response-from, only threads on the user's own PR, or that they took part in or were @mentioned in")
+	grep := fetchCmd.String("grep", "", "Only threads with a comment matching this regular expression")
+	grepFixed := fetchCmd.Bool("grep-fixed", false, "Treat --grep as a fixed string instead of a regular expression")
+	grepIgnoreCase := fetchCmd.Bool("i", false, "Match --grep case-insensitively")
+	grepDiff := fetchCmd.Bool("grep-diff", false, "Also match --grep against the threads' diff hunks")
+	var categories stringListFlag
+	fetchCmd.Var(&categories, "category", "Only threads of this intent: blocking, question, suggestion, nit, praise, comment (repeatable, comma-separated)")
+	categoryRules := fetchCmd.String("category-rules", "", "JSON file with extra rules for classifying comments by intent")
+	since := fetchCmd.String("since", "", "Only threads from this time on: RFC3339 timestamp, YYYY-MM-DD date, or duration ago like 2h, 3d")
+	until := fetchCmd.String("until", "", "Only threads up to this time: RFC3339 timestamp, YYYY-MM-DD date, or duration ago like 2h, 3d")
+	timeField := fetchCmd.String("time-field", "created", "Time matched by --since/--until: created (first comment) or activity (latest comment)")
+	sinceCommit := fetchCmd.String("since-commit", "", "Only threads since this commit's timestamp, e.g. your last pushed SHA")
+	sortMode := fetchCmd.String("sort", "", "Thread order: file, priority (blocking first, then reviewer weight, then age), age, activity or reviewer")
+	var reviewerWeightFlags stringListFlag
+	fetchCmd.Var(&reviewerWeightFlags, "reviewer-weight", "Rank threads from this reviewer higher with --sort priority, as LOGIN=WEIGHT (repeatable, comma-separated)")
+	var columns stringListFlag
+	fetchCmd.Var(&columns, "columns", "Columns of the csv and tsv formats, in order (comma-separated; default thread_id,file,line,resolved,outdated,author,created_at,is_reply,body,url)")
+	layout := fetchCmd.String("layout", LayoutFiles, "Claude format layout: files (per-file sections) or ranked (a single ranked task list)")
+	templatePath := fetchCmd.String("template", "", "Go text/template file that renders each PR with --format template")
+	fetchCmd.Var(&codeownersTeams, "codeowners-team", "Only threads on files this team owns per the repo's CODEOWNERS, e.g. @org/team (repeatable, comma-separated)")
+
 	fetchCmd.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s fetch --owner OWNER --repo REPO --pr PR_NUMBER [OPTIONS]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s fetch --owner OWNER --repo REPO --pr PR_NUMBER [OPTIONS] [PR_REF...]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Fetch and parse GitHub PR review comments.\n\n")
+		fmt.Fprintf(os.Stderr, "PR_REF may be a PR number (with --owner and --repo), OWNER/REPO#NUMBER or a PR URL.\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		fetchCmd.PrintDefaults()
-		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
-		fmt.Fprintf(os.Stderr, "  GITHUB_TOKEN    GitHub personal access token (not required if --token is used)\n")
+		fmt.Fprintf(os.Stderr, "\nCredentials (tried in order):\n")
+		fmt.Fprint(os.Stderr, credentialSourcesHelp)
+		fmt.Fprintf(os.Stderr, "\nExit codes:\n")
+		fmt.Fprint(os.Stderr, exitCodesHelp)
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  # Basic usage (GraphQL, unresolved threads only)\n")
 		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2\n\n", os.Args[0])
@@ -59,118 +132,1047 @@ func handleFetch(args []string) {
 		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --include-outdated\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  # Include general PR comments\n")
 		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --include-general\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Only feedback from humans, ignoring Dependabot, Codecov and other bots\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --exclude-bots\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Only feedback on files owned by your team\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --codeowners-team @org/team\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Threads mentioning a TODO, highlighted\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --grep todo -i --format human\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Only blocking feedback and open questions\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --category blocking,question\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Most important feedback first, as a single task list\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --sort priority --reviewer-weight maintainer=10 --layout ranked\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Keep a persistent worklist, preserving boxes you've ticked\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --format checklist --out-todo REVIEW_TODO.md\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Self-contained HTML report for reviewers without GitHub access\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --include-resolved --format html > review.html\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # One row per comment for spreadsheets, across several PRs\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2,3 --include-resolved --format csv --columns repo,pr,file,line,author,created_at,body > comments.csv\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Stream one JSON object per thread as it is fetched\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --format jsonl | jq -c 'select(.type == \"thread\")'\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Render with your own template\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --format template --template ~/.config/pr-review-cli/review.tmpl\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Pin the json output shape across releases\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --format json --json-version 2\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Use a preset from ~/.config/pr-review-cli/profiles.yaml\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --profile triage\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Threads waiting on your reply\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --needs-response-from @me\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Feedback that came in since your last push\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --since-commit $(git rev-parse HEAD) --time-field activity\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Fetch several PRs at once\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2,3 AObuchow/Sample-Commander#1 --format json\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Fetch PRs listed in a file, one output file per PR\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --pr-file prs.txt --output-dir review-comments\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Fail a CI job while blocking feedback is unresolved\n")
+		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --fail-on blocking\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  # Use legacy REST API\n")
 		fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --graphql=false\n", os.Args[0])
 	}
 
-	if err := fetchCmd.Parse(args); err != nil {
+	positional, err := parseInterleaved(fetchCmd, args)
+	if err != nil {
 		os.Exit(1)
 	}
+	given := givenFlags(fetchCmd)
+	applyConfig(fetchCmd, "fetch", auth, fetchRepoHint(*owner, *repo, prRefs, positional))
 
-	// Validate required arguments
-	if *owner == "" || *repo == "" || *prNumber == 0 {
-		fmt.Fprintf(os.Stderr, "Error: --owner, --repo, and --pr are required\n\n")
-		fetchCmd.Usage()
-		os.Exit(1)
+	// Errors are reported as text or JSON from here on
+	if !isValidErrorFormat(*errorFormat) {
+		fmt.Fprintf(os.Stderr, "Error: Invalid error format '%s'. Must be one of: text, json\n", *errorFormat)
+		os.Exit(ExitUsage)
 	}
+	errs := NewErrorReporter(*errorFormat)
 
-	// Validate token availability
-	if *token == "" && os.Getenv("GITHUB_TOKEN") == "" {
-		fmt.Fprintf(os.Stderr, "Error: GitHub token is required. Provide via --token flag or GITHUB_TOKEN environment variable\n\n")
-		fetchCmd.Usage()
-		os.Exit(1)
+	// Validate required arguments
+	refs, err := CollectPRRefs(*owner, *repo, prRefs, *prFile, positional)
+	if err == nil && len(refs) == 0 {
+		err = fmt.Errorf("--owner, --repo, and --pr are required")
+	}
+	if err != nil {
+		errs.Report("Error", newError(KindUsage, "%w", err), nil)
+		if errs.Format == ErrorFormatText {
+			fmt.Fprintln(os.Stderr)
+			fetchCmd.Usage()
+		}
+		os.Exit(ExitUsage)
 	}
 
 	// Validate format
-	validFormats := map[string]bool{"json": true, "human": true, "claude": true}
+	validFormats := map[string]bool{"json": true, "jsonl": true, "human": true, "claude": true, "checklist": true, "html": true, "csv": true, "tsv": true, "template": true}
 	if !validFormats[*format] {
-		fmt.Fprintf(os.Stderr, "Error: Invalid format '%s'. Must be one of: json, human, claude\n", *format)
-		os.Exit(1)
+		errs.Exit("Error", newError(KindUsage, "Invalid format '%s'. Must be one of: json, jsonl, human, claude, checklist, html, csv, tsv, template", *format), nil)
+	}
+	if !*useGraphQL && (*format == "jsonl" || *format == "checklist" || *format == "html" || *format == "template" || isTabularFormat(*format)) {
+		errs.Exit("Error", newError(KindUsage, "--format %s requires the GraphQL API", *format), nil)
+	}
+	if !*useGraphQL {
+		if err := checkRESTFlags(given); err != nil {
+			errs.Exit("Error", err, nil)
+		}
+	}
+	if !*useGraphQL && *outTodo != "" {
+		errs.Exit("Error", newError(KindUsage, "--out-todo requires the GraphQL API"), nil)
+	}
+	if !*useGraphQL && *requireOpen {
+		errs.Exit("Error", newError(KindUsage, "--require-open requires the GraphQL API"), nil)
+	}
+
+	// Validate review gate
+	gate := FeedbackGate{Mode: *failOn, Max: *maxUnresolved}
+	if gate.Mode == "" && gate.Max >= 0 {
+		gate.Mode = FailOnUnresolved
+	}
+	if gate.Max < 0 {
+		gate.Max = 0
+	}
+	if gate.Enabled() && !isValidFailOn(gate.Mode) {
+		errs.Exit("Error", newError(KindUsage, "Invalid fail-on '%s'. Must be one of: %s", gate.Mode, strings.Join(validFailOnModes, ", ")), nil)
+	}
+	if gate.Enabled() && !*useGraphQL {
+		errs.Exit("Error", newError(KindUsage, "--fail-on requires the GraphQL API"), nil)
+	}
+
+	if !isSupportedJSONVersion(*jsonVersion) {
+		errs.Exit("Error", newError(KindUsage, "Invalid JSON version %d. Must be %d or %d", *jsonVersion, JSONVersion1, JSONVersion2), nil)
+	}
+
+	// Validate time window
+	now := time.Now()
+	var sinceTime, untilTime time.Time
+	if *since != "" {
+		if sinceTime, err = ParseTimeBound(*since, now, false); err != nil {
+			errs.Exit("Error", newError(KindUsage, "--since: %w", err), nil)
+		}
+	}
+	if *until != "" {
+		if untilTime, err = ParseTimeBound(*until, now, true); err != nil {
+			errs.Exit("Error", newError(KindUsage, "--until: %w", err), nil)
+		}
+	}
+	if *timeField != timeFieldCreated && *timeField != timeFieldActivity {
+		errs.Exit("Error", newError(KindUsage, "Invalid time field '%s'. Must be one of: created, activity", *timeField), nil)
+	}
+
+	// Validate search pattern
+	var grepPattern *regexp.Regexp
+	if *grep != "" {
+		if grepPattern, err = CompileGrepPattern(*grep, *grepFixed, *grepIgnoreCase); err != nil {
+			errs.Exit("Error", newError(KindUsage, "%w", err), nil)
+		}
+	}
+
+	// Validate classification
+	for _, category := range categories {
+		if !isKnownCategory(category) {
+			errs.Exit("Error", newError(KindUsage, "Invalid category '%s'. Must be one of: %s", category, strings.Join(categoryOrder, ", ")), nil)
+		}
+	}
+	var classifier *Classifier
+	if *categoryRules != "" {
+		if classifier, err = LoadClassifier(*categoryRules); err != nil {
+			errs.Exit("Error", newError(KindUsage, "%w", err), nil)
+		}
+	}
+
+	// Validate ordering
+	if *sortMode != "" && !isValidSortMode(*sortMode) {
+		errs.Exit("Error", newError(KindUsage, "Invalid sort '%s'. Must be one of: %s", *sortMode, strings.Join(validSortModes, ", ")), nil)
+	}
+	if *layout != LayoutFiles && *layout != LayoutRanked {
+		errs.Exit("Error", newError(KindUsage, "Invalid layout '%s'. Must be '%s' or '%s'", *layout, LayoutFiles, LayoutRanked), nil)
+	}
+	reviewerWeights, err := ParseReviewerWeights(reviewerWeightFlags)
+	if err != nil {
+		errs.Exit("Error", newError(KindUsage, "%w", err), nil)
+	}
+	selectedColumns, err := ParseTableColumns(columns)
+	if err != nil {
+		errs.Exit("Error", newError(KindUsage, "%w", err), nil)
+	}
+	var outputTemplate *template.Template
+	if *format == "template" {
+		if *templatePath == "" {
+			errs.Exit("Error", newError(KindUsage, "--format template requires --template"), nil)
+		}
+		if outputTemplate, err = LoadOutputTemplate(*templatePath); err != nil {
+			errs.Exit("Error", newError(KindUsage, "%w", err), nil)
+		}
+	}
+
+	formatOpts := FormatOptions{
+		Highlight:       grepPattern,
+		Sort:            *sortMode,
+		ReviewerWeights: reviewerWeights,
+		Layout:          *layout,
+		Columns:         selectedColumns,
+		JSONVersion:     *jsonVersion,
+		Template:        outputTemplate,
 	}
 
-	var response *PRCommentsResponse
+	streamJSONL := *format == "jsonl" && *outputDir == ""
+	apiCtx, tokens := apiAccess(auth, traffic, errs)
+
+	var fetchPR PRFetchFunc
+	var formatResponse func(*PRCommentsResponse, string) (string, error)
 
 	if *useGraphQL {
 		// GraphQL path (new default)
-		client, err := NewGitHubGraphQLClient(*token)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating GitHub GraphQL client: %v\n", err)
-			os.Exit(1)
-		}
+		client := NewGitHubGraphQLClientFromTokenSource(apiCtx, tokens, *auth.Host)
 
 		opts := FetchOptions{
-			IncludeResolved: *includeResolved,
-			IncludeOutdated: *includeOutdated,
-			IncludeGeneral:  *includeGeneral,
+			IncludeResolved:   *includeResolved,
+			IncludeOutdated:   *includeOutdated,
+			IncludeGeneral:    *includeGeneral,
+			RequireOpen:       *requireOpen,
+			Authors:           authors,
+			ExcludeAuthors:    excludeAuthors,
+			ExcludeBots:       *excludeBots,
+			ThreadStartedBy:   threadStartedBy,
+			Paths:             paths,
+			ExcludePaths:      excludePaths,
+			CodeownersTeams:   codeownersTeams,
+			Since:             sinceTime,
+			Until:             untilTime,
+			TimeField:         *timeField,
+			SinceCommit:       *sinceCommit,
+			NeedsResponseFrom: *needsResponseFrom,
+			OnlyInvolved:      *onlyInvolved,
+			Grep:              grepPattern,
+			GrepDiffHunks:     *grepDiff,
+			Classifier:        classifier,
+			Categories:        categories,
 		}
 
-		threads, generalComments, err := client.FetchPRReviewThreads(
-			context.Background(),
-			*owner, *repo, *prNumber,
-			opts,
-		)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error fetching PR review threads: %v\n", err)
-			os.Exit(1)
+		// JSON Lines events are written as threads are fetched, unless going to files
+		var events *JSONLWriter
+		if streamJSONL {
+			events = NewJSONLWriter(os.Stdout)
 		}
 
-		response = &PRCommentsResponse{
-			PRNumber:        *prNumber,
-			Owner:           *owner,
-			Repo:            *repo,
-			ReviewThreads:   threads,
-			GeneralComments: generalComments,
-			Summary:         GenerateThreadSummary(threads, generalComments, *owner, *repo, *prNumber),
-		}
+		fetchPR = func(ctx context.Context, ref PRRef) (*PRCommentsResponse, error) {
+			if events == nil {
+				return fetchGraphQLResponse(ctx, client, ref, opts)
+			}
 
+			response, err := fetchGraphQLResponse(ctx, client, ref, events.Observe(ref, opts))
+			events.Finish(ref, response, err)
+			if err == nil {
+				err = events.Err()
+			}
+			return response, err
+		}
 		// Format and output using V2 formatters
-		output, err := FormatCommentsV2(response, *format)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
-			os.Exit(1)
+		formatResponse = func(response *PRCommentsResponse, format string) (string, error) {
+			return FormatCommentsV2(response, format, formatOpts)
 		}
-
-		fmt.Print(output)
 	} else {
 		// REST path (legacy)
-		client, err := NewGitHubClient(*token)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating GitHub client: %v\n", err)
-			os.Exit(1)
+		client := NewGitHubClientFromTokenSource(apiCtx, tokens, *auth.Host)
+
+		fetchPR = func(ctx context.Context, ref PRRef) (*PRCommentsResponse, error) {
+			return fetchRESTResponse(client, ref)
+		}
+		// Format and output using V1 formatters
+		formatResponse = func(response *PRCommentsResponse, format string) (string, error) {
+			if format == "json" {
+				return formatJSONVersion(response, *jsonVersion)
+			}
+			return FormatComments(response, format)
 		}
+	}
 
-		// Fetch comments
-		comments, err := client.FetchPRComments(*owner, *repo, *prNumber)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error fetching PR comments: %v\n", err)
-			os.Exit(1)
+	ctx := context.Background()
+
+	if len(refs) > 1 || *outputDir != "" {
+		results, batchErr := runFetchBatch(ctx, refs, *concurrency, fetchPR, formatResponse, *format, *jsonVersion, *outputDir, *stream, errs)
+
+		var responses []*PRCommentsResponse
+		for _, result := range results {
+			if result.Err == nil {
+				responses = append(responses, result.Response)
+			}
 		}
+		if err := writeTodo(*outTodo, responses, formatOpts); err != nil {
+			errs.Exit("Error writing TODO file", err, nil)
+		}
+
+		gateFailed := false
+		for _, result := range results {
+			if result.Err != nil {
+				continue
+			}
+			if err := gate.Check(result.Response); err != nil {
+				errs.Report("Review gate failed", err, &result.Ref)
+				gateFailed = true
+			}
+		}
+
+		if batchErr != nil {
+			errs.Exit("Error", batchErr, nil)
+		}
+		if gateFailed {
+			os.Exit(ExitUnresolved)
+		}
+		return
+	}
 
-		// Parse comments
-		parsedComments, err := ParseComments(comments)
+	response, err := fetchPR(ctx, refs[0])
+	if err != nil {
+		errs.Exit("Error fetching "+refs[0].String(), err, &refs[0])
+	}
+
+	if !streamJSONL {
+		output, err := formatResponse(response, *format)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing comments: %v\n", err)
-			os.Exit(1)
+			errs.Exit("Error formatting output", err, &refs[0])
 		}
+		fmt.Print(output)
+	}
+
+	if err := writeTodo(*outTodo, []*PRCommentsResponse{response}, formatOpts); err != nil {
+		errs.Exit("Error writing TODO file", err, nil)
+	}
+
+	if err := gate.Check(response); err != nil {
+		errs.Exit("Review gate failed", err, &refs[0])
+	}
+}
+
+// writeTodo merges the fetched PRs into the --out-todo file, if one was given
+func writeTodo(path string, responses []*PRCommentsResponse, opts FormatOptions) error {
+	if path == "" || len(responses) == 0 {
+		return nil
+	}
+
+	if err := WriteTodoFile(path, responses, opts); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	return nil
+}
+
+// parseInterleaved parses flags that may appear before, between or after
+// positional arguments, returning the positional arguments in order
+func parseInterleaved(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := flags.Args()
+		if endsWithTerminator(flags, args[:len(args)-len(rest)]) {
+			// Everything after "--" is positional, even if it looks like a flag
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
 
-		// Generate response
-		response = &PRCommentsResponse{
-			PRNumber: *prNumber,
-			Owner:    *owner,
-			Repo:     *repo,
-			Comments: parsedComments,
-			Summary:  GenerateSummary(parsedComments, *owner, *repo, *prNumber),
+// endsWithTerminator reports whether the arguments flag.Parse consumed ended
+// with a "--" terminator, rather than with "--" given as a flag's value
+func endsWithTerminator(flags *flag.FlagSet, consumed []string) bool {
+	for i := 0; i < len(consumed); i++ {
+		arg := consumed[i]
+		if arg == "--" {
+			return true
 		}
 
-		// Format and output using V1 formatters
-		output, err := FormatComments(response, *format)
+		name := strings.TrimLeft(arg, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		f := flags.Lookup(name)
+		if f == nil {
+			continue
+		}
+		if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && boolFlag.IsBoolFlag() {
+			continue
+		}
+		i++ // the flag's value
+	}
+	return false
+}
+
+// fetchGraphQLResponse fetches a PR's review threads and assembles the response
+func fetchGraphQLResponse(ctx context.Context, client *GitHubGraphQLClient, ref PRRef, opts FetchOptions) (*PRCommentsResponse, error) {
+	threads, generalComments, err := client.FetchPRReviewThreads(
+		ctx,
+		ref.Owner, ref.Repo, ref.Number,
+		opts,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := GenerateThreadSummary(threads, generalComments, ref.Owner, ref.Repo, ref.Number)
+	summary.FiltersApplied = opts.DescribeFilters()
+
+	return &PRCommentsResponse{
+		PRNumber:        ref.Number,
+		Owner:           ref.Owner,
+		Repo:            ref.Repo,
+		ReviewThreads:   threads,
+		GeneralComments: generalComments,
+		Summary:         summary,
+	}, nil
+}
+
+// fetchRESTResponse fetches a PR's comments with the legacy REST API and parses them
+func fetchRESTResponse(client *GitHubClient, ref PRRef) (*PRCommentsResponse, error) {
+	comments, err := client.FetchPRComments(ref.Owner, ref.Repo, ref.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedComments, err := ParseComments(comments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing comments: %w", err)
+	}
+
+	return &PRCommentsResponse{
+		PRNumber: ref.Number,
+		Owner:    ref.Owner,
+		Repo:     ref.Repo,
+		Comments: parsedComments,
+		Summary:  GenerateSummary(parsedComments, ref.Owner, ref.Repo, ref.Number),
+	}, nil
+}
+
+// runFetchBatch fetches several PRs concurrently and writes their output.
+// Failures are reported per PR; it returns the results, and an error if any
+// PR failed: of the failures' kind if every PR failed the same way, else of
+// kind KindPartial.
+func runFetchBatch(
+	ctx context.Context,
+	refs []PRRef,
+	concurrency int,
+	fetchPR PRFetchFunc,
+	formatResponse func(*PRCommentsResponse, string) (string, error),
+	format string,
+	jsonVersion int,
+	outputDir string,
+	stream bool,
+	errs ErrorReporter,
+) ([]BatchResult, error) {
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0o755); err != nil {
+			return nil, fmt.Errorf("creating output directory: %w", err)
+		}
+	}
+
+	streamJSON := stream && format == "json" && outputDir == ""
+	// JSON Lines events are already written by fetchPR as they arrive
+	streamedJSONL := format == "jsonl" && outputDir == ""
+	var failures []error
+
+	results := FetchBatch(ctx, refs, concurrency, fetchPR, func(result BatchResult) {
+		if result.Err != nil {
+			failures = append(failures, result.Err)
+			errs.Report("Error fetching "+result.Ref.String(), result.Err, &result.Ref)
+			return
+		}
+
+		switch {
+		case outputDir != "":
+			path, err := writeBatchOutputFile(outputDir, result, format, formatResponse)
+			if err != nil {
+				failures = append(failures, err)
+				errs.Report("Error writing output for "+result.Ref.String(), err, &result.Ref)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+		case streamJSON:
+			line, err := formatBatchJSONLine(result.Response, jsonVersion)
+			if err != nil {
+				failures = append(failures, err)
+				errs.Report("Error formatting output for "+result.Ref.String(), err, &result.Ref)
+				return
+			}
+			fmt.Print(line)
+		}
+	})
+
+	if outputDir == "" && !streamJSON && !streamedJSONL {
+		if format == "json" {
+			output, err := formatBatchJSONArray(results, jsonVersion)
+			if err != nil {
+				return results, fmt.Errorf("formatting output: %w", err)
+			}
+			fmt.Println(output)
+		} else {
+			wroteTable := false
+			for _, result := range results {
+				if result.Err != nil {
+					continue
+				}
+				output, err := formatResponse(result.Response, format)
+				if err != nil {
+					failures = append(failures, err)
+					errs.Report("Error formatting output for "+result.Ref.String(), err, &result.Ref)
+					continue
+				}
+
+				// CSV and TSV rows of all PRs share the first PR's header
+				if isTabularFormat(format) {
+					if wroteTable {
+						output = dropTableHeader(output)
+					}
+					wroteTable = true
+					fmt.Print(output)
+					continue
+				}
+
+				fmt.Print(output)
+				fmt.Print("\n")
+			}
+		}
+	}
+
+	return results, batchError(failures, len(refs))
+}
+
+// batchError sums up the failures of a batch of total PRs: nil if there were
+// none, else an error of the failures' kind if they all failed the same way,
+// or of kind KindPartial
+func batchError(failures []error, total int) error {
+	if len(failures) == 0 {
+		return nil
+	}
+
+	kind := ErrorKindOf(failures[0])
+	for _, err := range failures[1:] {
+		if ErrorKindOf(err) != kind {
+			kind = KindPartial
+		}
+	}
+	if len(failures) < total {
+		kind = KindPartial
+	}
+	return newError(kind, "fetched %d of %d pull requests (%d failed)", total-len(failures), total, len(failures))
+}
+
+func handleList(args []string) {
+	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
+
+	owner := listCmd.String("owner", "", "GitHub repository owner (use with --repo)")
+	repo := listCmd.String("repo", "", "GitHub repository name (use with --owner)")
+	org := listCmd.String("org", "", "List open PRs across all repositories in an organization")
+	author := listCmd.String("author", "", "Only PRs authored by this user (@me for the authenticated user)")
+	reviewRequested := listCmd.String("review-requested", "", "Only PRs with a review requested from this user (@me for the authenticated user)")
+	limit := listCmd.Int("limit", 50, "Maximum number of pull requests to list")
+	concurrency := listCmd.Int("concurrency", 4, "Number of PRs to fetch review threads for in parallel")
+	format := listCmd.String("format", "table", "Output format: table, json, claude")
+	errorFormat := listCmd.String("error-format", ErrorFormatText, "How errors are written to stderr: text, or json for one machine-readable object per error")
+	auth := RegisterAuthFlags(listCmd)
+	traffic := RegisterTrafficFlags(listCmd)
+
+	listCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s list [--owner OWNER --repo REPO | --org ORG] [--author USER] [--review-requested USER] [OPTIONS]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "List open PRs with their review thread counts, most in need of attention first.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		listCmd.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  # Open PRs in a repository\n")
+		fmt.Fprintf(os.Stderr, "  %s list --owner AObuchow --repo Eclipse-Spectrum-Theme\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Your own open PRs across GitHub\n")
+		fmt.Fprintf(os.Stderr, "  %s list --author @me\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # PRs in an organization waiting on your review\n")
+		fmt.Fprintf(os.Stderr, "  %s list --org timehop --review-requested @me --format claude\n", os.Args[0])
+	}
+
+	if err := listCmd.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	applyConfig(listCmd, "list", auth, "")
+
+	// Errors are reported as text or JSON from here on
+	if !isValidErrorFormat(*errorFormat) {
+		fmt.Fprintf(os.Stderr, "Error: Invalid error format '%s'. Must be one of: text, json\n", *errorFormat)
+		os.Exit(ExitUsage)
+	}
+	errs := NewErrorReporter(*errorFormat)
+
+	query := PRListQuery{
+		Owner:           *owner,
+		Repo:            *repo,
+		Org:             *org,
+		Author:          *author,
+		ReviewRequested: *reviewRequested,
+	}
+
+	searchQuery, err := query.SearchString()
+	if err != nil {
+		errs.Report("Error", newError(KindUsage, "%w", err), nil)
+		if errs.Format == ErrorFormatText {
+			fmt.Fprintln(os.Stderr)
+			listCmd.Usage()
+		}
+		os.Exit(ExitUsage)
+	}
+
+	validFormats := map[string]bool{"table": true, "json": true, "claude": true}
+	if !validFormats[*format] {
+		errs.Exit("Error", newError(KindUsage, "Invalid format '%s'. Must be one of: table, json, claude", *format), nil)
+	}
+
+	apiCtx, tokens := apiAccess(auth, traffic, errs)
+	client := NewGitHubGraphQLClientFromTokenSource(apiCtx, tokens, *auth.Host)
+
+	ctx := context.Background()
+	pullRequests, err := client.SearchPullRequests(ctx, searchQuery, *limit)
+	if err != nil {
+		errs.Exit("Error searching pull requests", err, nil)
+	}
+
+	// Thread counts cover everything on the PR, not just actionable threads
+	opts := FetchOptions{
+		IncludeResolved: true,
+		IncludeOutdated: true,
+	}
+
+	refs := make([]PRRef, len(pullRequests))
+	for i, pr := range pullRequests {
+		refs[i] = PRRef{Owner: pr.Owner, Repo: pr.Repo, Number: pr.Number}
+	}
+
+	results := FetchBatch(ctx, refs, *concurrency, func(ctx context.Context, ref PRRef) (*PRCommentsResponse, error) {
+		return fetchGraphQLResponse(ctx, client, ref, opts)
+	}, nil)
+
+	now := time.Now()
+	items := make([]PRListItem, 0, len(pullRequests))
+	var failures []error
+	for i, result := range results {
+		if result.Err != nil {
+			failures = append(failures, result.Err)
+			errs.Report("Error fetching review threads for "+result.Ref.String(), result.Err, &result.Ref)
+			continue
+		}
+		items = append(items, BuildPRListItem(pullRequests[i], result.Response.ReviewThreads, now))
+	}
+
+	response := &PRListResponse{
+		Query:        searchQuery,
+		PullRequests: sortPRListItems(items),
+	}
+
+	output, err := FormatPRList(response, *format)
+	if err != nil {
+		errs.Exit("Error formatting output", err, nil)
+	}
+
+	fmt.Print(output)
+
+	if err := batchError(failures, len(refs)); err != nil {
+		errs.Exit("Error", err, nil)
+	}
+}
+
+func handleSearch(args []string) {
+	searchCmd := flag.NewFlagSet("search", flag.ExitOnError)
+
+	owner := searchCmd.String("owner", "", "GitHub repository owner")
+	repo := searchCmd.String("repo", "", "GitHub repository name")
+	grep := searchCmd.String("grep", "", "Regular expression to search review and general comments for")
+	grepFixed := searchCmd.Bool("grep-fixed", false, "Treat --grep as a fixed string instead of a regular expression")
+	grepIgnoreCase := searchCmd.Bool("i", false, "Match --grep case-insensitively")
+	grepDiff := searchCmd.Bool("grep-diff", false, "Also match --grep against the threads' diff hunks")
+	state := searchCmd.String("state", "all", "PR state to search: open, closed, merged, all")
+	limit := searchCmd.Int("limit", 100, "Maximum number of pull requests to search")
+	concurrency := searchCmd.Int("concurrency", 4, "Number of PRs to search in parallel")
+	format := searchCmd.String("format", "human", "Output format: json, human, claude")
+	errorFormat := searchCmd.String("error-format", ErrorFormatText, "How errors are written to stderr: text, or json for one machine-readable object per error")
+	auth := RegisterAuthFlags(searchCmd)
+	traffic := RegisterTrafficFlags(searchCmd)
+
+	searchCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s search --owner OWNER --repo REPO --grep PATTERN [OPTIONS]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Search review threads and PR comments across many PRs in a repository.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		searchCmd.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  # Every follow-up promised in review\n")
+		fmt.Fprintf(os.Stderr, "  %s search --owner AObuchow --repo Eclipse-Spectrum-Theme --grep 'todo after merge' --grep-fixed -i\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Open PRs with comments about deprecated APIs\n")
+		fmt.Fprintf(os.Stderr, "  %s search --owner AObuchow --repo Eclipse-Spectrum-Theme --grep 'deprecat(ed|ion)' --state open --format json\n", os.Args[0])
+	}
+
+	if err := searchCmd.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	applyConfig(searchCmd, "search", auth, "")
+
+	// Errors are reported as text or JSON from here on
+	if !isValidErrorFormat(*errorFormat) {
+		fmt.Fprintf(os.Stderr, "Error: Invalid error format '%s'. Must be one of: text, json\n", *errorFormat)
+		os.Exit(ExitUsage)
+	}
+	errs := NewErrorReporter(*errorFormat)
+
+	searchQuery, err := SearchPRQuery(*owner, *repo, *state)
+	if err != nil {
+		errs.Report("Error", newError(KindUsage, "%w", err), nil)
+		if errs.Format == ErrorFormatText {
+			fmt.Fprintln(os.Stderr)
+			searchCmd.Usage()
+		}
+		os.Exit(ExitUsage)
+	}
+
+	if *grep == "" {
+		errs.Report("Error", newError(KindUsage, "--grep is required"), nil)
+		if errs.Format == ErrorFormatText {
+			fmt.Fprintln(os.Stderr)
+			searchCmd.Usage()
+		}
+		os.Exit(ExitUsage)
+	}
+
+	pattern, err := CompileGrepPattern(*grep, *grepFixed, *grepIgnoreCase)
+	if err != nil {
+		errs.Exit("Error", newError(KindUsage, "%w", err), nil)
+	}
+
+	validFormats := map[string]bool{"json": true, "human": true, "claude": true}
+	if !validFormats[*format] {
+		errs.Exit("Error", newError(KindUsage, "Invalid format '%s'. Must be one of: json, human, claude", *format), nil)
+	}
+
+	apiCtx, tokens := apiAccess(auth, traffic, errs)
+	client := NewGitHubGraphQLClientFromTokenSource(apiCtx, tokens, *auth.Host)
+
+	ctx := context.Background()
+	pullRequests, err := client.SearchPullRequests(ctx, searchQuery, *limit)
+	if err != nil {
+		errs.Exit("Error searching pull requests", err, nil)
+	}
+
+	// Past promises live in resolved and outdated threads too
+	opts := FetchOptions{
+		IncludeResolved: true,
+		IncludeOutdated: true,
+		IncludeGeneral:  true,
+		Grep:            pattern,
+		GrepDiffHunks:   *grepDiff,
+	}
+
+	refs := make([]PRRef, len(pullRequests))
+	for i, pr := range pullRequests {
+		refs[i] = PRRef{Owner: pr.Owner, Repo: pr.Repo, Number: pr.Number}
+	}
+
+	results := FetchBatch(ctx, refs, *concurrency, func(ctx context.Context, ref PRRef) (*PRCommentsResponse, error) {
+		return fetchGraphQLResponse(ctx, client, ref, opts)
+	}, nil)
+
+	response := &SearchResponse{
+		Query:        searchQuery,
+		Pattern:      pattern.String(),
+		PullRequests: len(pullRequests),
+		Matches:      make([]SearchMatch, 0),
+	}
+	var failures []error
+	for i, result := range results {
+		if result.Err != nil {
+			failures = append(failures, result.Err)
+			errs.Report("Error searching "+result.Ref.String(), result.Err, &result.Ref)
+			response.FailedPRs = append(response.FailedPRs, result.Ref.String())
+			continue
+		}
+		response.Matches = append(response.Matches, CollectSearchMatches(pullRequests[i], result.Response, pattern, *grepDiff)...)
+	}
+
+	output, err := FormatSearchResults(response, *format, pattern)
+	if err != nil {
+		errs.Exit("Error formatting output", err, nil)
+	}
+
+	fmt.Print(output)
+
+	if err := batchError(failures, len(refs)); err != nil {
+		errs.Exit("Error", err, nil)
+	}
+}
+
+func handleSchema(args []string) {
+	schemaCmd := flag.NewFlagSet("schema", flag.ExitOnError)
+
+	jsonVersion := schemaCmd.Int("json-version", DefaultJSONVersion, "Version of the json format to describe (1 or 2)")
+
+	schemaCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s schema [OPTIONS]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Print the JSON Schema of the fetch command's json output format.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		schemaCmd.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  # Schema of the default, original format\n")
+		fmt.Fprintf(os.Stderr, "  %s schema > pr-comments-response.schema.json\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Schema of version 2\n")
+		fmt.Fprintf(os.Stderr, "  %s schema --json-version 2\n", os.Args[0])
+	}
+
+	if err := schemaCmd.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	output, err := JSONSchema(*jsonVersion)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(output)
+}
+
+// applyConfig fills in options not given on the command line from a profile,
+// config files and the environment, then validates the host
+func applyConfig(flags *flag.FlagSet, command string, auth AuthFlags, repo string) {
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "host" {
+			*auth.HostGiven = true
+		}
+	})
+	if err := ApplyConfig(flags, command, repo); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := validateHost(*auth.Host); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// repoHint returns owner/repo when both are given, for selecting a profile
+func repoHint(owner, repo string) string {
+	if owner == "" || repo == "" {
+		return ""
+	}
+	return owner + "/" + repo
+}
+
+// fetchRepoHint returns the repository of the first PR given on the command
+// line, for selecting a profile
+func fetchRepoHint(owner, repo string, prRefs, positional []string) string {
+	for _, value := range append(append([]string{}, prRefs...), positional...) {
+		if ref, err := ParsePRRef(value, owner, repo); err == nil {
+			return repoHint(ref.Owner, ref.Repo)
+		}
+	}
+	return repoHint(owner, repo)
+}
+
+func handleConfig(args []string) {
+	configCmd := flag.NewFlagSet("config", flag.ExitOnError)
+
+	command := configCmd.String("command", "fetch", "Command whose settings to show: "+strings.Join(configCommands, ", "))
+	profile := configCmd.String("profile", "", "Include this profile's settings (fetch only)")
+	repo := configCmd.String("repo", "", "Include the profile selected for this OWNER/REPO (fetch only)")
+
+	configCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s config show [OPTIONS]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Show the effective settings from config files and the environment, and where each came from.\n\n")
+		fmt.Fprintf(os.Stderr, "Settings are read from, lowest precedence first:\n")
+		fmt.Fprintf(os.Stderr, "  ~/.config/pr-review-cli/%s   user config ($XDG_CONFIG_HOME is honoured)\n", userConfigFileName)
+		fmt.Fprintf(os.Stderr, "  %s                    repo config, found from the current directory up to the git root\n", repoConfigFileName)
+		fmt.Fprintf(os.Stderr, "  %sOPTION_NAME         environment, e.g. %s\n", configEnvPrefix, configEnvName("include-general"))
+		fmt.Fprintf(os.Stderr, "  ~/.config/pr-review-cli/%s profile chosen with --profile, a profile setting, or per repository (fetch only)\n", profilesFileName)
+		fmt.Fprintf(os.Stderr, "Flags on the command line override them all.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		configCmd.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  %s config show\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s config show --command list\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s config show --profile audit\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s config show --repo AObuchow/Eclipse-Spectrum-Theme\n", os.Args[0])
+	}
+
+	if len(args) == 0 || args[0] != "show" {
+		configCmd.Usage()
+		os.Exit(1)
+	}
+	if err := configCmd.Parse(args[1:]); err != nil {
+		os.Exit(1)
+	}
+	if !isConfigCommand(*command) {
+		fmt.Fprintf(os.Stderr, "Error: Invalid command '%s'. Must be one of: %s\n", *command, strings.Join(configCommands, ", "))
+		os.Exit(1)
+	}
+
+	layers, err := loadConfigLayers()
+	if err == nil {
+		layers, err = withProfileLayer(layers, *command, *profile, *repo)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Print(FormatConfigSettings(EffectiveSettings(layers, *command), *command))
+}
+
+func handleAuth(args []string) {
+	authCmd := flag.NewFlagSet("auth", flag.ExitOnError)
+
+	auth := RegisterAuthFlags(authCmd)
+	repo := authCmd.String("repo", "", "Also check access to this repository's review threads (OWNER/REPO)")
+	jsonOutput := authCmd.Bool("json", false, "Print the status as JSON")
+
+	authCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s auth status [OPTIONS]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Check the GitHub token the other commands would use: who it belongs to, where it\n")
+		fmt.Fprintf(os.Stderr, "came from, its scopes, SAML SSO authorization and rate limit, and with --repo\n")
+		fmt.Fprintf(os.Stderr, "whether it can read and resolve review threads there. Exits with status 1 and\n")
+		fmt.Fprintf(os.Stderr, "suggests fixes if a problem is found.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		authCmd.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  %s auth status\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s auth status --repo AObuchow/Eclipse-Spectrum-Theme\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s auth status --host github.example.com --json\n", os.Args[0])
+	}
+
+	if len(args) == 0 || args[0] != "status" {
+		authCmd.Usage()
+		os.Exit(1)
+	}
+	if err := authCmd.Parse(args[1:]); err != nil {
+		os.Exit(1)
+	}
+	applyConfig(authCmd, "auth", auth, *repo)
+
+	ctx := context.Background()
+	var errs ErrorReporter
+	src, source, err := auth.TokenSource(ctx)
+	if err != nil {
+		errs.Exit("Error", err, nil)
+	}
+	token, err := src.Token()
+	if err != nil {
+		errs.Exit("Error", err, nil)
+	}
+
+	status, err := CheckAuthStatus(ctx,
+		NewGitHubClientFromTokenSource(ctx, src, *auth.Host),
+		NewGitHubGraphQLClientFromTokenSource(ctx, src, *auth.Host),
+		token.AccessToken, source, *auth.Host, *repo)
+	if err != nil {
+		errs.Exit("Error checking authentication", err, nil)
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(status, "", "  ")
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error encoding status: %v\n", err)
 			os.Exit(1)
 		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(FormatAuthStatus(status))
+	}
 
-		fmt.Print(output)
+	if !status.OK() {
+		os.Exit(1)
+	}
+}
+
+func handleDev(args []string) {
+	devCmd := flag.NewFlagSet("dev fake-server", flag.ExitOnError)
+
+	addr := devCmd.String("addr", "127.0.0.1:8080", "Address to listen on (port 0 picks a free port)")
+	seed := devCmd.String("seed", "", "JSON file with the repositories, pull requests and review threads to serve (default: none)")
+	token := devCmd.String("token", "", "Only accept this token (default: accept any token)")
+	pageSize := devCmd.Int("page-size", 0, "Return at most this many items per GraphQL page, to exercise pagination with small seeds")
+	rateLimit := devCmd.Int("rate-limit", 0, "Fail requests with rate limit errors after this many (default: GitHub's 5000)")
+	quiet := devCmd.Bool("quiet", false, "Don't log requests")
+
+	devCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s dev fake-server [OPTIONS]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Serve a fake GitHub API for end-to-end testing, over pull requests seeded from a\n")
+		fmt.Fprintf(os.Stderr, "JSON file. It answers the GraphQL queries and REST requests the other commands\n")
+		fmt.Fprintf(os.Stderr, "send when given --host with the server's URL, and mutations that resolve and\n")
+		fmt.Fprintf(os.Stderr, "reply to review threads. See the README for the seed format.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		devCmd.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  %s dev fake-server --seed fakegithub/example-seed.json\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s fetch --host http://127.0.0.1:8080 --token fake --owner octo-org --repo widgets --pr 7\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s dev fake-server --seed seed.json --addr 127.0.0.1:0 --page-size 2\n", os.Args[0])
+	}
+
+	if len(args) == 0 || args[0] != "fake-server" {
+		devCmd.Usage()
+		os.Exit(1)
+	}
+	if err := devCmd.Parse(args[1:]); err != nil {
+		os.Exit(1)
+	}
+
+	var data *fakegithub.Data
+	if *seed != "" {
+		var err error
+		if data, err = fakegithub.LoadData(*seed); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	server, err := fakegithub.New(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	server.Token = *token
+	server.PageSize = *pageSize
+	server.RateLimit = *rateLimit
+	if !*quiet {
+		server.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Fake GitHub listening on http://%s\n", listener.Addr())
+	fmt.Fprintf(os.Stderr, "Use it with: --host http://%s\n", listener.Addr())
+
+	if err := http.Serve(listener, server); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// apiAccess returns the context the GitHub clients send their requests with,
+// which records or replays traffic if asked to, and their token source. No
+// token is needed to replay recorded traffic.
+func apiAccess(auth AuthFlags, traffic TrafficFlags, errs ErrorReporter) (context.Context, oauth2.TokenSource) {
+	ctx, err := traffic.Context(context.Background())
+	if err != nil {
+		errs.Exit("Error", err, nil)
+	}
+	if traffic.Replaying() {
+		return ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: replayToken})
+	}
+	return ctx, resolveTokenSource(ctx, auth, errs)
+}
+
+// resolveTokenSource returns the token source for a command's auth flags,
+// exiting with an error if no token can be obtained. With --verbose it
+// reports the source used.
+func resolveTokenSource(ctx context.Context, auth AuthFlags, errs ErrorReporter) oauth2.TokenSource {
+	src, source, err := auth.TokenSource(ctx)
+	if err != nil {
+		errs.Exit("Error", err, nil)
+	}
+
+	if *auth.Verbose {
+		fmt.Fprintf(os.Stderr, "Using GitHub token from %s for %s\n", source, hostName(*auth.Host))
 	}
+	return src
 }
 
 func printUsage() {
@@ -178,10 +1180,20 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, "Usage: %s COMMAND [OPTIONS]\n\n", os.Args[0])
 	fmt.Fprintf(os.Stderr, "Commands:\n")
 	fmt.Fprintf(os.Stderr, "  fetch     Fetch and parse PR review comments\n")
+	fmt.Fprintf(os.Stderr, "  list      List open PRs with outstanding review threads\n")
+	fmt.Fprintf(os.Stderr, "  search    Search review comments across a repository's PRs\n")
+	fmt.Fprintf(os.Stderr, "  schema    Print the JSON Schema of the json output format\n")
+	fmt.Fprintf(os.Stderr, "  config    Show the settings from config files and the environment\n")
+	fmt.Fprintf(os.Stderr, "  auth      Check the GitHub token's identity, scopes and repository access\n")
+	fmt.Fprintf(os.Stderr, "  dev       Developer tools: a fake GitHub server for end-to-end tests\n")
 	fmt.Fprintf(os.Stderr, "  help      Show this help message\n\n")
 	fmt.Fprintf(os.Stderr, "For command-specific help, use: %s COMMAND --help\n\n", os.Args[0])
-	fmt.Fprintf(os.Stderr, "Environment Variables:\n")
-	fmt.Fprintf(os.Stderr, "  GITHUB_TOKEN    GitHub personal access token (not required if --token is used)\n\n")
+	fmt.Fprintf(os.Stderr, "Credentials (tried in order):\n")
+	fmt.Fprint(os.Stderr, credentialSourcesHelp)
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "Exit codes:\n")
+	fmt.Fprint(os.Stderr, exitCodesHelp)
+	fmt.Fprintf(os.Stderr, "\n")
 	fmt.Fprintf(os.Stderr, "Examples:\n")
 	fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2\n", os.Args[0])
 	fmt.Fprintf(os.Stderr, "  %s fetch --owner AObuchow --repo Eclipse-Spectrum-Theme --pr 2 --format human\n", os.Args[0])
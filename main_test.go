@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseInterleaved(t *testing.T) {
+	tests := []struct {
+		args       []string
+		want       []string
+		wantGrep   string
+		wantPretty bool
+	}{
+		{args: []string{"1", "2"}, want: []string{"1", "2"}},
+		{args: []string{"1", "--pretty", "2", "--grep", "todo", "3"}, want: []string{"1", "2", "3"}, wantGrep: "todo", wantPretty: true},
+		{args: []string{"--", "-1", "--pretty"}, want: []string{"-1", "--pretty"}},
+		{args: []string{"1", "--", "--pretty", "2"}, want: []string{"1", "--pretty", "2"}},
+		{args: []string{"1", "--pretty", "--", "--grep", "x"}, want: []string{"1", "--grep", "x"}, wantPretty: true},
+		{args: []string{"--", "--"}, want: []string{"--"}},
+		{args: []string{"--grep", "--", "1", "--pretty"}, want: []string{"1"}, wantGrep: "--", wantPretty: true},
+		{args: []string{"--grep=--", "1"}, want: []string{"1"}, wantGrep: "--"},
+	}
+
+	for _, tt := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		grep := flags.String("grep", "", "")
+		pretty := flags.Bool("pretty", false, "")
+
+		got, err := parseInterleaved(flags, tt.args)
+		if err != nil {
+			t.Errorf("%q: %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) || *grep != tt.wantGrep || *pretty != tt.wantPretty {
+			t.Errorf("%q: positional %q, --grep %q, --pretty %v; want %q, %q, %v",
+				tt.args, got, *grep, *pretty, tt.want, tt.wantGrep, tt.wantPretty)
+		}
+	}
+}
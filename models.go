@@ -89,6 +89,23 @@ type CommentsSummary struct {
 	ResolvedThreads   int `json:"resolved_threads,omitempty"`
 	OutdatedThreads   int `json:"outdated_threads,omitempty"`
 	GeneralComments   int `json:"general_comments_count,omitempty"`
+	// Filters used to narrow down the threads and comments (e.g. "exclude-bots")
+	FiltersApplied []string `json:"filters_applied,omitempty"`
+	// Number of threads per intent category
+	Categories map[string]int `json:"categories,omitempty"`
+}
+
+// JSONLEvent is a single record of the jsonl format: a thread or general
+// comment as it is fetched, then a summary (or error) once the PR is done
+type JSONLEvent struct {
+	Type     string           `json:"type"`
+	Owner    string           `json:"owner"`
+	Repo     string           `json:"repo"`
+	PRNumber int              `json:"pr_number"`
+	Thread   *ReviewThread    `json:"thread,omitempty"`
+	Comment  *GeneralComment  `json:"comment,omitempty"`
+	Summary  *CommentsSummary `json:"summary,omitempty"`
+	Error    string           `json:"error,omitempty"`
 }
 
 // ReviewThread represents a review thread with all its comments
@@ -103,6 +120,19 @@ type ReviewThread struct {
 	IsOutdated   bool            `json:"is_outdated"`
 	Comments     []ThreadComment `json:"comments"`
 	DiffHunk     string          `json:"diff_hunk,omitempty"`
+	// Intent of the thread's opening comment: blocking, question, suggestion, nit, praise or comment
+	Category string `json:"category,omitempty"`
+	// Set when the opening comment follows the Conventional Comments format
+	Conventional *ConventionalComment `json:"conventional_comment,omitempty"`
+}
+
+// ConventionalComment holds the parsed first line of a Conventional Comment,
+// e.g. "suggestion (non-blocking): extract this into a helper"
+type ConventionalComment struct {
+	Label       string   `json:"label"`
+	Decorations []string `json:"decorations,omitempty"`
+	Subject     string   `json:"subject"`
+	Blocking    bool     `json:"blocking"`
 }
 
 // ThreadComment represents a single comment within a review thread
@@ -113,6 +143,7 @@ type ThreadComment struct {
 	CreatedAt string `json:"created_at"`
 	HTMLURL   string `json:"html_url"`
 	IsReply   bool   `json:"is_reply"`
+	IsBot     bool   `json:"is_bot,omitempty"`
 }
 
 // GeneralComment represents a general PR comment (not attached to specific code)
@@ -122,4 +153,56 @@ type GeneralComment struct {
 	Author    string `json:"author"`
 	CreatedAt string `json:"created_at"`
 	HTMLURL   string `json:"html_url"`
+	IsBot     bool   `json:"is_bot,omitempty"`
+	Category  string `json:"category,omitempty"`
+}
+
+// PRListItem summarizes the review state of a single pull request
+type PRListItem struct {
+	Owner             string `json:"owner"`
+	Repo              string `json:"repo"`
+	PRNumber          int    `json:"pr_number"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	Author            string `json:"author"`
+	IsDraft           bool   `json:"is_draft"`
+	ReviewDecision    string `json:"review_decision,omitempty"`
+	LastActivity      string `json:"last_activity"`
+	UnresolvedThreads int    `json:"unresolved_threads"`
+	ResolvedThreads   int    `json:"resolved_threads"`
+	OutdatedThreads   int    `json:"outdated_threads"`
+	AttentionScore    int    `json:"attention_score"`
+}
+
+// PRListResponse represents the output of the list command
+type PRListResponse struct {
+	Query        string       `json:"query"`
+	PullRequests []PRListItem `json:"pull_requests"`
+}
+
+// SearchMatch is a review or general comment matching a search pattern
+type SearchMatch struct {
+	Owner      string `json:"owner"`
+	Repo       string `json:"repo"`
+	PRNumber   int    `json:"pr_number"`
+	PRTitle    string `json:"pr_title"`
+	ThreadID   string `json:"thread_id,omitempty"`
+	File       string `json:"file,omitempty"`
+	Line       *int   `json:"line,omitempty"`
+	IsResolved bool   `json:"is_resolved"`
+	Author     string `json:"author"`
+	CreatedAt  string `json:"created_at"`
+	Body       string `json:"body"`
+	HTMLURL    string `json:"html_url"`
+	// Set when the match is in the thread's diff hunk rather than a comment body
+	InDiffHunk bool `json:"in_diff_hunk,omitempty"`
+}
+
+// SearchResponse represents the output of the search command
+type SearchResponse struct {
+	Query        string        `json:"query"`
+	Pattern      string        `json:"pattern"`
+	PullRequests int           `json:"pull_requests_searched"`
+	Matches      []SearchMatch `json:"matches"`
+	FailedPRs    []string      `json:"failed_pull_requests,omitempty"`
 }
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// profilesFileName is the profiles file in the user config directory
+const profilesFileName = "profiles.yaml"
+
+// profileCommand is the command profiles hold presets for
+const profileCommand = "fetch"
+
+// profileExtendsKey names the profiles a profile inherits settings from
+const profileExtendsKey = "extends"
+
+// ProfileSet holds the named option presets of a profiles file
+type ProfileSet struct {
+	Path     string
+	profiles map[string]map[string]interface{}
+	repos    map[string]string // repository pattern -> profile name
+}
+
+// LoadProfiles reads the user's profiles file; a missing file has no profiles
+func LoadProfiles() (*ProfileSet, error) {
+	dir, err := userConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return loadProfilesFile(filepath.Join(dir, profilesFileName))
+}
+
+// loadProfilesFile parses a profiles file:
+//
+//	profiles:
+//	  agent:
+//	    format: claude
+//	  audit:
+//	    extends: agent
+//	    format: json
+//	repos:
+//	  my-org/*: agent
+func loadProfilesFile(profilesPath string) (*ProfileSet, error) {
+	set := &ProfileSet{
+		Path:     profilesPath,
+		profiles: make(map[string]map[string]interface{}),
+		repos:    make(map[string]string),
+	}
+
+	data, err := os.ReadFile(profilesPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return set, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", profilesPath, err)
+	}
+
+	document, err := parseYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", profilesPath, err)
+	}
+
+	for key, value := range document {
+		section, ok := value.(map[string]interface{})
+		if !ok && value != "" {
+			return nil, fmt.Errorf("%s: %s must be a mapping", profilesPath, key)
+		}
+
+		switch key {
+		case "profiles":
+			for name, profile := range section {
+				settings, ok := profile.(map[string]interface{})
+				if !ok && profile != "" {
+					return nil, fmt.Errorf("%s: profile %s must be a mapping", profilesPath, name)
+				}
+				set.profiles[name] = settings
+			}
+		case "repos":
+			for pattern, name := range section {
+				profile, ok := name.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s: repos.%s must name a profile", profilesPath, pattern)
+				}
+				set.repos[pattern] = profile
+			}
+		default:
+			return nil, fmt.Errorf("%s: unknown section %q (expected profiles or repos)", profilesPath, key)
+		}
+	}
+
+	for pattern, name := range set.repos {
+		if _, ok := set.profiles[name]; !ok {
+			return nil, fmt.Errorf("%s: repos.%s uses unknown profile %q", profilesPath, pattern, name)
+		}
+	}
+
+	return set, nil
+}
+
+// Names returns the profile names in alphabetical order
+func (s *ProfileSet) Names() []string {
+	names := make([]string, 0, len(s.profiles))
+	for name := range s.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns a profile's settings, including those it inherits. Parents
+// listed in extends are applied in order, and the profile's own settings
+// override them.
+func (s *ProfileSet) Resolve(name string) (map[string]string, error) {
+	return s.resolve(name, nil)
+}
+
+func (s *ProfileSet) resolve(name string, chain []string) (map[string]string, error) {
+	for _, seen := range chain {
+		if seen == name {
+			return nil, fmt.Errorf("profile %s extends itself via %s", name, strings.Join(append(chain, name), " -> "))
+		}
+	}
+
+	profile, ok := s.profiles[name]
+	if !ok {
+		if len(s.profiles) == 0 {
+			return nil, fmt.Errorf("unknown profile %q (no profiles defined in %s)", name, s.Path)
+		}
+		return nil, fmt.Errorf("unknown profile %q (expected one of: %s)", name, strings.Join(s.Names(), ", "))
+	}
+	chain = append(chain, name)
+
+	settings := make(map[string]string)
+
+	if extends, ok := profile[profileExtendsKey]; ok {
+		var parents []string
+		switch v := extends.(type) {
+		case string:
+			parents = []string{v}
+		case []string:
+			parents = v
+		default:
+			return nil, fmt.Errorf("profile %s: extends must name a profile or a list of profiles", name)
+		}
+
+		for _, parent := range parents {
+			inherited, err := s.resolve(parent, chain)
+			if err != nil {
+				return nil, err
+			}
+			for key, value := range inherited {
+				settings[key] = value
+			}
+		}
+	}
+
+	for key, value := range profile {
+		if key == profileExtendsKey {
+			continue
+		}
+		text, err := configValueString(value)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %s: %w", name, key, err)
+		}
+		settings[key] = text
+	}
+
+	return settings, nil
+}
+
+// ProfileForRepo returns the profile selected for an owner/repo by the repos
+// section, or "" if none matches. An exact match wins over a pattern like
+// owner/*, which wins over broader patterns.
+func (s *ProfileSet) ProfileForRepo(repo string) string {
+	if repo == "" {
+		return ""
+	}
+
+	best := ""
+	bestRank := -1
+	for pattern := range s.repos {
+		matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(repo))
+		if err != nil || !matched {
+			continue
+		}
+
+		// More literal characters make a more specific pattern
+		rank := len(pattern) - strings.Count(pattern, "*") - strings.Count(pattern, "?")
+		if !strings.ContainsAny(pattern, "*?[") {
+			rank = math.MaxInt32
+		}
+		if rank > bestRank || (rank == bestRank && pattern < best) {
+			best = pattern
+			bestRank = rank
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+	return s.repos[best]
+}
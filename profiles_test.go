@@ -0,0 +1,228 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testProfiles = `profiles:
+  agent:
+    format: claude
+    exclude-bots: true
+  triage:
+    format: human
+    include-general: true
+  audit:
+    extends: triage
+    format: json
+    include-resolved: true
+  everything:
+    extends: [audit, agent]
+    include-outdated: true
+  loop-a:
+    extends: loop-b
+  loop-b:
+    extends: loop-a
+repos:
+  my-org/*: agent
+  my-org/release-*: triage
+  my-org/release-tools: audit
+`
+
+// loadTestProfiles writes profiles into a temporary profiles file and loads it
+func loadTestProfiles(t *testing.T, content string) *ProfileSet {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), profilesFileName)
+	writeFile(t, path, content)
+	profiles, err := loadProfilesFile(path)
+	if err != nil {
+		t.Fatalf("loadProfilesFile: %v", err)
+	}
+	return profiles
+}
+
+func TestProfileResolve(t *testing.T) {
+	profiles := loadTestProfiles(t, testProfiles)
+
+	tests := []struct {
+		name    string
+		profile string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "own settings",
+			profile: "triage",
+			want:    map[string]string{"format": "human", "include-general": "true"},
+		},
+		{
+			name:    "extends overrides the parent",
+			profile: "audit",
+			want:    map[string]string{"format": "json", "include-general": "true", "include-resolved": "true"},
+		},
+		{
+			name:    "extends a list in order",
+			profile: "everything",
+			want: map[string]string{
+				"format": "claude", "exclude-bots": "true", "include-general": "true",
+				"include-resolved": "true", "include-outdated": "true",
+			},
+		},
+		{
+			name:    "cycle",
+			profile: "loop-a",
+			wantErr: "profile loop-a extends itself via loop-a -> loop-b -> loop-a",
+		},
+		{
+			name:    "unknown",
+			profile: "nightly",
+			wantErr: `unknown profile "nightly" (expected one of: agent, audit, everything, loop-a, loop-b, triage)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := profiles.Resolve(tt.profile)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileForRepo(t *testing.T) {
+	profiles := loadTestProfiles(t, testProfiles)
+
+	tests := []struct {
+		repo string
+		want string
+	}{
+		{"my-org/release-tools", "audit"},
+		{"My-Org/Release-Tools", "audit"},
+		{"my-org/release-notes", "triage"},
+		{"my-org/widgets", "agent"},
+		{"other-org/widgets", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := profiles.ProfileForRepo(tt.repo); got != tt.want {
+			t.Errorf("ProfileForRepo(%q) = %q, want %q", tt.repo, got, tt.want)
+		}
+	}
+}
+
+func TestLoadProfilesFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown section", "presets:\n  agent:\n    format: json\n", `unknown section "presets"`},
+		{"repo uses unknown profile", "profiles:\n  agent:\n    format: json\nrepos:\n  my-org/*: triage\n", `repos.my-org/* uses unknown profile "triage"`},
+		{"profile isn't a mapping", "profiles:\n  agent: json\n", "profile agent must be a mapping"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), profilesFileName)
+			writeFile(t, path, tt.content)
+			_, err := loadProfilesFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithProfileLayer(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	writeFile(t, filepath.Join(dir, configDirName, profilesFileName), testProfiles)
+
+	configured := &configLayer{
+		source:   "user config",
+		global:   map[string]string{"profile": "triage"},
+		commands: make(map[string]map[string]string),
+	}
+
+	tests := []struct {
+		name       string
+		command    string
+		layers     []*configLayer
+		profile    string
+		repo       string
+		wantFormat string
+		wantSource string
+	}{
+		{
+			name:       "given profile wins",
+			command:    "fetch",
+			layers:     []*configLayer{configured},
+			profile:    "audit",
+			repo:       "my-org/widgets",
+			wantFormat: "json",
+			wantSource: "profile audit",
+		},
+		{
+			name:       "profile setting wins over the repo",
+			command:    "fetch",
+			layers:     []*configLayer{configured},
+			repo:       "my-org/widgets",
+			wantFormat: "human",
+			wantSource: "profile triage, from user config",
+		},
+		{
+			name:       "selected for the repo",
+			command:    "fetch",
+			repo:       "my-org/widgets",
+			wantFormat: "claude",
+			wantSource: "profile agent, selected for my-org/widgets",
+		},
+		{
+			name:    "no profile",
+			command: "fetch",
+			repo:    "other-org/widgets",
+		},
+		{
+			name:    "fetch only",
+			command: "list",
+			profile: "audit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers, err := withProfileLayer(tt.layers, tt.command, tt.profile, tt.repo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			format := EffectiveSettings(layers, tt.command)["format"]
+			if format.Value != tt.wantFormat || format.Source != tt.wantSource {
+				t.Errorf("format = %q from %q, want %q from %q", format.Value, format.Source, tt.wantFormat, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestWithProfileLayerRejectsUnknownOptions(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	writeFile(t, filepath.Join(dir, configDirName, profilesFileName), "profiles:\n  agent:\n    review-requested: \"@me\"\n")
+
+	_, err := withProfileLayer(nil, "fetch", "agent", "")
+	if err == nil || err.Error() != "profile agent: fetch has no --review-requested option" {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// replayToken stands in for a real token when replaying recorded traffic
+const replayToken = "replay"
+
+// Exchange is one recorded API request and its response, stored as a JSON
+// file. Bodies that are JSON are stored as JSON to keep fixtures readable.
+// Request headers, and with them the token, are never recorded.
+type Exchange struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the part of a request that identifies it
+type RecordedRequest struct {
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body,omitempty"`
+	BodyText string          `json:"body_text,omitempty"`
+}
+
+// RecordedResponse is a response as the server sent it
+type RecordedResponse struct {
+	Status   int                 `json:"status"`
+	Headers  map[string][]string `json:"headers,omitempty"`
+	Body     json.RawMessage     `json:"body,omitempty"`
+	BodyText string              `json:"body_text,omitempty"`
+}
+
+// setBody stores a body as JSON if it is JSON, else as text
+func setBody(data []byte, body *json.RawMessage, text *string) {
+	if len(data) == 0 {
+		return
+	}
+	var compact bytes.Buffer
+	if json.Valid(data) && json.Compact(&compact, data) == nil {
+		*body = compact.Bytes()
+		return
+	}
+	*text = string(data)
+}
+
+// getBody returns a body stored by setBody
+func getBody(body json.RawMessage, text string) []byte {
+	if len(body) > 0 {
+		return body
+	}
+	return []byte(text)
+}
+
+// exchangeFileName names the file of a request: a hash of its method, path,
+// query and body, so the same request finds the same file. The path differs
+// between github.com and GitHub Enterprise Server, so replay with the --host
+// the traffic was recorded with.
+func exchangeFileName(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", req.Method, req.URL.RequestURI())
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))[:20] + ".json"
+}
+
+// readRequestBody reads a request's body and puts it back for sending
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// RecordingTransport saves every request and response that passes through it
+// into a directory
+type RecordingTransport struct {
+	Dir  string
+	Next http.RoundTripper
+}
+
+// RoundTrip sends the request and records the exchange
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	// Installation token exchanges would put a token in the recording, and
+	// replay needs no token
+	if strings.HasSuffix(req.URL.Path, "/access_tokens") {
+		return resp, nil
+	}
+
+	exchange := Exchange{
+		Request:  RecordedRequest{Method: req.Method, URL: req.URL.String()},
+		Response: RecordedResponse{Status: resp.StatusCode, Headers: resp.Header.Clone()},
+	}
+	delete(exchange.Response.Headers, "Set-Cookie")
+	setBody(body, &exchange.Request.Body, &exchange.Request.BodyText)
+	setBody(respBody, &exchange.Response.Body, &exchange.Response.BodyText)
+
+	if err := writeExchange(filepath.Join(t.Dir, exchangeFileName(req, body)), exchange); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// writeExchange writes an exchange file, replacing any earlier recording
+func writeExchange(path string, exchange Exchange) error {
+	data, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding recorded exchange: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("recording exchange: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes a file then renames it into place, so concurrent
+// fetches never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// ReplayTransport answers requests from the exchanges recorded in a
+// directory, without touching the network
+type ReplayTransport struct {
+	Dir string
+}
+
+// RoundTrip returns the recorded response to the request
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	name := exchangeFileName(req, body)
+	data, err := os.ReadFile(filepath.Join(t.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, newError(KindError, "no recorded response for %s %s in %s (record it with --record, using the same options and --host)", req.Method, req.URL.RequestURI(), t.Dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading recorded exchange: %w", err)
+	}
+
+	var exchange Exchange
+	if err := json.Unmarshal(data, &exchange); err != nil {
+		return nil, fmt.Errorf("parsing recorded exchange %s: %w", name, err)
+	}
+
+	return exchange.Response.httpResponse(req), nil
+}
+
+// httpResponse rebuilds the response to a request
+func (r RecordedResponse) httpResponse(req *http.Request) *http.Response {
+	body := getBody(r.Body, r.BodyText)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header(r.Headers).Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// TrafficFlags are the options for how API traffic is sent: recorded,
+// replayed or cached
+type TrafficFlags struct {
+	Record   *string
+	Replay   *string
+	CacheTTL *time.Duration
+	NoCache  *bool
+}
+
+// RegisterTrafficFlags defines the record, replay and cache options on a command
+func RegisterTrafficFlags(flags *flag.FlagSet) TrafficFlags {
+	return TrafficFlags{
+		Record:   flags.String("record", "", "Save every API request and response into this directory, e.g. to share a reproducible bug report"),
+		Replay:   flags.String("replay", "", "Answer API requests from responses saved with --record in this directory, without network access or a token"),
+		CacheTTL: flags.Duration("cache-ttl", defaultCacheTTL, "Reuse GraphQL responses for this long, e.g. 2m, while the PR's updatedAt is unchanged. New replies don't always change it, so output can be this stale (default: GraphQL isn't cached)"),
+		NoCache:  flags.Bool("no-cache", false, "Don't read or write the response cache (REST responses are otherwise revalidated with their ETag)"),
+	}
+}
+
+// Replaying reports whether requests are answered from recordings
+func (t TrafficFlags) Replaying() bool {
+	return *t.Replay != ""
+}
+
+// Context returns a context whose HTTP client records, replays or caches
+// traffic, as requested. The GitHub clients send their requests through it.
+// Recorded and replayed traffic bypasses the cache.
+func (t TrafficFlags) Context(ctx context.Context) (context.Context, error) {
+	var transport http.RoundTripper
+	switch {
+	case *t.Record != "" && *t.Replay != "":
+		return nil, newError(KindUsage, "--record and --replay can't be used together")
+	case *t.CacheTTL < 0:
+		return nil, newError(KindUsage, "--cache-ttl can't be negative")
+	case *t.Record != "":
+		if err := os.MkdirAll(*t.Record, 0o755); err != nil {
+			return nil, fmt.Errorf("creating record directory: %w", err)
+		}
+		transport = &RecordingTransport{Dir: *t.Record}
+	case *t.Replay != "":
+		if info, err := os.Stat(*t.Replay); err != nil || !info.IsDir() {
+			return nil, newError(KindUsage, "replay directory %s not found", *t.Replay)
+		}
+		transport = &ReplayTransport{Dir: *t.Replay}
+	case !*t.NoCache:
+		cache, err := newUserCache(*t.CacheTTL)
+		if err != nil {
+			// The cache only saves requests; carry on without it
+			fmt.Fprintf(os.Stderr, "Warning: response cache disabled: %v\n", err)
+			return ctx, nil
+		}
+		transport = cache
+	default:
+		return ctx, nil
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: transport}), nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"pr-review-cli/fakegithub"
+)
+
+// fetchThrough fetches PR 1 over GraphQL and REST with requests sent through transport
+func fetchThrough(t *testing.T, transport http.RoundTripper, src oauth2.TokenSource, host string) (*PRCommentsResponse, *PRCommentsResponse) {
+	t.Helper()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: transport})
+	ref := PRRef{Owner: "octo-org", Repo: "widgets", Number: 1}
+
+	graphQL, err := fetchGraphQLResponse(ctx, NewGitHubGraphQLClientFromTokenSource(ctx, src, host), ref,
+		FetchOptions{IncludeResolved: true, IncludeOutdated: true, IncludeGeneral: true})
+	if err != nil {
+		t.Fatalf("GraphQL fetch: %v", err)
+	}
+	rest, err := fetchRESTResponse(NewGitHubClientFromTokenSource(ctx, src, host), ref)
+	if err != nil {
+		t.Fatalf("REST fetch: %v", err)
+	}
+	return graphQL, rest
+}
+
+func TestRecordReplayRoundTrip(t *testing.T) {
+	server, host := newFakeGitHub(t, func(s *fakegithub.Server) { s.PageSize = 1 })
+	dir := t.TempDir()
+
+	// Record with an app installation token, whose exchange mustn't be recorded
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordCtx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: &RecordingTransport{Dir: dir}})
+	src := NewAppTokenSource(recordCtx, AppCredentials{AppID: 1, InstallationID: 42, PrivateKey: key}, restAPIBaseURL(host))
+	recordedGraphQL, recordedREST := fetchThrough(t, &RecordingTransport{Dir: dir}, src, host)
+	requests := server.Requests()
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The token exchange counts against the rate limit but isn't recorded
+	if len(files) == 0 || len(files) != requests-1 {
+		t.Errorf("recorded %d exchanges of %d requests, want all but the token exchange", len(files), requests)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{"access_tokens", "ghs_fakeinstallationtoken", "Authorization"} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("%s contains %q", file.Name(), secret)
+			}
+		}
+	}
+
+	// Replay answers the same requests without the server or a real token
+	replayedGraphQL, replayedREST := fetchThrough(t, &ReplayTransport{Dir: dir}, tokenSource(replayToken), host)
+	if server.Requests() != requests {
+		t.Errorf("replay sent %d requests to the server", server.Requests()-requests)
+	}
+	if !reflect.DeepEqual(replayedGraphQL, recordedGraphQL) {
+		t.Errorf("replayed GraphQL response differs:\n%+v\nrecorded:\n%+v", replayedGraphQL, recordedGraphQL)
+	}
+	if !reflect.DeepEqual(replayedREST, recordedREST) {
+		t.Errorf("replayed REST response differs:\n%+v\nrecorded:\n%+v", replayedREST, recordedREST)
+	}
+}
+
+func TestReplayMissingRecording(t *testing.T) {
+	_, host := newFakeGitHub(t, nil)
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: &ReplayTransport{Dir: t.TempDir()}})
+	client := NewGitHubClientFromTokenSource(ctx, tokenSource(replayToken), host)
+
+	_, err := client.FetchPRComments("octo-org", "widgets", 1)
+	var cliErr *CLIError
+	if !errors.As(err, &cliErr) || !strings.Contains(cliErr.Error(), "no recorded response for GET /api/v3/repos/octo-org/widgets/pulls/1/comments") {
+		t.Errorf("err = %v, want the missing recording named", err)
+	}
+}
+
+func TestTrafficFlagsContext(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		record  string
+		replay  string
+		wantErr string
+	}{
+		{name: "record", record: filepath.Join(dir, "new")},
+		{name: "replay", replay: dir},
+		{name: "both", record: dir, replay: dir, wantErr: "--record and --replay can't be used together"},
+		{name: "missing replay directory", replay: filepath.Join(dir, "missing"), wantErr: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, noCache := time.Duration(defaultCacheTTL), true
+			flags := TrafficFlags{Record: &tt.record, Replay: &tt.replay, CacheTTL: &ttl, NoCache: &noCache}
+			_, err := flags.Context(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Context: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || ErrorKindOf(err) != KindUsage {
+				t.Errorf("err = %v, want a usage error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
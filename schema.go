@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSON output versions accepted by --json-version
+const (
+	// JSONVersion1 is the original shape: no schema_version, empty lists and
+	// zero summary counts are left out, and fields added since are never written
+	JSONVersion1 = 1
+	// JSONVersion2 adds schema_version, categories, filters and bot flags, and
+	// always includes the thread lists and summary counts
+	JSONVersion2 = 2
+
+	// DefaultJSONVersion is written when --json-version isn't given, so
+	// existing consumers keep the original shape until they opt in
+	DefaultJSONVersion = JSONVersion1
+)
+
+// PRCommentsResponseV1 is the version 1 shape of the json format. It is
+// frozen: new fields go in version 2 only
+type PRCommentsResponseV1 struct {
+	PRNumber int    `json:"pr_number"`
+	Owner    string `json:"owner"`
+	Repo     string `json:"repo"`
+	// Legacy flat comments (for REST API backward compatibility)
+	Comments []ParsedComment `json:"comments,omitempty"`
+	// Thread-based comments (for GraphQL API)
+	ReviewThreads   []ReviewThreadV1   `json:"review_threads,omitempty"`
+	GeneralComments []GeneralCommentV1 `json:"general_comments,omitempty"`
+	Summary         CommentsSummaryV1  `json:"summary"`
+}
+
+// CommentsSummaryV1 is the version 1 summary, leaving out zero thread counts
+type CommentsSummaryV1 struct {
+	TotalComments int      `json:"total_comments"`
+	FilesAffected []string `json:"files_affected"`
+	Authors       []string `json:"authors"`
+	// Thread-specific statistics (for GraphQL API)
+	UnresolvedThreads int `json:"unresolved_threads,omitempty"`
+	ResolvedThreads   int `json:"resolved_threads,omitempty"`
+	OutdatedThreads   int `json:"outdated_threads,omitempty"`
+	GeneralComments   int `json:"general_comments_count,omitempty"`
+}
+
+// ReviewThreadV1 is the version 1 review thread, without categories
+type ReviewThreadV1 struct {
+	ID           string            `json:"id"`
+	File         string            `json:"file"`
+	LineNew      *int              `json:"line_new,omitempty"`
+	LineOld      *int              `json:"line_old,omitempty"`
+	StartLineNew *int              `json:"start_line_new,omitempty"`
+	StartLineOld *int              `json:"start_line_old,omitempty"`
+	IsResolved   bool              `json:"is_resolved"`
+	IsOutdated   bool              `json:"is_outdated"`
+	Comments     []ThreadCommentV1 `json:"comments"`
+	DiffHunk     string            `json:"diff_hunk,omitempty"`
+}
+
+// ThreadCommentV1 is the version 1 thread comment, without the bot flag
+type ThreadCommentV1 struct {
+	ID        string `json:"id"`
+	Body      string `json:"body"`
+	Author    string `json:"author"`
+	CreatedAt string `json:"created_at"`
+	HTMLURL   string `json:"html_url"`
+	IsReply   bool   `json:"is_reply"`
+}
+
+// GeneralCommentV1 is the version 1 general comment, without the bot flag or category
+type GeneralCommentV1 struct {
+	ID        string `json:"id"`
+	Body      string `json:"body"`
+	Author    string `json:"author"`
+	CreatedAt string `json:"created_at"`
+	HTMLURL   string `json:"html_url"`
+}
+
+// PRCommentsResponseV2 is the version 2 shape of the json format
+type PRCommentsResponseV2 struct {
+	SchemaVersion int    `json:"schema_version"`
+	PRNumber      int    `json:"pr_number"`
+	Owner         string `json:"owner"`
+	Repo          string `json:"repo"`
+	// Legacy flat comments, only present when fetched with the REST API
+	Comments        []ParsedComment   `json:"comments,omitempty"`
+	ReviewThreads   []ReviewThread    `json:"review_threads"`
+	GeneralComments []GeneralComment  `json:"general_comments"`
+	Summary         CommentsSummaryV2 `json:"summary"`
+}
+
+// CommentsSummaryV2 is the version 2 summary, with every count always present
+type CommentsSummaryV2 struct {
+	TotalComments     int            `json:"total_comments"`
+	FilesAffected     []string       `json:"files_affected"`
+	Authors           []string       `json:"authors"`
+	UnresolvedThreads int            `json:"unresolved_threads"`
+	ResolvedThreads   int            `json:"resolved_threads"`
+	OutdatedThreads   int            `json:"outdated_threads"`
+	GeneralComments   int            `json:"general_comments_count"`
+	FiltersApplied    []string       `json:"filters_applied"`
+	Categories        map[string]int `json:"categories"`
+}
+
+// isSupportedJSONVersion reports whether a --json-version value is supported
+func isSupportedJSONVersion(version int) bool {
+	return version == JSONVersion1 || version == JSONVersion2
+}
+
+// VersionedResponse converts a response to the shape of a JSON output version
+func VersionedResponse(response *PRCommentsResponse, version int) interface{} {
+	if version == 0 {
+		version = DefaultJSONVersion
+	}
+	if version == JSONVersion1 {
+		return responseV1(response)
+	}
+
+	threads := make([]ReviewThread, len(response.ReviewThreads))
+	for i, thread := range response.ReviewThreads {
+		if thread.Comments == nil {
+			thread.Comments = []ThreadComment{}
+		}
+		threads[i] = thread
+	}
+
+	summary := response.Summary
+	return &PRCommentsResponseV2{
+		SchemaVersion:   JSONVersion2,
+		PRNumber:        response.PRNumber,
+		Owner:           response.Owner,
+		Repo:            response.Repo,
+		Comments:        response.Comments,
+		ReviewThreads:   threads,
+		GeneralComments: nonNilSlice(response.GeneralComments),
+		Summary: CommentsSummaryV2{
+			TotalComments:     summary.TotalComments,
+			FilesAffected:     nonNilSlice(summary.FilesAffected),
+			Authors:           nonNilSlice(summary.Authors),
+			UnresolvedThreads: summary.UnresolvedThreads,
+			ResolvedThreads:   summary.ResolvedThreads,
+			OutdatedThreads:   summary.OutdatedThreads,
+			GeneralComments:   summary.GeneralComments,
+			FiltersApplied:    nonNilSlice(summary.FiltersApplied),
+			Categories:        nonNilMap(summary.Categories),
+		},
+	}
+}
+
+// responseV1 copies a response into the frozen version 1 shape
+func responseV1(response *PRCommentsResponse) *PRCommentsResponseV1 {
+	var threads []ReviewThreadV1
+	for _, thread := range response.ReviewThreads {
+		var comments []ThreadCommentV1
+		if thread.Comments != nil {
+			comments = make([]ThreadCommentV1, 0, len(thread.Comments))
+		}
+		for _, comment := range thread.Comments {
+			comments = append(comments, ThreadCommentV1{
+				ID:        comment.ID,
+				Body:      comment.Body,
+				Author:    comment.Author,
+				CreatedAt: comment.CreatedAt,
+				HTMLURL:   comment.HTMLURL,
+				IsReply:   comment.IsReply,
+			})
+		}
+		threads = append(threads, ReviewThreadV1{
+			ID:           thread.ID,
+			File:         thread.File,
+			LineNew:      thread.LineNew,
+			LineOld:      thread.LineOld,
+			StartLineNew: thread.StartLineNew,
+			StartLineOld: thread.StartLineOld,
+			IsResolved:   thread.IsResolved,
+			IsOutdated:   thread.IsOutdated,
+			Comments:     comments,
+			DiffHunk:     thread.DiffHunk,
+		})
+	}
+
+	var generalComments []GeneralCommentV1
+	for _, comment := range response.GeneralComments {
+		generalComments = append(generalComments, GeneralCommentV1{
+			ID:        comment.ID,
+			Body:      comment.Body,
+			Author:    comment.Author,
+			CreatedAt: comment.CreatedAt,
+			HTMLURL:   comment.HTMLURL,
+		})
+	}
+
+	summary := response.Summary
+	return &PRCommentsResponseV1{
+		PRNumber:        response.PRNumber,
+		Owner:           response.Owner,
+		Repo:            response.Repo,
+		Comments:        response.Comments,
+		ReviewThreads:   threads,
+		GeneralComments: generalComments,
+		Summary: CommentsSummaryV1{
+			TotalComments:     summary.TotalComments,
+			FilesAffected:     summary.FilesAffected,
+			Authors:           summary.Authors,
+			UnresolvedThreads: summary.UnresolvedThreads,
+			ResolvedThreads:   summary.ResolvedThreads,
+			OutdatedThreads:   summary.OutdatedThreads,
+			GeneralComments:   summary.GeneralComments,
+		},
+	}
+}
+
+// nonNilSlice returns an empty slice instead of nil, so it marshals as []
+func nonNilSlice[T any](values []T) []T {
+	if values == nil {
+		return []T{}
+	}
+	return values
+}
+
+// nonNilMap returns an empty map instead of nil, so it marshals as {}
+func nonNilMap(values map[string]int) map[string]int {
+	if values == nil {
+		return map[string]int{}
+	}
+	return values
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing a version of the json format
+func JSONSchema(version int) (string, error) {
+	if !isSupportedJSONVersion(version) {
+		return "", fmt.Errorf("unsupported JSON version %d", version)
+	}
+
+	var root reflect.Type = reflect.TypeOf(PRCommentsResponseV2{})
+	if version == JSONVersion1 {
+		root = reflect.TypeOf(PRCommentsResponseV1{})
+	}
+
+	generator := &schemaGenerator{defs: make(map[string]interface{})}
+	schema := generator.structSchema(root)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "PRCommentsResponse"
+	schema["description"] = fmt.Sprintf("Output of pr-review-cli fetch --format json --json-version %d", version)
+	if version != JSONVersion1 {
+		// schema_version pins the shape; it is always this version's number
+		schema["properties"].(map[string]interface{})["schema_version"] = map[string]interface{}{"const": version}
+	}
+	schema["$defs"] = generator.defs
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling JSON schema: %w", err)
+	}
+	return string(data), nil
+}
+
+// schemaGenerator builds JSON Schemas from Go types by reflection, following
+// the encoding/json field rules: fields without omitempty are required
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+// typeSchema returns the schema of a field's type; named structs are
+// referenced from $defs
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := g.typeSchema(t.Elem())
+		if ref, ok := schema["$ref"]; ok {
+			return map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"$ref": ref}, map[string]interface{}{"type": "null"}}}
+		}
+		schema["type"] = []interface{}{schema["type"], "null"}
+		return schema
+	case reflect.Struct:
+		name := t.Name()
+		if _, seen := g.defs[name]; !seen {
+			g.defs[name] = nil // guards against recursive types
+			g.defs[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns the object schema of a struct's exported JSON fields
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		omitEmpty := false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, option := range parts[1:] {
+				if option == "omitempty" {
+					omitEmpty = true
+				}
+			}
+		}
+
+		properties[name] = g.typeSchema(field.Type)
+		if !omitEmpty {
+			required = append(required, name)
+		}
+	}
+
+	// Later releases may add fields within a version, so extra properties are allowed
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
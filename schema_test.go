@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenResponse uses every field of the current response, including the
+// ones added after version 1
+func goldenResponse() *PRCommentsResponse {
+	line := func(n int) *int { return &n }
+	return &PRCommentsResponse{
+		PRNumber: 1,
+		Owner:    "octo-org",
+		Repo:     "widgets",
+		ReviewThreads: []ReviewThread{{
+			ID:       "PRRT_1",
+			File:     "cache.go",
+			LineNew:  line(12),
+			DiffHunk: "@@ -10,2 +10,3 @@\n entries map[string]Widget\n+hits int",
+			Category: CategoryBlocking,
+			Conventional: &ConventionalComment{
+				Label:       "issue",
+				Decorations: []string{"blocking"},
+				Subject:     "this map needs a mutex",
+				Blocking:    true,
+			},
+			Comments: []ThreadComment{
+				{ID: "PRRC_1", Body: "issue (blocking): this map needs a mutex", Author: "octocat", CreatedAt: "2026-01-02 03:04:05", HTMLURL: "https://github.com/octo-org/widgets/pull/1#discussion_r1"},
+				{ID: "PRRC_2", Body: "Flagged by the linter too.", Author: "lint-bot", CreatedAt: "2026-01-02 04:04:05", HTMLURL: "https://github.com/octo-org/widgets/pull/1#discussion_r2", IsReply: true, IsBot: true},
+			},
+		}},
+		GeneralComments: []GeneralComment{
+			{ID: "IC_1", Body: "Please add a benchmark.", Author: "octocat", CreatedAt: "2026-01-02 05:04:05", HTMLURL: "https://github.com/octo-org/widgets/pull/1#issuecomment-1", Category: CategoryComment},
+		},
+		Summary: CommentsSummary{
+			TotalComments:     3,
+			FilesAffected:     []string{"cache.go"},
+			Authors:           []string{"lint-bot", "octocat"},
+			UnresolvedThreads: 1,
+			GeneralComments:   1,
+			FiltersApplied:    []string{"unresolved-only"},
+			Categories:        map[string]int{CategoryBlocking: 1},
+		},
+	}
+}
+
+func TestJSONVersionGolden(t *testing.T) {
+	tests := []struct {
+		version int
+		golden  string
+	}{
+		{JSONVersion1, "json_v1.golden"},
+		{JSONVersion2, "json_v2.golden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			got, err := formatJSONVersion(goldenResponse(), tt.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, []byte(got+"\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if got+"\n" != string(want) {
+				t.Errorf("json --json-version %d changed shape:\n%s\nwant:\n%s", tt.version, got, want)
+			}
+		})
+	}
+}
+
+func TestJSONVersionDefault(t *testing.T) {
+	got, err := formatJSONVersion(goldenResponse(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, _ := formatJSONVersion(goldenResponse(), JSONVersion1)
+	if got != v1 {
+		t.Errorf("default json version isn't version 1:\n%s", got)
+	}
+}
+
+func TestJSONSchemaMatchesVersion(t *testing.T) {
+	for _, version := range []int{JSONVersion1, JSONVersion2} {
+		output, err := JSONSchema(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schema struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+			Defs       map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"$defs"`
+		}
+		if err := json.Unmarshal([]byte(output), &schema); err != nil {
+			t.Fatal(err)
+		}
+		_, hasVersion := schema.Properties["schema_version"]
+		if hasVersion != (version == JSONVersion2) {
+			t.Errorf("version %d schema has schema_version = %v", version, hasVersion)
+		}
+		hasCategory := false
+		for _, def := range schema.Defs {
+			if _, ok := def.Properties["category"]; ok {
+				hasCategory = true
+			}
+		}
+		if hasCategory != (version == JSONVersion2) {
+			t.Errorf("version %d schema has category = %v", version, hasCategory)
+		}
+	}
+
+	if _, err := JSONSchema(3); err == nil {
+		t.Error("JSONSchema(3) succeeded")
+	}
+}
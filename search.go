@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// SearchPRQuery builds the GitHub search query for the PRs of a repository in a given state
+func SearchPRQuery(owner, repo, state string) (string, error) {
+	if owner == "" || repo == "" {
+		return "", fmt.Errorf("--owner and --repo are required")
+	}
+
+	query := fmt.Sprintf("repo:%s/%s is:pr", owner, repo)
+	switch state {
+	case "all":
+	case "open", "closed", "merged":
+		query += " is:" + state
+	default:
+		return "", fmt.Errorf("invalid state '%s'. Must be one of: open, closed, merged, all", state)
+	}
+
+	return query, nil
+}
+
+// CollectSearchMatches extracts the comments matching the pattern from a
+// PR's threads and general comments
+func CollectSearchMatches(pr PullRequestSearchResult, response *PRCommentsResponse, pattern *regexp.Regexp, searchDiffHunks bool) []SearchMatch {
+	var matches []SearchMatch
+
+	for _, thread := range sortReviewThreads(response.ReviewThreads) {
+		line := thread.LineNew
+		if line == nil {
+			line = thread.LineOld
+		}
+
+		for i, comment := range thread.Comments {
+			inBody := pattern.MatchString(comment.Body)
+			inDiff := i == 0 && searchDiffHunks && pattern.MatchString(thread.DiffHunk)
+			if !inBody && !inDiff {
+				continue
+			}
+
+			matches = append(matches, SearchMatch{
+				Owner:      pr.Owner,
+				Repo:       pr.Repo,
+				PRNumber:   pr.Number,
+				PRTitle:    pr.Title,
+				ThreadID:   thread.ID,
+				File:       thread.File,
+				Line:       line,
+				IsResolved: thread.IsResolved,
+				Author:     comment.Author,
+				CreatedAt:  comment.CreatedAt,
+				Body:       comment.Body,
+				HTMLURL:    comment.HTMLURL,
+				InDiffHunk: !inBody,
+			})
+		}
+	}
+
+	for _, comment := range response.GeneralComments {
+		if !pattern.MatchString(comment.Body) {
+			continue
+		}
+		matches = append(matches, SearchMatch{
+			Owner:     pr.Owner,
+			Repo:      pr.Repo,
+			PRNumber:  pr.Number,
+			PRTitle:   pr.Title,
+			Author:    comment.Author,
+			CreatedAt: comment.CreatedAt,
+			Body:      comment.Body,
+			HTMLURL:   comment.HTMLURL,
+		})
+	}
+
+	return matches
+}
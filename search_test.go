@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSearchPRQuery(t *testing.T) {
+	tests := []struct {
+		owner, repo, state string
+		want               string
+		wantErr            bool
+	}{
+		{owner: "octo-org", repo: "widgets", state: "all", want: "repo:octo-org/widgets is:pr"},
+		{owner: "octo-org", repo: "widgets", state: "merged", want: "repo:octo-org/widgets is:pr is:merged"},
+		{owner: "octo-org", repo: "widgets", state: "draft", wantErr: true},
+		{owner: "octo-org", state: "open", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := SearchPRQuery(tt.owner, tt.repo, tt.state)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s/%s %s: got %q, want an error", tt.owner, tt.repo, tt.state, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s/%s %s: got %q, %v; want %q", tt.owner, tt.repo, tt.state, got, err, tt.want)
+		}
+	}
+}
+
+func TestCompileGrepPattern(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		fixed      bool
+		ignoreCase bool
+		text       string
+		want       bool
+		wantErr    bool
+	}{
+		{pattern: "TODO", text: "a TODO here", want: true},
+		{pattern: "todo", text: "a TODO here", want: false},
+		{pattern: "todo", ignoreCase: true, text: "a TODO here", want: true},
+		{pattern: "a.b", fixed: true, text: "axb", want: false},
+		{pattern: "a.b", fixed: true, text: "a.b", want: true},
+		{pattern: "deprecat(ed|ion)", text: "deprecation warning", want: true},
+		{pattern: "(", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		re, err := CompileGrepPattern(tt.pattern, tt.fixed, tt.ignoreCase)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("CompileGrepPattern(%q): want an error", tt.pattern)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("CompileGrepPattern(%q): %v", tt.pattern, err)
+			continue
+		}
+		if got := re.MatchString(tt.text); got != tt.want {
+			t.Errorf("pattern %q (fixed %v, -i %v) on %q = %v, want %v", tt.pattern, tt.fixed, tt.ignoreCase, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestKeepThreadGrep(t *testing.T) {
+	thread := ReviewThread{
+		DiffHunk: "@@ -1 +1 @@\n+legacyCall()",
+		Comments: []ThreadComment{{Body: "Looks fine"}, {Body: "Add a TODO for the cleanup"}},
+	}
+
+	tests := []struct {
+		name string
+		opts FetchOptions
+		want bool
+	}{
+		{name: "no pattern", want: true},
+		{name: "match in a reply", opts: FetchOptions{Grep: regexp.MustCompile("TODO")}, want: true},
+		{name: "no match", opts: FetchOptions{Grep: regexp.MustCompile("FIXME")}, want: false},
+		{name: "diff hunk not searched", opts: FetchOptions{Grep: regexp.MustCompile("legacyCall")}, want: false},
+		{name: "diff hunk searched", opts: FetchOptions{Grep: regexp.MustCompile("legacyCall"), GrepDiffHunks: true}, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.opts.keepThreadGrep(thread); got != tt.want {
+			t.Errorf("%s: kept = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	tests := []struct {
+		text    string
+		pattern *regexp.Regexp
+		want    string
+	}{
+		{text: "add a TODO", want: "add a TODO"},
+		{text: "todo and TODO", pattern: regexp.MustCompile("(?i)todo"), want: iconMatchStart + "todo" + iconMatchEnd + " and " + iconMatchStart + "TODO" + iconMatchEnd},
+		{text: "abc", pattern: regexp.MustCompile("x*"), want: "abc"},
+	}
+
+	for _, tt := range tests {
+		if got := highlightMatches(tt.text, tt.pattern); got != tt.want {
+			t.Errorf("highlightMatches(%q, %v) = %q, want %q", tt.text, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestSearchAcrossPullRequests(t *testing.T) {
+	_, host := newFakeGitHub(t, nil)
+	client := NewGitHubGraphQLClientFromTokenSource(context.Background(), tokenSource("test"), host)
+
+	pullRequests, err := client.SearchPullRequests(context.Background(), "repo:octo-org/widgets is:pr", 0)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+
+	tests := []struct {
+		pattern   string
+		diffHunks bool
+		want      []string
+	}{
+		{pattern: "(?i)mutex|benchmark", want: []string{"cache.go:octocat:false", ":octocat:false"}},
+		{pattern: "hits", want: nil},
+		{pattern: "hits", diffHunks: true, want: []string{"cache.go:octocat:true"}},
+		{pattern: "Typo", want: []string{"docs/cache.md:lint-bot:false"}},
+	}
+
+	for _, tt := range tests {
+		pattern := regexp.MustCompile(tt.pattern)
+		opts := FetchOptions{IncludeResolved: true, IncludeOutdated: true, IncludeGeneral: true, Grep: pattern, GrepDiffHunks: tt.diffHunks}
+
+		var got []string
+		for _, pr := range pullRequests {
+			response, err := fetchGraphQLResponse(context.Background(), client, PRRef{Owner: pr.Owner, Repo: pr.Repo, Number: pr.Number}, opts)
+			if err != nil {
+				t.Fatalf("fetch %d: %v", pr.Number, err)
+			}
+			for _, match := range CollectSearchMatches(pr, response, pattern, tt.diffHunks) {
+				if match.PRNumber != 1 || match.PRTitle != "Add caching" {
+					t.Errorf("%q: match on PR %d %q", tt.pattern, match.PRNumber, match.PRTitle)
+				}
+				got = append(got, fmt.Sprintf("%s:%s:%v", match.File, match.Author, match.InDiffHunk))
+			}
+		}
+
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%q: matches %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
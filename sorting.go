@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Thread sort modes accepted by --sort
+const (
+	SortFile     = "file"     // file, then unresolved first, then line (default)
+	SortPriority = "priority" // category (blocking first), then reviewer weight and age
+	SortAge      = "age"      // oldest thread first
+	SortActivity = "activity" // most recently active thread first
+	SortReviewer = "reviewer" // by the reviewer who started the thread
+)
+
+// validSortModes lists the accepted --sort values in help order
+var validSortModes = []string{SortFile, SortPriority, SortAge, SortActivity, SortReviewer}
+
+// Claude format layouts accepted by --layout
+const (
+	LayoutFiles  = "files"  // one section per file
+	LayoutRanked = "ranked" // a single ranked task list
+)
+
+// isValidSortMode reports whether a --sort value is supported
+func isValidSortMode(mode string) bool {
+	for _, valid := range validSortModes {
+		if mode == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseReviewerWeights parses --reviewer-weight values of the form login=weight
+func ParseReviewerWeights(values []string) (map[string]int, error) {
+	weights := make(map[string]int, len(values))
+	for _, value := range values {
+		login, weight, found := strings.Cut(value, "=")
+		if !found || strings.TrimSpace(login) == "" {
+			return nil, fmt.Errorf("invalid reviewer weight %q (expected LOGIN=WEIGHT)", value)
+		}
+
+		parsed, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil {
+			return nil, fmt.Errorf("invalid reviewer weight %q: %w", value, err)
+		}
+		weights[normalizeLogin(login)] = parsed
+	}
+	return weights, nil
+}
+
+// SortThreads orders threads according to a --sort mode. Every mode starts
+// from file order, which breaks its ties.
+func SortThreads(threads []ReviewThread, mode string, reviewerWeights map[string]int) []ReviewThread {
+	sorted := sortReviewThreads(threads)
+
+	switch mode {
+	case SortPriority:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			a := sorted[i]
+			b := sorted[j]
+
+			// Blocking is the most pressing category
+			if categoryRank(a.Category) != categoryRank(b.Category) {
+				return categoryRank(a.Category) < categoryRank(b.Category)
+			}
+
+			weightA := reviewerWeights[normalizeLogin(threadStarter(a))]
+			weightB := reviewerWeights[normalizeLogin(threadStarter(b))]
+			if weightA != weightB {
+				return weightA > weightB
+			}
+
+			return threadCreatedAt(a).Before(threadCreatedAt(b))
+		})
+	case SortAge:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return threadCreatedAt(sorted[i]).Before(threadCreatedAt(sorted[j]))
+		})
+	case SortActivity:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return threadLastActivity(sorted[i]).After(threadLastActivity(sorted[j]))
+		})
+	case SortReviewer:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return strings.ToLower(threadStarter(sorted[i])) < strings.ToLower(threadStarter(sorted[j]))
+		})
+	}
+
+	return sorted
+}
+
+// threadStarter returns the login of the reviewer who opened the thread
+func threadStarter(thread ReviewThread) string {
+	if len(thread.Comments) == 0 {
+		return ""
+	}
+	return thread.Comments[0].Author
+}
+
+// threadCreatedAt returns when the thread's first comment was made
+func threadCreatedAt(thread ReviewThread) time.Time {
+	if len(thread.Comments) == 0 {
+		return time.Time{}
+	}
+	t, _ := parseCommentTime(thread.Comments[0].CreatedAt)
+	return t
+}
+
+// threadLastActivity returns when the thread's latest comment was made
+func threadLastActivity(thread ReviewThread) time.Time {
+	var latest time.Time
+	for _, comment := range thread.Comments {
+		if t, ok := parseCommentTime(comment.CreatedAt); ok && t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
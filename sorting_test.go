@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseReviewerWeights(t *testing.T) {
+	tests := []struct {
+		values  []string
+		want    map[string]int
+		wantErr bool
+	}{
+		{values: nil, want: map[string]int{}},
+		{values: []string{"alice=3", "@Bob = -1", "renovate[bot]=0"}, want: map[string]int{"alice": 3, "bob": -1, "renovate": 0}},
+		{values: []string{"alice"}, wantErr: true},
+		{values: []string{"=3"}, wantErr: true},
+		{values: []string{"alice=high"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseReviewerWeights(tt.values)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseReviewerWeights(%q) = %v, want an error", tt.values, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseReviewerWeights(%q) = %v, %v; want %v", tt.values, got, err, tt.want)
+		}
+	}
+}
+
+func TestSortThreads(t *testing.T) {
+	line := func(n int) *int { return &n }
+	thread := func(id, file string, n int, resolved bool, category, author, created string) ReviewThread {
+		return ReviewThread{
+			ID:         id,
+			File:       file,
+			LineNew:    line(n),
+			IsResolved: resolved,
+			Category:   category,
+			Comments:   []ThreadComment{{Author: author, CreatedAt: created}},
+		}
+	}
+	threads := []ReviewThread{
+		thread("nit", "b.go", 5, false, CategoryNit, "alice", "2026-01-03 00:00:00"),
+		thread("question", "a.go", 20, false, CategoryQuestion, "bob", "2026-01-01 00:00:00"),
+		thread("resolved", "a.go", 1, true, CategoryBlocking, "alice", "2026-01-04 00:00:00"),
+		thread("blocking", "b.go", 9, false, CategoryBlocking, "carol", "2026-01-02 00:00:00"),
+		thread("suggestion", "a.go", 10, false, CategorySuggestion, "carol", "2026-01-05 00:00:00"),
+	}
+
+	tests := []struct {
+		mode    string
+		weights map[string]int
+		want    string
+	}{
+		{mode: "", want: "suggestion,question,resolved,nit,blocking"},
+		{mode: SortFile, want: "suggestion,question,resolved,nit,blocking"},
+		{mode: SortPriority, want: "blocking,resolved,question,suggestion,nit"},
+		{mode: SortPriority, weights: map[string]int{"alice": 5}, want: "resolved,blocking,question,suggestion,nit"},
+		{mode: SortAge, want: "question,blocking,nit,resolved,suggestion"},
+		{mode: SortActivity, want: "suggestion,resolved,nit,blocking,question"},
+		{mode: SortReviewer, want: "resolved,nit,question,suggestion,blocking"},
+	}
+
+	for _, tt := range tests {
+		var ids []string
+		for _, thread := range SortThreads(threads, tt.mode, tt.weights) {
+			ids = append(ids, thread.ID)
+		}
+		if got := strings.Join(ids, ","); got != tt.want {
+			t.Errorf("--sort %s: %s, want %s", tt.mode, got, tt.want)
+		}
+	}
+}
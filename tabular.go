@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// tableColumns lists the columns available to --columns, in their default order
+var tableColumns = []string{
+	"thread_id", "file", "line", "resolved", "outdated", "author", "created_at", "is_reply", "body", "url",
+	"comment_id", "repo", "pr", "category", "is_bot",
+}
+
+// defaultTableColumns are written when --columns isn't given
+var defaultTableColumns = tableColumns[:10]
+
+// tableRow holds one comment's values keyed by column name
+type tableRow map[string]string
+
+// isTabularFormat reports whether a format writes one row per comment
+func isTabularFormat(format string) bool {
+	return format == "csv" || format == "tsv"
+}
+
+// ParseTableColumns validates a --columns selection; empty means the default columns
+func ParseTableColumns(columns []string) ([]string, error) {
+	if len(columns) == 0 {
+		return defaultTableColumns, nil
+	}
+
+	for _, column := range columns {
+		known := false
+		for _, valid := range tableColumns {
+			if column == valid {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column %q (expected any of: %s)", column, strings.Join(tableColumns, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// formatTableV2 outputs one row per comment as CSV or TSV. CSV fields are
+// quoted as needed; TSV escapes tabs, newlines and backslashes in bodies so
+// every comment stays on one line.
+func formatTableV2(response *PRCommentsResponse, format string, opts FormatOptions) (string, error) {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = defaultTableColumns
+	}
+
+	var output strings.Builder
+	writeRow := func(values []string) error {
+		if format == "tsv" {
+			escaped := make([]string, len(values))
+			for i, value := range values {
+				escaped[i] = escapeTSVField(value)
+			}
+			output.WriteString(strings.Join(escaped, "\t") + "\n")
+			return nil
+		}
+
+		writer := csv.NewWriter(&output)
+		if err := writer.Write(values); err != nil {
+			return fmt.Errorf("writing CSV: %w", err)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	if err := writeRow(columns); err != nil {
+		return "", err
+	}
+
+	for _, row := range tableRows(response, opts) {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = row[column]
+		}
+		if err := writeRow(values); err != nil {
+			return "", err
+		}
+	}
+
+	return output.String(), nil
+}
+
+// tableRows flattens review threads and general comments into one row per comment
+func tableRows(response *PRCommentsResponse, opts FormatOptions) []tableRow {
+	repo := fmt.Sprintf("%s/%s", response.Owner, response.Repo)
+	pr := strconv.Itoa(response.PRNumber)
+
+	var rows []tableRow
+	for _, thread := range SortThreads(response.ReviewThreads, opts.Sort, opts.ReviewerWeights) {
+		line := ""
+		if number := threadLineNumber(thread); number != math.MaxInt32 {
+			line = strconv.Itoa(number)
+		}
+
+		for _, comment := range thread.Comments {
+			rows = append(rows, tableRow{
+				"thread_id":  thread.ID,
+				"file":       thread.File,
+				"line":       line,
+				"resolved":   strconv.FormatBool(thread.IsResolved),
+				"outdated":   strconv.FormatBool(thread.IsOutdated),
+				"author":     comment.Author,
+				"created_at": comment.CreatedAt,
+				"is_reply":   strconv.FormatBool(comment.IsReply),
+				"body":       comment.Body,
+				"url":        comment.HTMLURL,
+				"comment_id": comment.ID,
+				"repo":       repo,
+				"pr":         pr,
+				"category":   thread.Category,
+				"is_bot":     strconv.FormatBool(comment.IsBot),
+			})
+		}
+	}
+
+	for _, comment := range response.GeneralComments {
+		rows = append(rows, tableRow{
+			"author":     comment.Author,
+			"created_at": comment.CreatedAt,
+			"is_reply":   "false",
+			"body":       comment.Body,
+			"url":        comment.HTMLURL,
+			"comment_id": comment.ID,
+			"repo":       repo,
+			"pr":         pr,
+			"category":   comment.Category,
+			"is_bot":     strconv.FormatBool(comment.IsBot),
+		})
+	}
+
+	return rows
+}
+
+// escapeTSVField escapes the characters that would break a TSV row
+func escapeTSVField(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		"\t", `\t`,
+		"\r", `\r`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// dropTableHeader removes the header row from a CSV or TSV output, so
+// several PRs can be written as one table
+func dropTableHeader(output string) string {
+	if _, rows, found := strings.Cut(output, "\n"); found {
+		return rows
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTableColumns(t *testing.T) {
+	tests := []struct {
+		columns []string
+		want    []string
+		wantErr string
+	}{
+		{columns: nil, want: defaultTableColumns},
+		{columns: []string{"repo", "pr", "body"}, want: []string{"repo", "pr", "body"}},
+		{columns: []string{"file", "lines"}, wantErr: `unknown column "lines"`},
+		{columns: []string{"Body"}, wantErr: `unknown column "Body"`},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTableColumns(tt.columns)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q: err = %v, want %q", tt.columns, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q, %v; want %q", tt.columns, got, err, tt.want)
+		}
+	}
+}
+
+// tableResponse has a thread whose bodies need quoting or escaping and a general comment
+func tableResponse() *PRCommentsResponse {
+	line := 7
+	return &PRCommentsResponse{
+		Owner: "octo-org", Repo: "widgets", PRNumber: 3,
+		ReviewThreads: []ReviewThread{{
+			ID: "PRRT_1", File: "a,b.go", LineNew: &line, Category: CategoryQuestion,
+			Comments: []ThreadComment{
+				{ID: "C1", Author: "octocat", Body: "Why \"x\",\nnot y?", CreatedAt: "2026-03-01 10:00:00"},
+				{ID: "C2", Author: "hubot", Body: "tab\there \\n literal", IsReply: true, CreatedAt: "2026-03-01 11:00:00"},
+			},
+		}},
+		GeneralComments: []GeneralComment{{ID: "IC1", Author: "ci[bot]", IsBot: true, Body: "Build passed", CreatedAt: "2026-03-02 09:00:00"}},
+	}
+}
+
+func TestFormatCSV(t *testing.T) {
+	columns := []string{"thread_id", "file", "line", "author", "is_reply", "body", "category", "is_bot", "pr"}
+	output, err := formatTableV2(tableResponse(), "csv", FormatOptions{Columns: columns})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the CSV back: %v\n%s", err, output)
+	}
+	want := [][]string{
+		columns,
+		{"PRRT_1", "a,b.go", "7", "octocat", "false", "Why \"x\",\nnot y?", "question", "false", "3"},
+		{"PRRT_1", "a,b.go", "7", "hubot", "true", "tab\there \\n literal", "question", "false", "3"},
+		{"", "", "", "ci[bot]", "false", "Build passed", "", "true", "3"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+}
+
+func TestFormatTSV(t *testing.T) {
+	output, err := formatTableV2(tableResponse(), "tsv", FormatOptions{Columns: []string{"author", "body"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "author\tbody\n" +
+		"octocat\tWhy \"x\",\\nnot y?\n" +
+		"hubot\ttab\\there \\\\n literal\n" +
+		"ci[bot]\tBuild passed\n"
+	if output != want {
+		t.Errorf("got %q, want %q", output, want)
+	}
+	if rows := dropTableHeader(output); rows != strings.TrimPrefix(want, "author\tbody\n") {
+		t.Errorf("dropTableHeader = %q", rows)
+	}
+}
+
+func TestEscapeTSVField(t *testing.T) {
+	tests := map[string]string{
+		"plain":         "plain",
+		"a\tb":          `a\tb`,
+		"line\r\nbreak": `line\r\nbreak`,
+		`C:\dir`:        `C:\\dir`,
+		`already \n`:    `already \\n`,
+	}
+	for value, want := range tests {
+		if got := escapeTSVField(value); got != want {
+			t.Errorf("escapeTSVField(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// LoadOutputTemplate parses a --template file for the template format
+func LoadOutputTemplate(path string) (*template.Template, error) {
+	tmpl, err := template.ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// formatTemplateV2 renders the response with the --template file. The
+// template is given the response itself, so it sees the same fields as the
+// json format under their Go names, e.g. {{range .ReviewThreads}}{{.File}}{{end}}
+func formatTemplateV2(response *PRCommentsResponse, opts FormatOptions) (string, error) {
+	if opts.Template == nil {
+		return "", fmt.Errorf("the template format requires --template")
+	}
+
+	// Threads keep their fetch order unless a sort was asked for
+	if opts.Sort != "" {
+		sorted := *response
+		sorted.ReviewThreads = SortThreads(response.ReviewThreads, opts.Sort, opts.ReviewerWeights)
+		response = &sorted
+	}
+
+	var output strings.Builder
+	if err := opts.Template.Execute(&output, response); err != nil {
+		return "", fmt.Errorf("rendering template: %w", err)
+	}
+	return output.String(), nil
+}
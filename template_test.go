@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "fields of the response",
+			text: "PR #{{.PRNumber}} {{.Owner}}/{{.Repo}}: {{.Summary.UnresolvedThreads}} unresolved\n{{range .ReviewThreads}}{{.File}}:{{.LineNew}} {{.Category}}\n{{end}}",
+			want: "PR #1 octo-org/widgets: 1 unresolved\ncache.go:12 blocking\n",
+		},
+		{
+			name: "comments",
+			text: "{{range .ReviewThreads}}{{range .Comments}}{{.Author}}{{if .IsBot}} (bot){{end}};{{end}}{{end}}",
+			want: "octocat;lint-bot (bot);",
+		},
+		{
+			name:    "unknown field",
+			text:    "{{.Threads}}",
+			wantErr: "rendering template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "review.tmpl")
+			writeFile(t, path, tt.text)
+			tmpl, err := LoadOutputTemplate(path)
+			if err != nil {
+				t.Fatalf("LoadOutputTemplate: %v", err)
+			}
+
+			got, err := FormatCommentsV2(goldenResponse(), "template", FormatOptions{Template: tmpl})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadOutputTemplateErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadOutputTemplate(filepath.Join(dir, "missing.tmpl")); err == nil {
+		t.Error("loading a missing template succeeded")
+	}
+
+	path := filepath.Join(dir, "broken.tmpl")
+	writeFile(t, path, "{{range .ReviewThreads}}")
+	if _, err := LoadOutputTemplate(path); err == nil || !strings.Contains(err.Error(), "loading template") {
+		t.Errorf("err = %v, want a parse error", err)
+	}
+
+	if _, err := FormatCommentsV2(goldenResponse(), "template", FormatOptions{}); err == nil {
+		t.Error("the template format without a template succeeded")
+	}
+}
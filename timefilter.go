@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// commentTimeLayout is the layout comment timestamps are stored with
+const commentTimeLayout = "2006-01-02 15:04:05"
+
+// Values accepted by FetchOptions.TimeField
+const (
+	timeFieldCreated  = "created"
+	timeFieldActivity = "activity"
+)
+
+// ParseTimeBound parses a --since/--until value: an RFC3339 timestamp, a date
+// (YYYY-MM-DD, local time) or a duration before now such as "90m", "2h", "3d"
+// or "1w". With endOfDay, a bare date refers to the end of that day so that
+// "--until 2024-05-01" includes comments made on May 1st.
+func ParseTimeBound(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+		}
+		return t, nil
+	}
+
+	if d, err := parseRelativeDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q (expected RFC3339 timestamp, YYYY-MM-DD date, or duration like 2h, 3d, 1w)", value)
+}
+
+// parseRelativeDuration extends time.ParseDuration with day (d) and week (w) units
+func parseRelativeDuration(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(value, suffix) {
+			count, err := strconv.ParseFloat(strings.TrimSuffix(value, suffix), 64)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			return time.Duration(count * float64(unit)), nil
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
+
+// parseCommentTime parses a stored comment timestamp
+func parseCommentTime(value string) (time.Time, bool) {
+	t, err := time.Parse(commentTimeLayout, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// hasTimeFilters reports whether a time window is active
+func (o FetchOptions) hasTimeFilters() bool {
+	return !o.Since.IsZero() || !o.Until.IsZero()
+}
+
+// inTimeWindow reports whether a timestamp falls within --since/--until
+func (o FetchOptions) inTimeWindow(t time.Time) bool {
+	if !o.Since.IsZero() && t.Before(o.Since) {
+		return false
+	}
+	if !o.Until.IsZero() && t.After(o.Until) {
+		return false
+	}
+	return true
+}
+
+// threadTime returns the time a thread is matched on: when its first comment
+// was made, or with TimeField "activity", when its latest comment was made
+func (o FetchOptions) threadTime(thread ReviewThread) (time.Time, bool) {
+	var result time.Time
+	found := false
+
+	for i, comment := range thread.Comments {
+		t, ok := parseCommentTime(comment.CreatedAt)
+		if !ok {
+			continue
+		}
+		if o.TimeField != timeFieldActivity {
+			if i == 0 {
+				return t, true
+			}
+			continue
+		}
+		if !found || t.After(result) {
+			result = t
+			found = true
+		}
+	}
+
+	return result, found
+}
+
+// keepThreadTime applies the time window to a thread
+func (o FetchOptions) keepThreadTime(thread ReviewThread) bool {
+	if !o.hasTimeFilters() {
+		return true
+	}
+	t, ok := o.threadTime(thread)
+	return ok && o.inTimeWindow(t)
+}
+
+// keepGeneralCommentTime applies the time window to a general comment
+func (o FetchOptions) keepGeneralCommentTime(comment GeneralComment) bool {
+	if !o.hasTimeFilters() {
+		return true
+	}
+	t, ok := parseCommentTime(comment.CreatedAt)
+	return ok && o.inTimeWindow(t)
+}
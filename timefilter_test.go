@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"pr-review-cli/fakegithub"
+)
+
+// inZone runs the test with time.Local set to a fixed zone
+func inZone(t *testing.T, name string, offsetHours int) {
+	t.Helper()
+	saved := time.Local
+	time.Local = time.FixedZone(name, offsetHours*60*60)
+	t.Cleanup(func() { time.Local = saved })
+}
+
+func TestParseTimeBound(t *testing.T) {
+	inZone(t, "CEST", 2)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		endOfDay bool
+		want     time.Time
+		wantErr  bool
+	}{
+		{value: "2026-03-01T08:30:00Z", want: time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{value: "2026-03-01T08:30:00-05:00", want: time.Date(2026, 3, 1, 13, 30, 0, 0, time.UTC)},
+		{value: "2026-03-01T08:30:00Z", endOfDay: true, want: time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)},
+		// Bare dates are midnight local time, 22:00 UTC the day before
+		{value: "2026-03-01", want: time.Date(2026, 2, 28, 22, 0, 0, 0, time.UTC)},
+		{value: " 2026-03-01 ", endOfDay: true, want: time.Date(2026, 3, 1, 21, 59, 59, 999999999, time.UTC)},
+		{value: "90m", want: now.Add(-90 * time.Minute)},
+		{value: "2h", want: now.Add(-2 * time.Hour)},
+		{value: "3d", want: now.Add(-72 * time.Hour)},
+		{value: "1.5d", want: now.Add(-36 * time.Hour)},
+		{value: "1w", want: now.Add(-7 * 24 * time.Hour)},
+		{value: "-2h", wantErr: true},
+		{value: "-1d", wantErr: true},
+		{value: "yesterday", wantErr: true},
+		{value: "2026-13-01", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTimeBound(tt.value, now, tt.endOfDay)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseTimeBound(%q) = %v, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseTimeBound(%q, endOfDay %v) = %v, %v; want %v", tt.value, tt.endOfDay, got.UTC(), err, tt.want)
+		}
+	}
+}
+
+func TestKeepThreadTime(t *testing.T) {
+	inZone(t, "CEST", 2)
+	now := time.Now()
+	since, _ := ParseTimeBound("2026-03-10", now, false)
+	until, _ := ParseTimeBound("2026-03-10", now, true)
+
+	// Comment times are stored in UTC
+	thread := func(times ...string) ReviewThread {
+		var comments []ThreadComment
+		for _, created := range times {
+			comments = append(comments, ThreadComment{CreatedAt: created})
+		}
+		return ReviewThread{Comments: comments}
+	}
+
+	tests := []struct {
+		name   string
+		opts   FetchOptions
+		thread ReviewThread
+		want   bool
+	}{
+		{name: "no window", thread: thread("2020-01-01 00:00:00"), want: true},
+		{name: "late on the 9th in UTC is the 10th locally", opts: FetchOptions{Since: since}, thread: thread("2026-03-09 23:00:00"), want: true},
+		{name: "before local midnight", opts: FetchOptions{Since: since}, thread: thread("2026-03-09 21:59:59"), want: false},
+		{name: "end of the local day", opts: FetchOptions{Until: until}, thread: thread("2026-03-10 21:59:59"), want: true},
+		{name: "after the local day", opts: FetchOptions{Until: until}, thread: thread("2026-03-10 22:00:00"), want: false},
+		{name: "created before, active after", opts: FetchOptions{Since: since}, thread: thread("2026-03-01 00:00:00", "2026-03-10 09:00:00"), want: false},
+		{name: "activity", opts: FetchOptions{Since: since, TimeField: timeFieldActivity}, thread: thread("2026-03-01 00:00:00", "2026-03-10 09:00:00"), want: true},
+		{name: "no timestamps", opts: FetchOptions{Since: since}, thread: thread(""), want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.opts.keepThreadTime(tt.thread); got != tt.want {
+			t.Errorf("%s: kept = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestKeepGeneralCommentTime(t *testing.T) {
+	opts := FetchOptions{
+		Since: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Until: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := map[string]bool{
+		"2026-02-28 23:59:59": false,
+		"2026-03-01 00:00:00": true,
+		"2026-03-02 00:00:00": true,
+		"2026-03-02 00:00:01": false,
+		"not a time":          false,
+	}
+	for created, want := range tests {
+		if got := opts.keepGeneralCommentTime(GeneralComment{CreatedAt: created}); got != want {
+			t.Errorf("%s: kept = %v, want %v", created, got, want)
+		}
+	}
+}
+
+func TestFetchSinceCommit(t *testing.T) {
+	pushed := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	seed := fakeSeed()
+	widgets := seed.Repositories[0]
+	widgets.Commits = []*fakegithub.Commit{{SHA: "abc1234def", Date: pushed}}
+	threads := widgets.PullRequests[0].Threads
+	threads[0].Comments[0].CreatedAt = pushed.Add(-time.Hour)
+	threads[0].Comments[1].CreatedAt = pushed.Add(time.Hour)
+	threads[1].Comments[0].CreatedAt = pushed.Add(2 * time.Hour)
+	threads[2].Comments[0].CreatedAt = pushed.Add(-2 * time.Hour)
+
+	server, err := fakegithub.New(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client := NewGitHubGraphQLClientFromTokenSource(context.Background(), tokenSource("test"), httpServer.URL)
+	ref := PRRef{Owner: "octo-org", Repo: "widgets", Number: 1}
+
+	tests := []struct {
+		name      string
+		opts      FetchOptions
+		wantFiles []string
+		wantErr   bool
+	}{
+		{name: "created after the commit", opts: FetchOptions{SinceCommit: "abc1234"}, wantFiles: []string{"cache.go"}},
+		{name: "active after the commit", opts: FetchOptions{SinceCommit: "abc1234", TimeField: timeFieldActivity}, wantFiles: []string{"cache.go", "cache.go"}},
+		{name: "unknown commit", opts: FetchOptions{SinceCommit: "fff0000"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.IncludeResolved = true
+			opts.IncludeOutdated = true
+			response, err := fetchGraphQLResponse(context.Background(), client, ref, opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			var files []string
+			for _, thread := range response.ReviewThreads {
+				files = append(files, thread.File)
+			}
+			if !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("files = %v, want %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The config, profile and gh hosts files are YAML whose top level is a
+// mapping. Mappings are decoded as map[string]interface{}, lists of values as
+// []string and every value, whatever its YAML type, as the string it was
+// written as, since it ends up as a flag value; null is "". Lists of lists or
+// of mappings are not supported.
+
+// parseYAML parses a YAML document whose top level is a mapping
+func parseYAML(data []byte) (map[string]interface{}, error) {
+	var document yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&document); err != nil {
+		if errors.Is(err, io.EOF) {
+			return map[string]interface{}{}, nil
+		}
+		return nil, err
+	}
+
+	root := &document
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		return map[string]interface{}{}, nil
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping at the top level", root.Line)
+	}
+	return yamlMapping(root)
+}
+
+// yamlValue converts a node to a string, []string or map[string]interface{}
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		return yamlMapping(node)
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			text, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("line %d: lists may only hold values", item.Line)
+			}
+			items = append(items, text)
+		}
+		return items, nil
+	default:
+		if node.Tag == "!!null" {
+			return "", nil
+		}
+		return node.Value, nil
+	}
+}
+
+// yamlMapping converts a mapping node, rejecting duplicate keys
+func yamlMapping(node *yaml.Node) (map[string]interface{}, error) {
+	mapping := make(map[string]interface{}, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if keyNode.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("line %d: keys must be values", keyNode.Line)
+		}
+		key := keyNode.Value
+		if _, exists := mapping[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key %q", keyNode.Line, key)
+		}
+
+		value, err := yamlValue(node.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		mapping[key] = value
+	}
+	return mapping, nil
+}
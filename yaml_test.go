@@ -0,0 +1,95 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:  "empty",
+			input: "# nothing here\n",
+			want:  map[string]interface{}{},
+		},
+		{
+			name:  "values of every type are strings",
+			input: "format: json\ninclude-general: true\nconcurrency: 8\ncache-ttl: 2m\nempty:\nnull-value: ~\n",
+			want: map[string]interface{}{
+				"format": "json", "include-general": "true", "concurrency": "8",
+				"cache-ttl": "2m", "empty": "", "null-value": "",
+			},
+		},
+		{
+			name:  "list at the key's indentation",
+			input: "exclude-author:\n- renovate\n- \"sonar cloud\"\n",
+			want:  map[string]interface{}{"exclude-author": []string{"renovate", "sonar cloud"}},
+		},
+		{
+			name:  "indented and flow lists",
+			input: "fetch:\n  exclude-path:\n    - \"vendor/**\"\n    - '**/*.pb.go'\n  category: [blocking, question]\n",
+			want: map[string]interface{}{"fetch": map[string]interface{}{
+				"exclude-path": []string{"vendor/**", "**/*.pb.go"},
+				"category":     []string{"blocking", "question"},
+			}},
+		},
+		{
+			name:  "comments and quoting",
+			input: "grep: \"a # b\" # a comment\nquote: 'it''s'\n",
+			want:  map[string]interface{}{"grep": "a # b", "quote": "it's"},
+		},
+		{
+			name:  "block scalar and anchors",
+			input: "base: &base\n  format: json\nprofile: |\n  line one\n  line two\ncopy: *base\n",
+			want: map[string]interface{}{
+				"base":    map[string]interface{}{"format": "json"},
+				"profile": "line one\nline two\n",
+				"copy":    map[string]interface{}{"format": "json"},
+			},
+		},
+		{
+			name:    "duplicate key",
+			input:   "format: json\nformat: human\n",
+			wantErr: `duplicate key "format"`,
+		},
+		{
+			name:    "top level list",
+			input:   "- a\n- b\n",
+			wantErr: "expected a mapping",
+		},
+		{
+			name:    "list of mappings",
+			input:   "rules:\n  - name: a\n",
+			wantErr: "lists may only hold values",
+		},
+		{
+			name:    "bad indentation",
+			input:   "fetch:\n  format: json\n bad: x\n",
+			wantErr: "did not find expected key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML([]byte(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseYAML: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}